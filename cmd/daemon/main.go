@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"flag"
+	"net/http"
 	"os"
 	"time"
 
@@ -11,7 +12,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
 
 	daemon "github.com/tmax-cloud/virtualrouter-controller/internal/daemon"
@@ -19,6 +20,9 @@ import (
 	internalNetlink "github.com/tmax-cloud/virtualrouter-controller/internal/daemon/netlink"
 	clientset "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/clientset/versioned"
 	informers "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/informers/externalversions"
+	"github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/healthz"
+	"github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/httpserver"
+	"github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/metrics"
 	"github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/signals"
 	"github.com/tmax-cloud/virtualrouter-controller/internal/virtualroutermanager"
 )
@@ -26,23 +30,32 @@ import (
 var (
 	masterURL  string
 	kubeconfig string
+	nodeName   string
+
+	metricsBindAddress     string
+	healthProbeBindAddress string
 )
 
 func main() {
 
 	// internalCidr := flag.String("internalCidr", os.Getenv("internalCIDR"), "The InternalCIDR of the hosts")
 	// externalCidr := flag.String("externalCidr", os.Getenv("externalCIDR"), "The ExternalCIDR of the hosts")
-	nodeName := flag.String("nodeName", os.Getenv("nodeName"), "The nodeName of the hosts")
-	if nodeName == nil {
-		klog.Fatalf("Error node Name is empty")
-	}
 	klog.InitFlags(nil)
 	flag.Parse()
+	if nodeName == "" {
+		klog.Fatalf("Error node Name is empty. Set --node-name or the nodeName environment variable")
+	}
 
 	// set up signals so we handle the first shutdown signal gracefully
 	stopSignalCh := signals.SetupSignalHandler()
 	stopCh := make(chan struct{})
-	cfg, err := rest.InClusterConfig()
+
+	mux := http.NewServeMux()
+	mux.Handle(metrics.METRICS_PATH, metrics.Handler())
+	httpserver.Serve(metricsBindAddress, mux, stopSignalCh)
+
+	// falls back to the in-cluster config when neither flag is set
+	cfg, err := clientcmd.BuildConfigFromFlags(masterURL, kubeconfig)
 	if err != nil {
 		klog.Fatalf("Error building kubeconfig: %s", err.Error())
 	}
@@ -63,10 +76,10 @@ func main() {
 	kubeInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, time.Second*30, kubeinformers.WithTweakListOptions(func(opt *v1.ListOptions) {
 		// opt.LabelSelector = labels.Set(labelSelector.MatchLabels).String()
 		opt.LabelSelector = labelSelector.String()
-		opt.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", *nodeName).String()
+		opt.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
 	}))
 
-	myNode, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), *nodeName, v1.GetOptions{})
+	myNode, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), nodeName, v1.GetOptions{})
 	if err != nil {
 		klog.Error(err)
 		return
@@ -96,6 +109,10 @@ func main() {
 		ExternalBridgeName:          "extbr",
 	})
 
+	probeMux := http.NewServeMux()
+	healthz.InstallHandlers(probeMux, nil, []healthz.Checker{{Name: "network-daemon", Check: d.Ready}})
+	httpserver.Serve(healthProbeBindAddress, probeMux, stopSignalCh)
+
 	err = d.Start(stopSignalCh, stopCh)
 	if err != nil {
 		klog.Errorf("Error running network daemon: %s", err.Error())
@@ -119,4 +136,9 @@ func main() {
 func init() {
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
 	flag.StringVar(&masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
+	flag.StringVar(&metricsBindAddress, "metrics-bind-address", ":8082", "The address the metrics endpoint binds to. Set to 0 to disable it.")
+	flag.StringVar(&healthProbeBindAddress, "health-probe-bind-address", ":8083", "The address the /healthz and /readyz endpoints bind to. Set to 0 to disable them.")
+	flag.StringVar(&nodeName, "node-name", os.Getenv("nodeName"), "The name of the node the daemon runs on. Defaults to the nodeName environment variable.")
+	// Deprecated: kept for existing manifests, use --node-name
+	flag.StringVar(&nodeName, "nodeName", os.Getenv("nodeName"), "Deprecated: use --node-name.")
 }
@@ -17,13 +17,21 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/klog/v2"
 
 	// Uncomment the following line to load the gcp plugin (only required to authenticate against GKE clusters).
@@ -31,15 +39,37 @@ import (
 
 	clientset "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/clientset/versioned"
 	informers "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/informers/externalversions"
+	"github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/healthz"
+	"github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/httpserver"
+	"github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/metrics"
 	"github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/signals"
 	c1 "github.com/tmax-cloud/virtualrouter-controller/internal/virtualroutermanager"
+	"github.com/tmax-cloud/virtualrouter-controller/internal/webhook"
 )
 
 var (
 	masterURL  string
 	kubeconfig string
+
+	metricsBindAddress     string
+	healthProbeBindAddress string
+	webhookBindAddress     string
+	webhookCertDir         string
+	defaultRouterImage     string
+	installCRD             bool
+	routerRoleConfig       string
+	watchNamespaces        string
+
+	leaderElect              bool
+	leaderElectLeaseDuration time.Duration
+	leaderElectRenewDeadline time.Duration
+	leaderElectRetryPeriod   time.Duration
+	leaderElectNamespace     string
+	leaderElectResourceName  string
 )
 
+const LEADER_ELECTION_ID = "virtualrouter-controller"
+
 func main() {
 	klog.InitFlags(nil)
 	flag.Parse()
@@ -47,16 +77,20 @@ func main() {
 	// set up signals so we handle the first shutdown signal gracefully
 	stopCh := signals.SetupSignalHandler()
 
-	cfg, err := rest.InClusterConfig()
+	c1.RegisterMetrics()
+	mux := http.NewServeMux()
+	mux.Handle(metrics.METRICS_PATH, metrics.Handler())
+	httpserver.Serve(metricsBindAddress, mux, stopCh)
+
+	// falls back to the in-cluster config when neither flag is set
+	cfg, err := clientcmd.BuildConfigFromFlags(masterURL, kubeconfig)
+	if err != nil {
+		klog.Fatalf("Error building kubeconfig: %s", err.Error())
+	}
 
 	// ToDo: find out more graceful method
 	namespace := os.Getenv("POD_NAMESPACE")
 
-	// cfg, err := clientcmd.BuildConfigFromFlags(masterURL, kubeconfig)
-	// if err != nil {
-	// 	klog.Fatalf("Error building kubeconfig: %s", err.Error())
-	// }
-
 	kubeClient, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
 		klog.Fatalf("Error building kubernetes clientset: %s", err.Error())
@@ -67,25 +101,179 @@ func main() {
 		klog.Fatalf("Error building example clientset: %s", err.Error())
 	}
 
-	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Second*30)
+	apiextensionsClient, err := apiextensionsclientset.NewForConfig(cfg)
+	if err != nil {
+		klog.Fatalf("Error building apiextensions clientset: %s", err.Error())
+	}
+
+	// The CRDs have to be served before the informers list VirtualRouters
+	// and the pools.
+	if installCRD {
+		if err := c1.InstallCustomResourceDefinition(apiextensionsClient); err != nil {
+			klog.Fatalf("Error installing CustomResourceDefinition: %s", err.Error())
+		}
+	}
+
+	// Only the objects generated for VirtualRouters, which carry the owner
+	// labels, are cached
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, time.Second*30, kubeinformers.WithTweakListOptions(func(opt *metav1.ListOptions) {
+		// Selects the objects with the label, whatever its value
+		opt.LabelSelector = c1.VIRTUALROUTER_NAMESPACE_LABEL
+	}))
 	// exampleInformerFactory := informers.NewSharedInformerFactory(exampleClient, time.Second*30)
-	exampleInformerFactory := informers.NewFilteredSharedInformerFactory(exampleClient, time.Second*30, namespace, nil)
+	// The VirtualRouters of every namespace are cached, since their addresses
+	// and VLANs conflict with those of the watched namespaces. The controller
+	// only reconciles the VirtualRouters of the watched namespaces.
+	namespaces := parseWatchNamespaces(watchNamespaces, namespace)
+	exampleInformerFactory := informers.NewSharedInformerFactory(exampleClient, time.Second*30)
 
 	controller := c1.NewController(kubeClient, exampleClient,
 		kubeInformerFactory.Apps().V1().Deployments(),
-		exampleInformerFactory.Tmax().V1().VirtualRouters())
+		kubeInformerFactory.Apps().V1().StatefulSets(),
+		kubeInformerFactory.Policy().V1beta1().PodDisruptionBudgets(),
+		kubeInformerFactory.Core().V1().ServiceAccounts(),
+		kubeInformerFactory.Rbac().V1().Roles(),
+		kubeInformerFactory.Rbac().V1().RoleBindings(),
+		exampleInformerFactory.Tmax().V1().VirtualRouters(),
+		exampleInformerFactory.Tmax().V1().IPPools(),
+		exampleInformerFactory.Tmax().V1().VlanPools())
+
+	roleConfig, err := c1.LoadRouterRoleConfig(routerRoleConfig)
+	if err != nil {
+		klog.Fatalf("Error loading router role config: %s", err.Error())
+	}
+	controller.SetRouterRoleConfig(roleConfig)
+	controller.SetWatchNamespaces(namespaces)
+	if len(namespaces) == 0 {
+		klog.Info("Watching VirtualRouters in every namespace")
+	} else {
+		klog.Infof("Watching VirtualRouters in namespaces %v", namespaces)
+	}
+
+	// Every replica serves the probes and keeps its caches warm, so a standby
+	// is ready as soon as its caches have synced.
+	probeMux := http.NewServeMux()
+	healthz.InstallHandlers(probeMux, nil, []healthz.Checker{{Name: "informer-sync", Check: controller.Ready}})
+	httpserver.Serve(healthProbeBindAddress, probeMux, stopCh)
+
+	// Webhooks are served by every replica, not only the leader. Without a
+	// certificate the manager keeps reconciling and only the webhooks are off.
+	if err := webhook.Serve(webhookBindAddress, webhookCertDir, webhook.NewHandler(webhook.Defaults{Image: defaultRouterImage}), stopCh); err != nil {
+		klog.Errorf("Webhooks are disabled: %s", err.Error())
+	}
 
 	// notice that there is no need to run Start methods in a separate goroutine. (i.e. go kubeInformerFactory.Start(stopCh)
 	// Start method is non-blocking and runs all registered informers in a dedicated goroutine.
 	kubeInformerFactory.Start(stopCh)
 	exampleInformerFactory.Start(stopCh)
 
-	if err = controller.Run(2, stopCh); err != nil {
-		klog.Fatalf("Error running controller: %s", err.Error())
+	run := func(ctx context.Context) {
+		// Objects are converted by the webhooks, so the migration only
+		// completes once they are served.
+		if installCRD {
+			go c1.MigrateStorageVersion(apiextensionsClient, exampleClient, ctx.Done())
+		}
+		if err := controller.Run(2, ctx.Done()); err != nil {
+			klog.Fatalf("Error running controller: %s", err.Error())
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+
+	if !leaderElect {
+		run(ctx)
+		return
+	}
+
+	if leaderElectNamespace == "" {
+		leaderElectNamespace = namespace
+	}
+	if leaderElectNamespace == "" {
+		klog.Fatalf("--leader-elect-namespace is required when POD_NAMESPACE is not set")
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		klog.Fatalf("Error getting hostname: %s", err.Error())
+	}
+	id := hostname + "_" + string(uuid.NewUUID())
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      leaderElectResourceName,
+			Namespace: leaderElectNamespace,
+		},
+		Client: kubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: id,
+		},
+	}
+
+	// Only the leader runs the workers; the other replicas wait to take over
+	// the Lease.
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		ReleaseOnCancel: true,
+		LeaseDuration:   leaderElectLeaseDuration,
+		RenewDeadline:   leaderElectRenewDeadline,
+		RetryPeriod:     leaderElectRetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: run,
+			OnStoppedLeading: func() {
+				if ctx.Err() != nil {
+					klog.Infof("%s released leadership", id)
+					return
+				}
+				klog.Fatalf("%s lost leadership", id)
+			},
+			OnNewLeader: func(identity string) {
+				if identity != id {
+					klog.Infof("new leader elected: %s", identity)
+				}
+			},
+		},
+		Name: leaderElectResourceName,
+	})
+}
+
+// parseWatchNamespaces returns the namespaces of --watch-namespaces, or nil
+// for every namespace. Without the flag only the namespace of the manager is
+// watched.
+func parseWatchNamespaces(value string, podNamespace string) []string {
+	if value == "" {
+		value = podNamespace
+	}
+	var namespaces []string
+	for _, namespace := range strings.Split(value, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace == "*" {
+			return nil
+		}
+		if namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
 	}
+	return namespaces
 }
 
 func init() {
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
 	flag.StringVar(&masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
+	flag.StringVar(&metricsBindAddress, "metrics-bind-address", ":8080", "The address the metrics endpoint binds to. Set to 0 to disable it.")
+	flag.StringVar(&healthProbeBindAddress, "health-probe-bind-address", ":8081", "The address the /healthz and /readyz endpoints bind to. Set to 0 to disable them.")
+	flag.StringVar(&webhookBindAddress, "webhook-bind-address", "0", "The address the admission webhooks bind to, e.g. :9443. Set to 0 to disable them.")
+	flag.StringVar(&defaultRouterImage, "default-router-image", "", "Image set by the defaulting webhook on VirtualRouters without spec.image.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs", "Directory containing tls.crt and tls.key for the admission webhooks.")
+	flag.StringVar(&routerRoleConfig, "router-role-config", "", "Path to a YAML file with the rules of the Role of the router pods, per namespace rules and the rules VirtualRouters may add. Defaults to the rules the router image needs.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "", "Comma separated namespaces whose VirtualRouters are reconciled, or * for every namespace. Defaults to POD_NAMESPACE.")
+	flag.BoolVar(&installCRD, "install-crd", false, "Create or upgrade the VirtualRouter, IPPool and VlanPool CustomResourceDefinitions on startup.")
+	flag.BoolVar(&leaderElect, "leader-elect", true, "Elect a leader through a Lease before running the workers. Required when running more than one replica.")
+	flag.DurationVar(&leaderElectLeaseDuration, "leader-elect-lease-duration", 15*time.Second, "Duration non-leader candidates wait before trying to take over leadership.")
+	flag.DurationVar(&leaderElectRenewDeadline, "leader-elect-renew-deadline", 10*time.Second, "Duration the leader retries refreshing leadership before giving it up.")
+	flag.DurationVar(&leaderElectRetryPeriod, "leader-elect-retry-period", 2*time.Second, "Duration candidates wait between tries of acquiring or renewing leadership.")
+	flag.StringVar(&leaderElectNamespace, "leader-elect-namespace", "", "Namespace of the leader election Lease. Defaults to POD_NAMESPACE.")
+	flag.StringVar(&leaderElectResourceName, "leader-elect-resource-name", LEADER_ELECTION_ID, "Name of the leader election Lease.")
 }
@@ -5,10 +5,12 @@ module github.com/tmax-cloud/virtualrouter-controller
 go 1.15
 
 require (
+	github.com/evanphx/json-patch v4.9.0+incompatible
 	github.com/kr/text v0.2.0 // indirect
 	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e // indirect
 	github.com/onsi/ginkgo v1.14.1 // indirect
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.7.1
 	github.com/tmax-cloud/virtualrouter v0.0.0-20211029141731-b08c699a7893
 	github.com/vishvananda/netlink v1.1.1-0.20201029203352-d40f9887b852
 	github.com/vishvananda/netns v0.0.0-20210104183010-2eb08e3e575f
@@ -18,12 +20,14 @@ require (
 	gopkg.in/check.v1 v1.0.0-20200902074654-038fdea0a05b // indirect
 	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776 // indirect
 	k8s.io/api v0.20.6
+	k8s.io/apiextensions-apiserver v0.0.0
 	k8s.io/apimachinery v0.20.6
 	k8s.io/client-go v0.20.6
 	k8s.io/code-generator v0.19.15
 	k8s.io/cri-api v0.20.6
 	k8s.io/klog/v2 v2.8.0
 	k8s.io/kubernetes v1.19.0
+	sigs.k8s.io/yaml v1.2.0
 )
 
 replace (
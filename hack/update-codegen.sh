@@ -28,7 +28,7 @@ CODEGEN_PKG=~/workspace/k8s.io/code-generator
 #                  instead of the $GOPATH directly. For normal projects this can be dropped.
 bash "${CODEGEN_PKG}"/generate-groups.sh "deepcopy,client,informer,lister" \
   github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis \
-  networkcontroller:v1 \
+  networkcontroller:v1,v2 \
   --output-base ~/workspace \
   --go-header-file "${SCRIPT_ROOT}"/hack/boilerplate.go.txt
 
@@ -0,0 +1,75 @@
+#!/usr/bin/env bash
+
+# Regenerates deploy/integrated/virtualrouter-crd.yaml,
+# deploy/integrated/ippool-crd.yaml and deploy/integrated/vlanpool-crd.yaml from the kubebuilder markers in
+# internal/utils/pkg/apis and embeds the results into the manager so that
+# --install-crd applies the same manifests.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+SCRIPT_ROOT=$(dirname "${BASH_SOURCE[0]}")/..
+CONTROLLER_GEN=${CONTROLLER_GEN:-controller-gen}
+CRD_FILE="${SCRIPT_ROOT}"/deploy/integrated/virtualrouter-crd.yaml
+IPPOOL_CRD_FILE="${SCRIPT_ROOT}"/deploy/integrated/ippool-crd.yaml
+VLANPOOL_CRD_FILE="${SCRIPT_ROOT}"/deploy/integrated/vlanpool-crd.yaml
+CRD_GO_FILE="${SCRIPT_ROOT}"/internal/virtualroutermanager/zz_generated.crd.go
+
+CRD_DIR=$(mktemp -d)
+trap 'rm -rf "${CRD_DIR}"' EXIT
+
+cd "${SCRIPT_ROOT}"
+"${CONTROLLER_GEN}" crd:crdVersions=v1 \
+  paths=./internal/utils/pkg/apis/networkcontroller/... \
+  output:crd:dir="${CRD_DIR}"
+cd - > /dev/null
+cp "${CRD_DIR}"/tmax.hypercloud.com_ippools.yaml "${IPPOOL_CRD_FILE}"
+cp "${CRD_DIR}"/tmax.hypercloud.com_vlanpools.yaml "${VLANPOOL_CRD_FILE}"
+
+# v1 and v2 are converted by the manager's webhook (deploy/controller/webhook.yaml),
+# whose CA bundle cert-manager injects.
+awk '
+  /^  annotations:$/ && !annotated {
+    print
+    print "    cert-manager.io/inject-ca-from: virtualrouter/virtualrouter-webhook-cert"
+    annotated = 1
+    next
+  }
+  /^  group:/ {
+    print "  conversion:"
+    print "    strategy: Webhook"
+    print "    webhook:"
+    print "      clientConfig:"
+    print "        service:"
+    print "          name: virtualrouter-webhook"
+    print "          namespace: virtualrouter"
+    print "          path: /convert"
+    print "          port: 443"
+    print "      conversionReviewVersions:"
+    print "      - v1"
+  }
+  { print }
+' "${CRD_DIR}"/tmax.hypercloud.com_virtualrouters.yaml > "${CRD_FILE}"
+
+{
+  cat "${SCRIPT_ROOT}"/hack/boilerplate.go.txt
+  echo "// Code generated by hack/update-crd.sh. DO NOT EDIT."
+  echo
+  echo "package virtualroutermanager"
+  echo
+  echo "// VIRTUALROUTER_CRD is deploy/integrated/virtualrouter-crd.yaml."
+  printf 'const VIRTUALROUTER_CRD = `'
+  sed 's/`/` + "`" + `/g' "${CRD_FILE}"
+  echo '`'
+  echo
+  echo "// IPPOOL_CRD is deploy/integrated/ippool-crd.yaml."
+  printf 'const IPPOOL_CRD = `'
+  sed 's/`/` + "`" + `/g' "${IPPOOL_CRD_FILE}"
+  echo '`'
+  echo
+  echo "// VLANPOOL_CRD is deploy/integrated/vlanpool-crd.yaml."
+  printf 'const VLANPOOL_CRD = `'
+  sed 's/`/` + "`" + `/g' "${VLANPOOL_CRD_FILE}"
+  echo '`'
+} > "${CRD_GO_FILE}"
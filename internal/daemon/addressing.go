@@ -0,0 +1,148 @@
+package daemon
+
+import (
+	"fmt"
+
+	v1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+// errAddressPoolExhausted is returned by assignReplicaAddress when every
+// address of a pool is held by another router pod.
+var errAddressPoolExhausted = fmt.Errorf("no free address left in the replica address pool")
+
+// assignReplicaAddress returns assigned with an address of every pool of spec
+// assigned to podName. The addresses the pod already holds are kept as long as
+// they are still in the pool, so that the router pods are not renumbered.
+// A pod with an ordinal always gets the address of the pool at its ordinal.
+// Without ReplicaAddressing the pod holds no address.
+func assignReplicaAddress(spec *v1.VirtualRouterSpec, assigned []v1.ReplicaAddress, podName string, ordinal int) ([]v1.ReplicaAddress, error) {
+	if spec.ReplicaAddressing == nil {
+		return releaseReplicaAddress(assigned, podName), nil
+	}
+
+	current := v1.ReplicaAddress{Pod: podName}
+	index := -1
+	usedInternal := map[string]bool{}
+	usedExternal := map[string]bool{}
+	for i, address := range assigned {
+		if address.Pod == podName {
+			current = address
+			index = i
+			continue
+		}
+		usedInternal[address.InternalIP] = true
+		usedExternal[address.ExternalIP] = true
+	}
+
+	var err error
+	if current.InternalIP, err = pickAddress(spec.ReplicaAddressing.Internal, current.InternalIP, usedInternal, ordinal); err != nil {
+		return nil, err
+	}
+	if current.ExternalIP, err = pickAddress(spec.ReplicaAddressing.External, current.ExternalIP, usedExternal, ordinal); err != nil {
+		return nil, err
+	}
+
+	addresses := append([]v1.ReplicaAddress{}, assigned...)
+	if index < 0 {
+		return append(addresses, current), nil
+	}
+	addresses[index] = current
+	return addresses, nil
+}
+
+// pickAddress keeps current if it is a free address of pool, and otherwise
+// returns the first free one. A pool that is not set hands out no address.
+func pickAddress(pool *v1.AddressPool, current string, used map[string]bool, ordinal int) (string, error) {
+	if pool == nil {
+		return "", nil
+	}
+	candidates, err := pool.List()
+	if err != nil {
+		return "", err
+	}
+	if ordinal >= 0 {
+		if ordinal >= len(candidates) {
+			return "", fmt.Errorf("no address left in the replica address pool for ordinal %d", ordinal)
+		}
+		return candidates[ordinal], nil
+	}
+	for _, candidate := range candidates {
+		if candidate == current && !used[candidate] {
+			return current, nil
+		}
+	}
+	for _, candidate := range candidates {
+		if !used[candidate] {
+			return candidate, nil
+		}
+	}
+	return "", errAddressPoolExhausted
+}
+
+// releaseReplicaAddress returns assigned without the addresses of podName.
+func releaseReplicaAddress(assigned []v1.ReplicaAddress, podName string) []v1.ReplicaAddress {
+	var addresses []v1.ReplicaAddress
+	for _, address := range assigned {
+		if address.Pod != podName {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// replicaSpec returns the spec the router pod podName is configured with,
+// which carries the addresses and VLAN allocated from the pools of the spec,
+// and the addresses assigned to the pod in place of the shared ones.
+func replicaSpec(podName string, virtualRouter *v1.VirtualRouter) (v1.VirtualRouterSpec, error) {
+	spec, err := allocatedSpec(virtualRouter)
+	if err != nil || spec.ReplicaAddressing == nil {
+		return spec, err
+	}
+	for _, address := range virtualRouter.Status.ReplicaAddresses {
+		if address.Pod != podName {
+			continue
+		}
+		if address.InternalIP != "" {
+			spec.InternalIP = address.InternalIP
+		}
+		if address.ExternalIP != "" {
+			spec.ExternalIP = address.ExternalIP
+		}
+		return spec, nil
+	}
+	return spec, fmt.Errorf("no address assigned to pod %q", podName)
+}
+
+// allocatedSpec returns the spec of virtualRouter with the addresses the
+// manager allocated from the IPPools of the spec, and the VLAN it allocated
+// from the VlanPool of the spec, filled in.
+func allocatedSpec(virtualRouter *v1.VirtualRouter) (v1.VirtualRouterSpec, error) {
+	spec := *virtualRouter.Spec.DeepCopy()
+	for _, allocation := range virtualRouter.Status.IPAllocations {
+		switch {
+		case allocation.Interface == v1.IPAllocationInternal && allocation.Pool == spec.InternalIPPool:
+			spec.InternalIP = allocation.Address
+			spec.InternalNetmask = allocation.Netmask
+		case allocation.Interface == v1.IPAllocationExternal && allocation.Pool == spec.ExternalIPPool:
+			spec.ExternalIP = allocation.Address
+			spec.ExternalNetmask = allocation.Netmask
+			if spec.GatewayIP == "" {
+				spec.GatewayIP = allocation.Gateway
+			}
+		}
+	}
+	if spec.VlanNumber == 0 && spec.VlanPool != "" {
+		allocation := virtualRouter.Status.VlanAllocation
+		if allocation == nil || allocation.Pool != spec.VlanPool {
+			return spec, fmt.Errorf("no VLAN allocated from VlanPool %q yet", spec.VlanPool)
+		}
+		spec.VlanNumber = allocation.VlanNumber
+	}
+	if spec.InternalIPPool != "" && spec.InternalIP == "" {
+		return spec, fmt.Errorf("no internal address allocated from IPPool %q yet", spec.InternalIPPool)
+	}
+	if spec.ExternalIPPool != "" && spec.ExternalIP == "" {
+		return spec, fmt.Errorf("no external address allocated from IPPool %q yet", spec.ExternalIPPool)
+	}
+	return spec, nil
+}
@@ -0,0 +1,167 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+func newReplicaAddressingSpec() *v1.VirtualRouterSpec {
+	return &v1.VirtualRouterSpec{
+		InternalIP: "10.10.10.11",
+		ExternalIP: "192.168.8.153",
+		ReplicaAddressing: &v1.ReplicaAddressing{
+			Internal: &v1.AddressPool{Range: "10.10.10.20-10.10.10.21"},
+		},
+	}
+}
+
+func TestAssignReplicaAddress(t *testing.T) {
+	spec := newReplicaAddressingSpec()
+
+	assigned, err := assignReplicaAddress(spec, nil, "pod-a", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assigned, err = assignReplicaAddress(spec, assigned, "pod-b", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []v1.ReplicaAddress{
+		{Pod: "pod-a", InternalIP: "10.10.10.20"},
+		{Pod: "pod-b", InternalIP: "10.10.10.21"},
+	}
+	if !reflect.DeepEqual(assigned, expected) {
+		t.Fatalf("expected %v, got %v", expected, assigned)
+	}
+
+	// Claiming again keeps the addresses
+	if again, err := assignReplicaAddress(spec, assigned, "pod-a", -1); err != nil || !reflect.DeepEqual(again, expected) {
+		t.Errorf("expected %v, got %v (%v)", expected, again, err)
+	}
+
+	if _, err := assignReplicaAddress(spec, assigned, "pod-c", -1); err != errAddressPoolExhausted {
+		t.Errorf("expected the pool to be exhausted, got %v", err)
+	}
+
+	// A released address is handed out again
+	assigned, err = assignReplicaAddress(spec, releaseReplicaAddress(assigned, "pod-a"), "pod-c", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []v1.ReplicaAddress{
+		{Pod: "pod-b", InternalIP: "10.10.10.21"},
+		{Pod: "pod-c", InternalIP: "10.10.10.20"},
+	}
+	if !reflect.DeepEqual(assigned, expected) {
+		t.Errorf("expected %v, got %v", expected, assigned)
+	}
+
+	// An address removed from the pool is replaced
+	spec.ReplicaAddressing.Internal = &v1.AddressPool{Addresses: []string{"10.10.10.21", "10.10.10.30"}}
+	assigned, err = assignReplicaAddress(spec, assigned, "pod-c", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assigned[1].InternalIP != "10.10.10.30" {
+		t.Errorf("expected pod-c to move to 10.10.10.30, got %v", assigned)
+	}
+}
+
+func TestReplicaSpec(t *testing.T) {
+	virtualRouter := &v1.VirtualRouter{
+		Spec: *newReplicaAddressingSpec(),
+		Status: v1.VirtualRouterStatus{
+			ReplicaAddresses: []v1.ReplicaAddress{{Pod: "pod-a", InternalIP: "10.10.10.20"}},
+		},
+	}
+
+	spec, err := replicaSpec("pod-a", virtualRouter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.InternalIP != "10.10.10.20" || spec.ExternalIP != "192.168.8.153" {
+		t.Errorf("expected the internal address of pod-a and the shared external address, got %s and %s", spec.InternalIP, spec.ExternalIP)
+	}
+
+	if _, err := replicaSpec("pod-b", virtualRouter); err == nil {
+		t.Error("expected an error for a pod without addresses")
+	}
+
+	virtualRouter.Spec.ReplicaAddressing = nil
+	if spec, err := replicaSpec("pod-b", virtualRouter); err != nil || spec.InternalIP != "10.10.10.11" {
+		t.Errorf("expected the shared internal address, got %s (%v)", spec.InternalIP, err)
+	}
+}
+
+func TestAssignReplicaAddressByOrdinal(t *testing.T) {
+	spec := newReplicaAddressingSpec()
+
+	// The ordinal decides, even for the first pod to claim an address
+	assigned, err := assignReplicaAddress(spec, nil, "router-1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []v1.ReplicaAddress{{Pod: "router-1", InternalIP: "10.10.10.21"}}; !reflect.DeepEqual(assigned, expected) {
+		t.Errorf("expected %v, got %v", expected, assigned)
+	}
+
+	if _, err := assignReplicaAddress(spec, assigned, "router-2", 2); err == nil {
+		t.Error("expected an error for an ordinal beyond the pool")
+	}
+}
+
+func TestAllocatedSpec(t *testing.T) {
+	virtualRouter := &v1.VirtualRouter{
+		Spec: v1.VirtualRouterSpec{
+			InternalIP:      "10.10.10.11",
+			InternalNetmask: "255.255.255.0",
+			ExternalIPPool:  "external",
+		},
+	}
+	if _, err := allocatedSpec(virtualRouter); err == nil {
+		t.Error("expected an error before the external address is allocated")
+	}
+
+	virtualRouter.Status.IPAllocations = []v1.IPAllocation{
+		{Interface: v1.IPAllocationExternal, Pool: "external", Address: "192.168.8.10", Netmask: "255.255.254.0", Gateway: "192.168.8.1"},
+	}
+	spec, err := allocatedSpec(virtualRouter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.InternalIP != "10.10.10.11" || spec.ExternalIP != "192.168.8.10" || spec.ExternalNetmask != "255.255.254.0" || spec.GatewayIP != "192.168.8.1" {
+		t.Errorf("expected the external addressing of the allocation, got %+v", spec)
+	}
+
+	// An allocation from a pool the spec no longer references is not used
+	virtualRouter.Spec.ExternalIPPool = "other"
+	if _, err := allocatedSpec(virtualRouter); err == nil {
+		t.Error("expected an error for an allocation from another pool")
+	}
+}
+
+func TestAllocatedSpecVlan(t *testing.T) {
+	virtualRouter := &v1.VirtualRouter{
+		Spec: v1.VirtualRouterSpec{
+			InternalIP: "10.10.10.11",
+			ExternalIP: "192.168.8.153",
+			VlanPool:   "tenants",
+		},
+	}
+	if _, err := allocatedSpec(virtualRouter); err == nil {
+		t.Error("expected an error before the VLAN is allocated")
+	}
+
+	virtualRouter.Status.VlanAllocation = &v1.VlanAllocation{Pool: "tenants", VlanNumber: 120}
+	if spec, err := allocatedSpec(virtualRouter); err != nil || spec.VlanNumber != 120 {
+		t.Errorf("expected VLAN 120, got %d (%v)", spec.VlanNumber, err)
+	}
+
+	// A VLAN set in the spec is only claimed from the pool
+	virtualRouter.Spec.VlanNumber = 210
+	if spec, err := allocatedSpec(virtualRouter); err != nil || spec.VlanNumber != 210 {
+		t.Errorf("expected VLAN 210, got %d (%v)", spec.VlanNumber, err)
+	}
+}
@@ -0,0 +1,98 @@
+package daemon
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+// PodAttachment returns the interfaces the daemon set up for the router pod
+// and the settings applied to them. Only Pod is set if the pod is not
+// attached.
+func (n *NetworkDaemon) PodAttachment(pod *corev1.Pod) v1.PodAttachment {
+	attachment := v1.PodAttachment{Pod: pod.Name}
+	desc, exist := n.pod2containerMap[pod.Namespace+"/"+pod.Name]
+	if !exist {
+		return attachment
+	}
+	attachment.ContainerID = desc.containerID
+	attachment.InternalInterface = "int" + desc.interfaceID()
+	attachment.ExternalInterface = "ext" + desc.interfaceID()
+	if spec, exist := n.runnigState[desc.pod]; exist {
+		attachment.VlanNumber = spec.VlanNumber
+		attachment.InternalIP = spec.InternalIP
+		attachment.ExternalIP = spec.ExternalIP
+		attachment.GatewayIP = spec.GatewayIP
+	}
+	return attachment
+}
+
+// setPodAttachment replaces the attachment of the same pod in attachments.
+// LastUpdateTime is only moved to now when the attachment changed, so that
+// resyncs do not rewrite the status.
+func setPodAttachment(attachments []v1.PodAttachment, attachment v1.PodAttachment, now metav1.Time) []v1.PodAttachment {
+	result := []v1.PodAttachment{}
+	for _, current := range attachments {
+		if current.Pod != attachment.Pod {
+			result = append(result, current)
+			continue
+		}
+		attachment.LastUpdateTime = current.LastUpdateTime
+		if equality.Semantic.DeepEqual(current, attachment) {
+			return attachments
+		}
+	}
+	attachment.LastUpdateTime = now
+	return append(result, attachment)
+}
+
+// removePodAttachment removes the attachment of the router pod podName.
+func removePodAttachment(attachments []v1.PodAttachment, podName string) []v1.PodAttachment {
+	var result []v1.PodAttachment
+	for _, attachment := range attachments {
+		if attachment.Pod != podName {
+			result = append(result, attachment)
+		}
+	}
+	return result
+}
+
+// networkAttachedCondition derives the NetworkAttached condition of
+// virtualRouter from the attachments of all of its router pods, whichever
+// node they run on: it is True once as many pods as replicas are attached
+// and False as long as one of them failed.
+func networkAttachedCondition(virtualRouter *v1.VirtualRouter) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               v1.ConditionNetworkAttached,
+		ObservedGeneration: virtualRouter.Generation,
+	}
+	replicas := 1
+	if virtualRouter.Spec.Replicas != nil {
+		replicas = int(*virtualRouter.Spec.Replicas)
+	}
+	attached := 0
+	for _, attachment := range virtualRouter.Status.PodAttachments {
+		if attachment.LastError != "" {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = ReasonAttachFailed
+			condition.Message = fmt.Sprintf(MessageAttachFailed, attachment.Pod, attachment.LastError)
+			return condition
+		}
+		if attachment.ContainerID != "" {
+			attached++
+		}
+	}
+	condition.Message = fmt.Sprintf(MessageAttached, attached, replicas)
+	if attached >= replicas {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ReasonAttached
+	} else {
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = ReasonAttaching
+	}
+	return condition
+}
@@ -0,0 +1,92 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+func TestPodAttachment(t *testing.T) {
+	n := NewDaemon(nil, nil)
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "router-abc", Namespace: "default-vr1"}}
+	if attachment := n.PodAttachment(pod); !reflect.DeepEqual(attachment, v1.PodAttachment{Pod: "router-abc"}) {
+		t.Errorf("expected only the pod of a detached pod, got %+v", attachment)
+	}
+
+	n.pod2containerMap["default-vr1/router-abc"] = &containerDesc{pod: "default-vr1/router-abc", virtualRouter: "default/vr1", containerID: "0123456789abcdef"}
+	n.runnigState["default-vr1/router-abc"] = &v1.VirtualRouterSpec{VlanNumber: 210, InternalIP: "10.10.10.11", ExternalIP: "192.168.8.153", GatewayIP: "192.168.8.1"}
+	expected := v1.PodAttachment{
+		Pod:               "router-abc",
+		ContainerID:       "0123456789abcdef",
+		InternalInterface: "int0123456",
+		ExternalInterface: "ext0123456",
+		VlanNumber:        210,
+		InternalIP:        "10.10.10.11",
+		ExternalIP:        "192.168.8.153",
+		GatewayIP:         "192.168.8.1",
+	}
+	if attachment := n.PodAttachment(pod); !reflect.DeepEqual(attachment, expected) {
+		t.Errorf("expected %+v, got %+v", expected, attachment)
+	}
+}
+
+func TestSetPodAttachment(t *testing.T) {
+	before := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	now := metav1.NewTime(time.Now().Truncate(time.Second))
+	other := v1.PodAttachment{Pod: "router-b", Node: "node2", LastUpdateTime: before}
+	current := v1.PodAttachment{Pod: "router-a", Node: "node1", InternalIP: "10.10.10.20", LastUpdateTime: before}
+	attachments := []v1.PodAttachment{current, other}
+
+	// An unchanged attachment keeps its timestamp
+	unchanged := current
+	unchanged.LastUpdateTime = metav1.Time{}
+	if result := setPodAttachment(attachments, unchanged, now); !reflect.DeepEqual(result, attachments) {
+		t.Errorf("expected %+v, got %+v", attachments, result)
+	}
+
+	failed := unchanged
+	failed.LastError = "no running container found"
+	expectedFailed := failed
+	expectedFailed.LastUpdateTime = now
+	expected := []v1.PodAttachment{other, expectedFailed}
+	if result := setPodAttachment(attachments, failed, now); !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %+v, got %+v", expected, result)
+	}
+
+	if result := removePodAttachment(expected, "router-a"); !reflect.DeepEqual(result, []v1.PodAttachment{other}) {
+		t.Errorf("expected only %s, got %+v", other.Pod, result)
+	}
+}
+
+func TestNetworkAttachedCondition(t *testing.T) {
+	replicas := int32(2)
+	virtualRouter := &v1.VirtualRouter{Spec: v1.VirtualRouterSpec{Replicas: &replicas}}
+	attachedA := v1.PodAttachment{Pod: "router-a", Node: "node1", ContainerID: "aaaaaaaaaaaa"}
+	attachedB := v1.PodAttachment{Pod: "router-b", Node: "node2", ContainerID: "bbbbbbbbbbbb"}
+	failedB := v1.PodAttachment{Pod: "router-b", Node: "node2", LastError: "no running container found"}
+
+	testCases := []struct {
+		name        string
+		attachments []v1.PodAttachment
+		status      metav1.ConditionStatus
+		reason      string
+		message     string
+	}{
+		{"no pod attached", nil, metav1.ConditionUnknown, ReasonAttaching, "0 of 2 router pods attached"},
+		{"one of two replicas", []v1.PodAttachment{attachedA}, metav1.ConditionUnknown, ReasonAttaching, "1 of 2 router pods attached"},
+		{"every replica", []v1.PodAttachment{attachedA, attachedB}, metav1.ConditionTrue, ReasonAttached, "2 of 2 router pods attached"},
+		{"one replica failed", []v1.PodAttachment{attachedA, failedB}, metav1.ConditionFalse, ReasonAttachFailed, `Router pod "router-b": no running container found`},
+	}
+	for _, tc := range testCases {
+		virtualRouter.Status.PodAttachments = tc.attachments
+		condition := networkAttachedCondition(virtualRouter)
+		if condition.Status != tc.status || condition.Reason != tc.reason || condition.Message != tc.message {
+			t.Errorf("%s: expected %s %s %q, got %s %s %q", tc.name, tc.status, tc.reason, tc.message, condition.Status, condition.Reason, condition.Message)
+		}
+	}
+}
@@ -22,8 +22,11 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 
@@ -34,9 +37,11 @@ import (
 	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	networkv1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
 	clientset "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/clientset/versioned"
 	samplescheme "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/clientset/versioned/scheme"
 	informers "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/informers/externalversions/networkcontroller/v1"
@@ -70,6 +75,48 @@ const (
 	MessageResourceSynced = "VirtualRouter synced successfully"
 )
 
+const (
+	// ReasonAttached is used in the NetworkAttached condition when the
+	// interfaces of every router pod are wired up
+	ReasonAttached = "Attached"
+	// ReasonAttaching is used in the NetworkAttached condition while some
+	// router pods are not attached yet
+	ReasonAttaching = "Attaching"
+	// ReasonAttachFailed is used in the NetworkAttached condition when the
+	// daemon failed to wire up a router pod
+	ReasonAttachFailed = "AttachFailed"
+
+	MessageAttached     = "%d of %d router pods attached"
+	MessageAttachFailed = "Router pod %q: %s"
+)
+
+// Event reasons and messages of the steps of wiring up a router pod. They
+// are recorded on both the router pod and its VirtualRouter.
+const (
+	ReasonInterfacesAttached     = "InterfacesAttached"
+	ReasonInterfacesAttachFailed = "InterfacesAttachFailed"
+	ReasonVlanAssigned           = "VlanAssigned"
+	ReasonVlanAssignFailed       = "VlanAssignFailed"
+	ReasonIPAssigned             = "IPAssigned"
+	ReasonIPAssignFailed         = "IPAssignFailed"
+	ReasonDefaultRouteSet        = "DefaultRouteSet"
+	ReasonDefaultRouteFailed     = "DefaultRouteFailed"
+	ReasonDetached               = "Detached"
+	ReasonDetachFailed           = "DetachFailed"
+
+	MessageInterfacesAttached     = "Attached host veths %s and %s to pod %s"
+	MessageInterfacesAttachFailed = "Attaching the interfaces of pod %s failed"
+	MessageVlanAssigned           = "Assigned VLAN %d to the internal interface"
+	MessageVlanRemoved            = "Removed the VLAN of the internal interface"
+	MessageVlanAssignFailed       = "Assigning VLAN %d failed"
+	MessageIPAssigned             = "Assigned %s address %s/%s"
+	MessageIPAssignFailed         = "Assigning %s address %s failed"
+	MessageDefaultRouteSet        = "Set default route via %s"
+	MessageDefaultRouteFailed     = "Setting default route via %s failed"
+	MessageDetached               = "Detached pod %s"
+	MessageDetachFailed           = "Detaching pod %s failed"
+)
+
 type podKey string
 type virtualrouterKey string
 
@@ -234,7 +281,12 @@ func (c *Controller) syncHandler(obj interface{}) error {
 			return err
 		}
 		if !virtualRouterPod.DeletionTimestamp.IsZero() {
-			if err := c.networkDaemon.DettachingPod(name); err != nil {
+			virtualRouterCR, _ := c.virtualRouterOf(virtualRouterPod)
+			if err := c.networkDaemon.DettachingPod(string(key), c.recordStep(virtualRouterPod, virtualRouterCR)); err != nil {
+				return err
+			}
+			c.forgetPodAttachment(virtualRouterPod)
+			if err := c.releasePodAddress(virtualRouterPod); err != nil {
 				return err
 			}
 			if err := c.deleteFinalizer(name, virtualRouterPod); err != nil {
@@ -259,10 +311,20 @@ func (c *Controller) syncHandler(obj interface{}) error {
 			return err
 		}
 
-		if err := c.networkDaemon.AttachingPod(name, virtualRouterCR); err != nil {
+		claimed, err := c.claimPodAddress(virtualRouterCR, name)
+		if err != nil {
+			klog.ErrorS(err, "Claiming replica address failed", "pod", name)
+			c.reportPodAttachment(virtualRouterCR, virtualRouterPod, err)
+			return err
+		}
+		virtualRouterCR = claimed
+
+		if err := c.networkDaemon.AttachingPod(virtualRouterPod, virtualRouterCR, c.recordStep(virtualRouterPod, virtualRouterCR)); err != nil {
 			klog.ErrorS(err, "Sync failed")
+			c.reportPodAttachment(virtualRouterCR, virtualRouterPod, err)
 			return err
 		}
+		c.reportPodAttachment(virtualRouterCR, virtualRouterPod, nil)
 
 		klog.Infof("Successfully synced '%s'", string(key))
 
@@ -284,9 +346,19 @@ func (c *Controller) syncHandler(obj interface{}) error {
 			return err
 		}
 
-		if err := c.networkDaemon.Sync(name, virtualRouterCR.Spec); err != nil {
-			klog.ErrorS(err, "Sync failed")
-			return err
+		// Every router pod of the VirtualRouter on this node gets the new spec
+		var errs []error
+		for _, podKey := range c.networkDaemon.AttachedPods(virtualRouterCR) {
+			claimed, err := c.syncAttachedPod(virtualRouterCR, podKey)
+			if err != nil {
+				klog.ErrorS(err, "Sync failed", "pod", podKey)
+				errs = append(errs, err)
+				continue
+			}
+			virtualRouterCR = claimed
+		}
+		if len(errs) != 0 {
+			return utilerrors.NewAggregate(errs)
 		}
 
 		klog.Infof("Successfully synced '%s'", string(key))
@@ -294,6 +366,35 @@ func (c *Controller) syncHandler(obj interface{}) error {
 	return nil
 }
 
+// syncAttachedPod applies the spec of virtualRouter to its attached router
+// pod podKey, and returns the VirtualRouter with the addresses claimed for
+// the pod.
+func (c *Controller) syncAttachedPod(virtualRouter *networkv1.VirtualRouter, podKey string) (*networkv1.VirtualRouter, error) {
+	namespace, podName, err := cache.SplitMetaNamespaceKey(podKey)
+	if err != nil {
+		return virtualRouter, err
+	}
+	claimed, err := c.claimPodAddress(virtualRouter, podName)
+	if err != nil {
+		klog.ErrorS(err, "Claiming replica address failed", "pod", podKey)
+		return virtualRouter, err
+	}
+	spec, err := replicaSpec(podName, claimed)
+	if err != nil {
+		return claimed, err
+	}
+
+	pod, err := c.podLister.Pods(namespace).Get(podName)
+	if err != nil {
+		pod = nil
+	}
+	syncErr := c.networkDaemon.Sync(podKey, spec, c.recordStep(pod, claimed))
+	if pod != nil {
+		c.reportPodAttachment(claimed, pod, syncErr)
+	}
+	return claimed, syncErr
+}
+
 // enqueueVirtualRouter takes a VirtualRouter resource and converts it into a namespace/name
 // string which is then put onto the work queue. This method should *not* be
 // passed resources of any type other than VirtualRouter.
@@ -330,6 +431,187 @@ func (c *Controller) deleteFinalizer(podName string, virtualrouterPod *corev1.Po
 	return nil
 }
 
+// recordStep returns a StepFunc recording the steps of wiring up a router
+// pod as Events of the pod and of its VirtualRouter. Either may be nil.
+func (c *Controller) recordStep(virtualRouterPod *corev1.Pod, virtualRouter *networkv1.VirtualRouter) StepFunc {
+	return func(reason string, message string, err error) {
+		eventtype := corev1.EventTypeNormal
+		if err != nil {
+			eventtype = corev1.EventTypeWarning
+			message = fmt.Sprintf("%s: %v", message, err)
+		}
+		if virtualRouterPod != nil {
+			c.recorder.Event(virtualRouterPod, eventtype, reason, message)
+		}
+		if virtualRouter != nil {
+			c.recorder.Event(virtualRouter, eventtype, reason, message)
+		}
+	}
+}
+
+// reportPodAttachment publishes the attachment of a router pod in the status
+// of its VirtualRouter, along with attachErr if attaching the pod failed.
+// Failures are only logged since the attachment is informational.
+func (c *Controller) reportPodAttachment(virtualRouter *networkv1.VirtualRouter, virtualRouterPod *corev1.Pod, attachErr error) {
+	attachment := c.networkDaemon.PodAttachment(virtualRouterPod)
+	attachment.Node = virtualRouterPod.Spec.NodeName
+	if attachErr != nil {
+		attachment.LastError = attachErr.Error()
+	}
+	err := c.updatePodAttachments(virtualRouter, func(attachments []networkv1.PodAttachment) []networkv1.PodAttachment {
+		return setPodAttachment(attachments, attachment, v1.Now())
+	})
+	if err != nil {
+		klog.ErrorS(err, "Reporting pod attachment failed", "virtualRouter", virtualRouter.Name, "pod", virtualRouterPod.Name)
+	}
+}
+
+// forgetPodAttachment removes the attachment of a deleted router pod from
+// the status of its VirtualRouter.
+func (c *Controller) forgetPodAttachment(virtualRouterPod *corev1.Pod) {
+	virtualRouterCR, err := c.virtualRouterOf(virtualRouterPod)
+	if virtualRouterCR == nil || err != nil {
+		return
+	}
+	err = c.updatePodAttachments(virtualRouterCR, func(attachments []networkv1.PodAttachment) []networkv1.PodAttachment {
+		return removePodAttachment(attachments, virtualRouterPod.Name)
+	})
+	if err != nil && !errors.IsNotFound(err) {
+		klog.ErrorS(err, "Removing pod attachment failed", "virtualRouter", virtualRouterCR.Name, "pod", virtualRouterPod.Name)
+	}
+}
+
+// updatePodAttachments writes the pod attachments computed by update from
+// the latest VirtualRouter through the status subresource, along with the
+// NetworkAttached condition derived from them. The daemons of every node
+// report into the same list, hence the retry on conflict.
+func (c *Controller) updatePodAttachments(virtualRouter *networkv1.VirtualRouter, update func([]networkv1.PodAttachment) []networkv1.PodAttachment) error {
+	latest := virtualRouter
+	firstTry := true
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if !firstTry {
+			var err error
+			latest, err = c.sampleclientset.TmaxV1().VirtualRouters(virtualRouter.Namespace).Get(context.TODO(), virtualRouter.Name, v1.GetOptions{})
+			if err != nil {
+				return err
+			}
+		}
+		firstTry = false
+
+		virtualRouterCopy := latest.DeepCopy()
+		virtualRouterCopy.Status.PodAttachments = update(latest.Status.PodAttachments)
+		meta.SetStatusCondition(&virtualRouterCopy.Status.Conditions, networkAttachedCondition(virtualRouterCopy))
+		if equality.Semantic.DeepEqual(latest.Status, virtualRouterCopy.Status) {
+			return nil
+		}
+		_, err := c.sampleclientset.TmaxV1().VirtualRouters(virtualRouter.Namespace).UpdateStatus(context.TODO(), virtualRouterCopy, v1.UpdateOptions{})
+		return err
+	})
+}
+
+// virtualRouterOf returns the VirtualRouter of a router pod, or nil if it is
+// gone.
+func (c *Controller) virtualRouterOf(virtualRouterPod *corev1.Pod) (*networkv1.VirtualRouter, error) {
+	crName := virtualRouterPod.GetAnnotations()["customresourceName"]
+	crNS := virtualRouterPod.GetAnnotations()["customresourceNamespace"]
+	if crName == "" || crNS == "" {
+		return nil, nil
+	}
+	virtualRouterCR, err := c.virtualRoutersLister.VirtualRouters(crNS).Get(crName)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	return virtualRouterCR, err
+}
+
+// claimPodAddress assigns the router pod podName its replica addresses and
+// returns the updated VirtualRouter. Once a pool is exhausted, the addresses
+// held by router pods which no longer exist are reclaimed. The pods of a
+// StatefulSet get the addresses at their ordinal.
+func (c *Controller) claimPodAddress(virtualRouter *networkv1.VirtualRouter, podName string) (*networkv1.VirtualRouter, error) {
+	return c.updateReplicaAddresses(virtualRouter, func(latest *networkv1.VirtualRouter) ([]networkv1.ReplicaAddress, error) {
+		ordinal := podOrdinal(latest, podName)
+		addresses, err := assignReplicaAddress(&latest.Spec, latest.Status.ReplicaAddresses, podName, ordinal)
+		if err != errAddressPoolExhausted {
+			return addresses, err
+		}
+		live, err := c.liveReplicaAddresses(latest)
+		if err != nil {
+			return nil, err
+		}
+		return assignReplicaAddress(&latest.Spec, live, podName, ordinal)
+	})
+}
+
+// releasePodAddress gives the replica addresses of a deleted router pod back
+// to the pools of its VirtualRouter.
+func (c *Controller) releasePodAddress(virtualRouterPod *corev1.Pod) error {
+	virtualRouterCR, err := c.virtualRouterOf(virtualRouterPod)
+	if virtualRouterCR == nil || err != nil {
+		return err
+	}
+	_, err = c.updateReplicaAddresses(virtualRouterCR, func(latest *networkv1.VirtualRouter) ([]networkv1.ReplicaAddress, error) {
+		return releaseReplicaAddress(latest.Status.ReplicaAddresses, virtualRouterPod.Name), nil
+	})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// liveReplicaAddresses returns the replica addresses of virtualRouter whose
+// router pod still exists.
+func (c *Controller) liveReplicaAddresses(virtualRouter *networkv1.VirtualRouter) ([]networkv1.ReplicaAddress, error) {
+	var addresses []networkv1.ReplicaAddress
+	for _, address := range virtualRouter.Status.ReplicaAddresses {
+		_, err := c.kubeclientset.CoreV1().Pods(virtualRouter.Status.Namespace).Get(context.TODO(), address.Pod, v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
+
+// updateReplicaAddresses writes the replica addresses computed by update from
+// the latest VirtualRouter through the status subresource, retrying on
+// conflict so that daemons on different nodes never hand out the same
+// address. It returns the updated VirtualRouter.
+func (c *Controller) updateReplicaAddresses(virtualRouter *networkv1.VirtualRouter, update func(*networkv1.VirtualRouter) ([]networkv1.ReplicaAddress, error)) (*networkv1.VirtualRouter, error) {
+	latest := virtualRouter
+	firstTry := true
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if !firstTry {
+			var err error
+			latest, err = c.sampleclientset.TmaxV1().VirtualRouters(virtualRouter.Namespace).Get(context.TODO(), virtualRouter.Name, v1.GetOptions{})
+			if err != nil {
+				return err
+			}
+		}
+		firstTry = false
+
+		addresses, err := update(latest)
+		if err != nil {
+			return err
+		}
+		if equality.Semantic.DeepEqual(latest.Status.ReplicaAddresses, addresses) {
+			return nil
+		}
+		virtualRouterCopy := latest.DeepCopy()
+		virtualRouterCopy.Status.ReplicaAddresses = addresses
+		updated, err := c.sampleclientset.TmaxV1().VirtualRouters(virtualRouter.Namespace).UpdateStatus(context.TODO(), virtualRouterCopy, v1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		latest = updated
+		return nil
+	})
+	return latest, err
+}
+
 func containsString(slice []string, s string) bool {
 	for _, item := range slice {
 		if item == s {
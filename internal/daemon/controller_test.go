@@ -0,0 +1,112 @@
+package daemon
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	networkv1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+	"github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/clientset/versioned/fake"
+	listers "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/listers/networkcontroller/v1"
+)
+
+func TestRecordStep(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	c := &Controller{recorder: recorder}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "router-abc", Namespace: "default-vr1"}}
+	virtualRouter := &networkv1.VirtualRouter{ObjectMeta: metav1.ObjectMeta{Name: "vr1", Namespace: "default"}}
+
+	step := c.recordStep(pod, virtualRouter)
+	step(ReasonVlanAssigned, fmt.Sprintf(MessageVlanAssigned, 210), nil)
+	step(ReasonDefaultRouteFailed, fmt.Sprintf(MessageDefaultRouteFailed, "192.168.8.1"), fmt.Errorf("network is unreachable"))
+	// Steps of a VirtualRouter whose router pod is not known are only
+	// recorded on the VirtualRouter
+	c.recordStep(nil, virtualRouter)(ReasonDetached, fmt.Sprintf(MessageDetached, "default-vr1/router-abc"), nil)
+	// and a nil StepFunc ignores them
+	StepFunc(nil).report(ReasonDetached, fmt.Sprintf(MessageDetached, "default-vr1/router-abc"), nil)
+
+	expected := []string{
+		"Normal VlanAssigned Assigned VLAN 210 to the internal interface",
+		"Normal VlanAssigned Assigned VLAN 210 to the internal interface",
+		"Warning DefaultRouteFailed Setting default route via 192.168.8.1 failed: network is unreachable",
+		"Warning DefaultRouteFailed Setting default route via 192.168.8.1 failed: network is unreachable",
+		"Normal Detached Detached pod default-vr1/router-abc",
+	}
+	close(recorder.Events)
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected events %q, got %q", expected, events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Errorf("expected event %q, got %q", expected[i], events[i])
+		}
+	}
+}
+
+func TestSyncVirtualRoutersOfTheSameName(t *testing.T) {
+	n := NewDaemon(nil, nil)
+	network := newFakeNetwork()
+	n.network = network
+
+	// a/r1 and b/r1 have a router pod of the same name on this node
+	routerA := &networkv1.VirtualRouter{
+		ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "a"},
+		Spec:       networkv1.VirtualRouterSpec{DeploymentName: "router", InternalIP: "10.10.10.10", ExternalIP: "192.168.8.150"},
+		Status:     networkv1.VirtualRouterStatus{Namespace: "a-r1"},
+	}
+	routerB := routerA.DeepCopy()
+	routerB.Namespace = "b"
+	routerB.Spec.InternalIP = "10.10.20.10"
+	routerB.Spec.ExternalIP = "192.168.8.160"
+	routerB.Status.Namespace = "b-r1"
+	podA := newRouterPod(routerA, "router-0", "aaaaaaaaaaaa")
+	podB := newRouterPod(routerB, "router-0", "bbbbbbbbbbbb")
+	for _, tc := range []struct {
+		pod           *corev1.Pod
+		virtualRouter *networkv1.VirtualRouter
+	}{{podA, routerA}, {podB, routerB}} {
+		if err := n.AttachingPod(tc.pod, tc.virtualRouter, nil); err != nil {
+			t.Fatalf("attaching %s/%s: %v", tc.pod.Namespace, tc.pod.Name, err)
+		}
+	}
+
+	// A spec change of a/r1 only reaches its own pod
+	updated := routerA.DeepCopy()
+	updated.Spec.InternalIP = "10.10.10.20"
+	virtualRouterIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	virtualRouterIndexer.Add(updated)
+	virtualRouterIndexer.Add(routerB)
+	podIndexer.Add(podA)
+	podIndexer.Add(podB)
+	c := &Controller{
+		kubeclientset:        k8sfake.NewSimpleClientset(podA, podB),
+		sampleclientset:      fake.NewSimpleClientset(updated, routerB),
+		networkDaemon:        n,
+		podLister:            corelisters.NewPodLister(podIndexer),
+		virtualRoutersLister: listers.NewVirtualRouterLister(virtualRouterIndexer),
+		recorder:             record.NewFakeRecorder(100),
+	}
+	if err := c.syncHandler(virtualrouterKey("a/r1")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{
+		"aaaaaaaaaaaa": {"10.10.10.10", "192.168.8.150", "10.10.10.20"},
+		"bbbbbbbbbbbb": {"10.10.20.10", "192.168.8.160"},
+	}
+	if !reflect.DeepEqual(network.addresses, expected) {
+		t.Errorf("expected addresses %v, got %v", expected, network.addresses)
+	}
+}
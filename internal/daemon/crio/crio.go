@@ -132,6 +132,22 @@ func Initialize(cfg *CrioConfig) error {
 	return nil
 }
 
+// Check verifies that the CRI runtime service is reachable.
+func Check(cfg *CrioConfig) error {
+	conn, err := getRuntimeClientConnection(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+	if _, err := runtimeapi.NewRuntimeServiceClient(conn).Version(ctx, &runtimeapi.VersionRequest{}); err != nil {
+		return fmt.Errorf("CRI runtime %s is not reachable: %v", cfg.RuntimeEndpoint, err)
+	}
+	return nil
+}
+
 func RuntimeServiceTestfunc(cfg *CrioConfig) error {
 	var remoteRuntimeService cri.RuntimeService
 	var err error
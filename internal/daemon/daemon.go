@@ -2,10 +2,17 @@ package daemon
 
 import (
 	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	internalCrio "github.com/tmax-cloud/virtualrouter-controller/internal/daemon/crio"
 	internalNetlink "github.com/tmax-cloud/virtualrouter-controller/internal/daemon/netlink"
 	v1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 )
 
@@ -14,19 +21,63 @@ const (
 	DEFAULT_TABLE_NUMBER                           int    = 200
 	DEFAULT_VIRTURALROUTER_INTERNAL_INTERFACE_NAME string = "ethint"
 	DEFAULT_VIRTURALROUTER_EXTERNAL_INTERFACE_NAME string = "ethext"
+
+	initializeRetryPeriod = 10 * time.Second
 )
 
 type NetworkDaemon struct {
-	crioCfg          *internalCrio.CrioConfig
-	netlinkCfg       *internalNetlink.Config
+	crioCfg    *internalCrio.CrioConfig
+	netlinkCfg *internalNetlink.Config
+	network    containerNetwork
+
+	// The state is kept per router pod, by its namespace/name, since several
+	// pods of a VirtualRouter can run on the same node
 	runnigState      map[string]*v1.VirtualRouterSpec
 	pod2containerMap map[string]*containerDesc
 	vlanUse          map[int][]string
+
+	// initErr is the result of the last Initialize, guarded by initMu
+	initMu  sync.RWMutex
+	initErr error
 }
 
 type containerDesc struct {
-	containerName string
+	// pod and virtualRouter are the namespace/name of the router pod and of
+	// its VirtualRouter
+	pod           string
+	virtualRouter string
 	containerID   string
+	// identity is set for the pods of a StatefulSet, see podIdentity
+	identity string
+}
+
+// interfaceID is the suffix of the host side veths of the container. It
+// follows the pod identity, if any, so that it survives restarts.
+func (d *containerDesc) interfaceID() string {
+	if d.identity != "" {
+		return stableInterfaceID(d.identity)
+	}
+	return d.containerID[:7]
+}
+
+// hardwareAddr is the MAC address of the pod side veth, or nil for a random
+// one.
+func (d *containerDesc) hardwareAddr(isInternal bool) net.HardwareAddr {
+	if d.identity == "" {
+		return nil
+	}
+	return stableHardwareAddr(d.identity, isInternal)
+}
+
+// StepFunc is called after each step of wiring up a router pod with the
+// Event reason and message of the step, and its error if it failed. A nil
+// StepFunc ignores the steps.
+type StepFunc func(reason string, message string, err error)
+
+func (f StepFunc) report(reason string, message string, err error) {
+	if f != nil {
+		f(reason, message, err)
+	}
 }
 
 // type virtualrouterSpec struct {
@@ -40,9 +91,11 @@ func NewDaemon(crioCfg *internalCrio.CrioConfig, netlinkCfg *internalNetlink.Con
 	return &NetworkDaemon{
 		crioCfg:          crioCfg,
 		netlinkCfg:       netlinkCfg,
+		network:          &hostNetwork{crioCfg: crioCfg, netlinkCfg: netlinkCfg},
 		pod2containerMap: make(map[string]*containerDesc),
 		runnigState:      make(map[string]*v1.VirtualRouterSpec),
 		vlanUse:          make(map[int][]string),
+		initErr:          fmt.Errorf("not initialized"),
 	}
 }
 
@@ -50,8 +103,17 @@ func (n *NetworkDaemon) Start(stopSignalCh <-chan struct{}, stopCh chan<- struct
 	klog.Info("Starting NetworkDaemon")
 	klog.Info("Initializing start")
 
-	if err := n.Initialize(); err != nil {
+	if err := n.initialize(); err != nil {
 		klog.Error("failed to Initialize")
+		// Keep retrying so the daemon becomes ready once the host is fixed
+		go wait.PollUntil(initializeRetryPeriod, func() (bool, error) {
+			if err := n.initialize(); err != nil {
+				klog.ErrorS(err, "Retrying Initialize failed")
+				return false, nil
+			}
+			klog.Info("Initializing done")
+			return true, nil
+		}, stopSignalCh)
 	} else {
 		klog.Info("Initializing done")
 	}
@@ -70,6 +132,31 @@ func (n *NetworkDaemon) Start(stopSignalCh <-chan struct{}, stopCh chan<- struct
 	return nil
 }
 
+// initialize runs Initialize and records its result for Ready.
+func (n *NetworkDaemon) initialize() error {
+	err := n.Initialize()
+	n.initMu.Lock()
+	n.initErr = err
+	n.initMu.Unlock()
+	return err
+}
+
+// Ready returns nil once Initialize succeeded and the bridges and the CRI
+// runtime it set up are still usable.
+func (n *NetworkDaemon) Ready() error {
+	n.initMu.RLock()
+	initErr := n.initErr
+	n.initMu.RUnlock()
+	if initErr != nil {
+		return fmt.Errorf("initialize failed: %v", initErr)
+	}
+
+	if err := internalCrio.Check(n.crioCfg); err != nil {
+		return err
+	}
+	return internalNetlink.Check(n.netlinkCfg)
+}
+
 func (n *NetworkDaemon) Initialize() error {
 	if err := internalCrio.Initialize(n.crioCfg); err != nil {
 		klog.ErrorS(err, "Crio Initialization failed")
@@ -77,6 +164,7 @@ func (n *NetworkDaemon) Initialize() error {
 	}
 
 	if err := internalNetlink.Initialize(n.netlinkCfg); err != nil {
+		netlinkFailed("Initialize")
 		klog.ErrorS(err, "Netlink Initialization failed")
 		return err
 	}
@@ -85,116 +173,159 @@ func (n *NetworkDaemon) Initialize() error {
 
 func (n *NetworkDaemon) ClearAll() error {
 	if err := internalNetlink.Clear(n.netlinkCfg); err != nil {
+		netlinkFailed("Clear")
 		klog.ErrorS(err, "Netlink Clear failed")
 		return err
 	}
 	return nil
 }
 
-func (n *NetworkDaemon) ClearContainer(containerName string, containerID string) error {
-	klog.InfoS("ClearContainer Start", "ContainerID", containerID)
-	if _, exist := n.runnigState[containerName]; !exist {
+// ClearContainer detaches the container of the router pod podKey from the
+// bridges of the node.
+func (n *NetworkDaemon) ClearContainer(podKey string) error {
+	desc, err := n.containerDescOf(podKey)
+	if err != nil {
+		return err
+	}
+	klog.InfoS("ClearContainer Start", "pod", podKey, "InterfaceID", desc.interfaceID())
+	if _, exist := n.runnigState[podKey]; !exist {
 		return nil
 	}
-	if n.runnigState[containerName].VlanNumber != 0 {
-		if err := n.AssignVlan(containerName, 0, int(n.runnigState[containerName].VlanNumber)); err != nil {
+	if n.runnigState[podKey].VlanNumber != 0 {
+		if err := n.AssignVlan(podKey, 0, int(n.runnigState[podKey].VlanNumber)); err != nil {
 			return err
 		}
 	}
 
-	if err := internalNetlink.ClearVethInterface(containerID[:7], true); err != nil {
-		klog.ErrorS(err, "ClearVethInterface failed", "containerID", containerID[:7], "isInternal", true)
-		return err
-	}
-	if err := internalNetlink.ClearVethInterface(containerID[:7], false); err != nil {
-		klog.ErrorS(err, "ClearVethInterface failed", "containerID", containerID[:7], "isInternal", false)
+	if err := n.network.ClearInterfaces(desc); err != nil {
 		return err
 	}
 
-	delete(n.runnigState, containerName)
+	delete(n.runnigState, podKey)
 
-	klog.InfoS("ClearContainer Done", "ContainerName", containerName)
+	klog.InfoS("ClearContainer Done", "pod", podKey)
 	return nil
 }
 
-func (n *NetworkDaemon) AttachingPod(podName string, virtualrouter *v1.VirtualRouter) error {
-	var containerName string
-	var err error
-	if desc, exist := n.pod2containerMap[podName]; exist {
-		containerName = desc.containerName
-	} else {
-		containerID := internalCrio.GetContainerIDFromContainerName(virtualrouter.Name, n.crioCfg)
+// routerContainerID returns the ID of the running router container of pod,
+// which is named after its VirtualRouter, or "" if it is not running.
+func routerContainerID(pod *corev1.Pod, containerName string) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != containerName || status.State.Running == nil {
+			continue
+		}
+		// The ID is prefixed with the runtime, e.g. cri-o://<id>
+		if i := strings.Index(status.ContainerID, "://"); i >= 0 {
+			return status.ContainerID[i+len("://"):]
+		}
+		return status.ContainerID
+	}
+	return ""
+}
+
+func (n *NetworkDaemon) AttachingPod(pod *corev1.Pod, virtualrouter *v1.VirtualRouter, step StepFunc) (err error) {
+	podKey := pod.Namespace + "/" + pod.Name
+	defer func(start time.Time) {
+		observePodOperation("attach", start, err)
+		n.updateStateMetrics()
+	}(time.Now())
+	desc, exist := n.pod2containerMap[podKey]
+	if !exist {
+		containerID := routerContainerID(pod, virtualrouter.Name)
 		if containerID == "" {
-			klog.Errorf("There is no running container with ContainerName: %s", containerName)
+			klog.Errorf("There is no running container %s in pod %s", virtualrouter.Name, podKey)
 			return fmt.Errorf("no running container found")
 		}
-		n.pod2containerMap[podName] = &containerDesc{
-			containerName: virtualrouter.Name,
+		desc = &containerDesc{
+			pod:           podKey,
+			virtualRouter: virtualrouter.Namespace + "/" + virtualrouter.Name,
 			containerID:   containerID,
+			identity:      podIdentity(virtualrouter, pod.Name),
 		}
-
-		containerName = virtualrouter.Name
+		n.pod2containerMap[podKey] = desc
 	}
 	defer func() {
 		if err != nil {
-			delete(n.pod2containerMap, podName)
+			delete(n.pod2containerMap, podKey)
 		}
 	}()
 
-	if _, exist := n.runnigState[containerName]; exist {
-		// klog.Warning("Duplicated containerName called. Do nothing")
+	if _, exist := n.runnigState[podKey]; exist {
+		// The pod is already attached
 		return nil
 	}
 
-	if err = n.ConnectInterface(containerName, true); err != nil {
-		klog.ErrorS(err, "Interface to Container faild", "containerName", containerName)
+	// Each replica may be assigned its own addresses
+	spec, err := replicaSpec(pod.Name, virtualrouter)
+	if err != nil {
 		return err
 	}
-	if err = n.ConnectInterface(containerName, false); err != nil {
-		klog.ErrorS(err, "Interface to Container faild", "containerName", containerName)
+
+	interfaceID := desc.interfaceID()
+	if err = n.ConnectInterface(podKey, true); err != nil {
+		klog.ErrorS(err, "Interface to Container faild", "pod", podKey)
+		step.report(ReasonInterfacesAttachFailed, fmt.Sprintf(MessageInterfacesAttachFailed, podKey), err)
 		return err
 	}
+	if err = n.ConnectInterface(podKey, false); err != nil {
+		klog.ErrorS(err, "Interface to Container faild", "pod", podKey)
+		step.report(ReasonInterfacesAttachFailed, fmt.Sprintf(MessageInterfacesAttachFailed, podKey), err)
+		return err
+	}
+	step.report(ReasonInterfacesAttached, fmt.Sprintf(MessageInterfacesAttached, "int"+interfaceID, "ext"+interfaceID, podKey), nil)
 
-	if err = n.Sync(containerName, virtualrouter.Spec); err != nil {
+	if err = n.Sync(podKey, spec, step); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (n *NetworkDaemon) DettachingPod(podName string) error {
-	var containerID string
-	var containerName string
-	if desc, exist := n.pod2containerMap[podName]; exist {
-		containerName = desc.containerName
-		containerID = desc.containerID
-	} else {
+func (n *NetworkDaemon) DettachingPod(podKey string, step StepFunc) error {
+	var clearErr error
+	defer func(start time.Time) {
+		observePodOperation("detach", start, clearErr)
+		n.updateStateMetrics()
+	}(time.Now())
+	if _, exist := n.pod2containerMap[podKey]; !exist {
 		return nil
 	}
 
-	n.ClearContainer(containerName, containerID)
-	delete(n.pod2containerMap, podName)
+	clearErr = n.ClearContainer(podKey)
+	if clearErr != nil {
+		step.report(ReasonDetachFailed, fmt.Sprintf(MessageDetachFailed, podKey), clearErr)
+	} else {
+		step.report(ReasonDetached, fmt.Sprintf(MessageDetached, podKey), nil)
+	}
+	delete(n.pod2containerMap, podKey)
 	return nil
 }
 
-func (n *NetworkDaemon) Sync(containerName string, virtualrouterSpec v1.VirtualRouterSpec) error {
-	var podExist bool = false
-	for _, descs := range n.pod2containerMap {
-		if descs.containerName == containerName {
-			podExist = true
-			break
+// AttachedPods returns the namespace/name of the router pods of
+// virtualRouter attached by the daemon.
+func (n *NetworkDaemon) AttachedPods(virtualRouter *v1.VirtualRouter) []string {
+	var podKeys []string
+	for podKey, desc := range n.pod2containerMap {
+		if desc.virtualRouter == virtualRouter.Namespace+"/"+virtualRouter.Name {
+			podKeys = append(podKeys, podKey)
 		}
 	}
-	if !podExist {
+	sort.Strings(podKeys)
+	return podKeys
+}
+
+// Sync applies virtualrouterSpec to the attached router pod podKey.
+func (n *NetworkDaemon) Sync(podKey string, virtualrouterSpec v1.VirtualRouterSpec, step StepFunc) error {
+	if _, exist := n.pod2containerMap[podKey]; !exist {
 		return nil
 	}
 	var vlanChanged, internalIPChanged, externalIPChanged, internalNetmaskChanged, externalNetmaskChanged, gatewayIPChanged bool
 	var vlan int = int(virtualrouterSpec.VlanNumber)
 
-	if virtualrouterSpecSnapshot, exist := n.runnigState[containerName]; !exist {
-		n.runnigState[containerName] = &virtualrouterSpec
+	if virtualrouterSpecSnapshot, exist := n.runnigState[podKey]; !exist {
+		n.runnigState[podKey] = &virtualrouterSpec
 		if vlan != 0 {
-			n.vlanUse[vlan] = append(n.vlanUse[vlan], containerName)
+			n.vlanUse[vlan] = append(n.vlanUse[vlan], podKey)
 			vlanChanged = true
 		}
 		internalIPChanged = true
@@ -202,7 +333,7 @@ func (n *NetworkDaemon) Sync(containerName string, virtualrouterSpec v1.VirtualR
 		internalNetmaskChanged = true
 		externalNetmaskChanged = true
 		gatewayIPChanged = true
-		if err := n.SetRouteRule2Container(containerName, DEFAULT_MASK_NUMBER, DEFAULT_TABLE_NUMBER); err != nil {
+		if err := n.SetRouteRule2Container(podKey, DEFAULT_MASK_NUMBER, DEFAULT_TABLE_NUMBER); err != nil {
 			return err
 		}
 	} else {
@@ -213,13 +344,13 @@ func (n *NetworkDaemon) Sync(containerName string, virtualrouterSpec v1.VirtualR
 			internalNetmaskChanged = true
 		}
 		if virtualrouterSpec.ExternalNetmask != virtualrouterSpecSnapshot.ExternalNetmask {
-			internalNetmaskChanged = true
+			externalNetmaskChanged = true
 		}
 		if virtualrouterSpec.InternalIP != virtualrouterSpecSnapshot.InternalIP {
 			internalIPChanged = true
 		}
 		if virtualrouterSpec.ExternalIP != virtualrouterSpecSnapshot.ExternalIP {
-			internalIPChanged = true
+			externalIPChanged = true
 		}
 		if virtualrouterSpec.GatewayIP != virtualrouterSpecSnapshot.GatewayIP {
 			gatewayIPChanged = true
@@ -232,154 +363,98 @@ func (n *NetworkDaemon) Sync(containerName string, virtualrouterSpec v1.VirtualR
 	}
 
 	if vlanChanged {
-		if err := n.AssignVlan(containerName, vlan, int(n.runnigState[containerName].VlanNumber)); err != nil {
-			klog.ErrorS(err, "UnssignVlan failed", "containerName", containerName, "vlan", vlan)
+		if err := n.AssignVlan(podKey, vlan, int(n.runnigState[podKey].VlanNumber)); err != nil {
+			klog.ErrorS(err, "UnssignVlan failed", "pod", podKey, "vlan", vlan)
+			step.report(ReasonVlanAssignFailed, fmt.Sprintf(MessageVlanAssignFailed, vlan), err)
 			return err
 		}
+		if vlan != 0 {
+			step.report(ReasonVlanAssigned, fmt.Sprintf(MessageVlanAssigned, vlan), nil)
+		} else {
+			step.report(ReasonVlanAssigned, MessageVlanRemoved, nil)
+		}
 
 	}
 
 	if internalIPChanged || internalNetmaskChanged {
-		if err := n.AssignIPaddress(containerName, virtualrouterSpec.InternalIP, virtualrouterSpec.InternalNetmask, true); err != nil {
-			klog.ErrorS(err, "AssignIPAddress failed", "containerName", containerName, "IPs", virtualrouterSpec.InternalIP)
+		if err := n.AssignIPaddress(podKey, virtualrouterSpec.InternalIP, virtualrouterSpec.InternalNetmask, true); err != nil {
+			klog.ErrorS(err, "AssignIPAddress failed", "pod", podKey, "IPs", virtualrouterSpec.InternalIP)
+			step.report(ReasonIPAssignFailed, fmt.Sprintf(MessageIPAssignFailed, "internal", virtualrouterSpec.InternalIP), err)
 			return err
 		}
+		step.report(ReasonIPAssigned, fmt.Sprintf(MessageIPAssigned, "internal", virtualrouterSpec.InternalIP, virtualrouterSpec.InternalNetmask), nil)
 
 	}
 
 	if externalIPChanged || externalNetmaskChanged {
-		if err := n.AssignIPaddress(containerName, virtualrouterSpec.ExternalIP, virtualrouterSpec.ExternalNetmask, false); err != nil {
-			klog.ErrorS(err, "AssignVlan failed", "containerName", containerName, "IPs", virtualrouterSpec.ExternalIP)
+		if err := n.AssignIPaddress(podKey, virtualrouterSpec.ExternalIP, virtualrouterSpec.ExternalNetmask, false); err != nil {
+			klog.ErrorS(err, "AssignVlan failed", "pod", podKey, "IPs", virtualrouterSpec.ExternalIP)
+			step.report(ReasonIPAssignFailed, fmt.Sprintf(MessageIPAssignFailed, "external", virtualrouterSpec.ExternalIP), err)
 			return err
 		}
+		step.report(ReasonIPAssigned, fmt.Sprintf(MessageIPAssigned, "external", virtualrouterSpec.ExternalIP, virtualrouterSpec.ExternalNetmask), nil)
 	}
 
 	if gatewayIPChanged {
-		if err := n.SetDefaultRoute2Container(containerName, virtualrouterSpec.GatewayIP); err != nil {
-			klog.ErrorS(err, "SetRoute2Container failed", "containerName", containerName, "gatewayIP", virtualrouterSpec.GatewayIP)
+		if err := n.SetDefaultRoute2Container(podKey, virtualrouterSpec.GatewayIP); err != nil {
+			klog.ErrorS(err, "SetRoute2Container failed", "pod", podKey, "gatewayIP", virtualrouterSpec.GatewayIP)
+			step.report(ReasonDefaultRouteFailed, fmt.Sprintf(MessageDefaultRouteFailed, virtualrouterSpec.GatewayIP), err)
 			return err
 		}
+		step.report(ReasonDefaultRouteSet, fmt.Sprintf(MessageDefaultRouteSet, virtualrouterSpec.GatewayIP), nil)
 	}
 
-	n.runnigState[containerName] = &virtualrouterSpec
+	n.runnigState[podKey] = &virtualrouterSpec
 	return nil
 }
 
-func (n *NetworkDaemon) SetRouteRule2Container(containerName string, markNumber int, tableNumber int) error {
-	var containerID string
-	var containerPid int
-
-	containerID = internalCrio.GetContainerIDFromContainerName(containerName, n.crioCfg)
-	if containerID == "" {
-		klog.Errorf("There is no running container with ContainerName: %s", containerName)
-		return fmt.Errorf("no running container found")
-	}
-
-	containerPid = internalCrio.GetContainerPid(containerID, n.crioCfg)
-	if containerPid <= 0 {
-		klog.Errorf("Wrong Pid(%d) value of Container(%s)", containerPid, containerName)
-		return fmt.Errorf("internal error")
-	}
-
-	if err := internalNetlink.SetRouteRule2Container(containerPid, markNumber, tableNumber); err != nil {
-		klog.ErrorS(err, "Set Route rule to Container failed", "ContainerName", containerName, "ContainerID", containerID)
+func (n *NetworkDaemon) SetRouteRule2Container(podKey string, markNumber int, tableNumber int) error {
+	desc, err := n.containerDescOf(podKey)
+	if err != nil {
 		return err
 	}
-	return nil
+	return n.network.SetRouteRule(desc, markNumber, tableNumber)
 }
 
-func (n *NetworkDaemon) SetDefaultRoute2Container(containerName string, gatewayIP string) error {
-	var containerID string
-	var containerPid int
-
-	containerID = internalCrio.GetContainerIDFromContainerName(containerName, n.crioCfg)
-	if containerID == "" {
-		klog.Errorf("There is no running container with ContainerName: %s", containerName)
-		return fmt.Errorf("no running container found")
-	}
-
-	containerPid = internalCrio.GetContainerPid(containerID, n.crioCfg)
-	if containerPid <= 0 {
-		klog.Errorf("Wrong Pid(%d) value of Container(%s)", containerPid, containerName)
-		return fmt.Errorf("internal error")
-	}
-
-	if err := internalNetlink.SetDefaultRoute2Container(containerPid, gatewayIP, DEFAULT_TABLE_NUMBER); err != nil {
-		klog.ErrorS(err, "Set Routing rule to Container failed", "ContainerName", containerName, "ContainerID", containerID)
+func (n *NetworkDaemon) SetDefaultRoute2Container(podKey string, gatewayIP string) error {
+	desc, err := n.containerDescOf(podKey)
+	if err != nil {
 		return err
 	}
-	return nil
+	return n.network.SetDefaultRoute(desc, gatewayIP)
 }
 
-func (n *NetworkDaemon) AssignVlan(containerName string, newVlan int, oldVlan int) error {
-	containerID := internalCrio.GetContainerIDFromContainerName(containerName, n.crioCfg)
-	if containerID == "" {
-		klog.Errorf("There is no running container with ContainerName: %s", containerName)
-		return fmt.Errorf("no running container found")
-	}
-
-	if err := internalNetlink.SetVlan("int"+containerID[:7], newVlan, oldVlan, n.netlinkCfg); err != nil {
-		klog.ErrorS(err, "SetVlan failed", "vlan", newVlan)
+func (n *NetworkDaemon) AssignVlan(podKey string, newVlan int, oldVlan int) error {
+	desc, err := n.containerDescOf(podKey)
+	if err != nil {
 		return err
 	}
-	return nil
+	return n.network.AssignVlan(desc, newVlan, oldVlan)
 }
 
-func (n *NetworkDaemon) AssignIPaddress(containerName string, ip string, netmask string, isInternal bool) error {
-	var containerID string
-	var containerPid int
-
-	containerID = internalCrio.GetContainerIDFromContainerName(containerName, n.crioCfg)
-	if containerID == "" {
-		klog.Errorf("There is no running container with ContainerName: %s", containerName)
-		return fmt.Errorf("no running container found")
-	}
-
-	containerPid = internalCrio.GetContainerPid(containerID, n.crioCfg)
-	if containerPid <= 0 {
-		klog.Errorf("Wrong Pid(%d) value of Container(%s)", containerPid, containerName)
-		return fmt.Errorf("internal error")
-	}
-
-	if err := internalNetlink.SetIPaddress2Container(containerPid, ip, netmask, isInternal); err != nil {
-		klog.ErrorS(err, "Set Interface to Container failed", "ContainerName", containerName, "ContainerID", containerID)
+func (n *NetworkDaemon) AssignIPaddress(podKey string, ip string, netmask string, isInternal bool) error {
+	desc, err := n.containerDescOf(podKey)
+	if err != nil {
 		return err
 	}
+	return n.network.AssignIPaddress(desc, ip, netmask, isInternal)
+}
 
-	var interfaceName string
-	if isInternal {
-		interfaceName = DEFAULT_VIRTURALROUTER_INTERNAL_INTERFACE_NAME
-	} else {
-		interfaceName = DEFAULT_VIRTURALROUTER_EXTERNAL_INTERFACE_NAME
-	}
-	if err := internalNetlink.SetRoute2Container(containerPid, interfaceName, DEFAULT_TABLE_NUMBER); err != nil {
-		klog.ErrorS(err, "Set Interface to Container failed", "ContainerName", containerName, "ContainerID", containerID)
+func (n *NetworkDaemon) ConnectInterface(podKey string, isInternal bool) error {
+	desc, err := n.containerDescOf(podKey)
+	if err != nil {
 		return err
 	}
-
-	return nil
+	return n.network.ConnectInterface(desc, isInternal)
 }
 
-func (n *NetworkDaemon) ConnectInterface(containerName string, isInternal bool) error {
-	var containerID string
-	var containerPid int
-
-	containerID = internalCrio.GetContainerIDFromContainerName(containerName, n.crioCfg)
-	if containerID == "" {
-		klog.Errorf("There is no running container with ContainerName: %s", containerName)
-		return fmt.Errorf("no running container found")
+// containerDescOf returns the description of the container of the router pod
+// podKey.
+func (n *NetworkDaemon) containerDescOf(podKey string) (*containerDesc, error) {
+	desc, exist := n.pod2containerMap[podKey]
+	if !exist {
+		klog.Errorf("There is no router pod %s attached", podKey)
+		return nil, fmt.Errorf("no running container found")
 	}
-
-	containerPid = internalCrio.GetContainerPid(containerID, n.crioCfg)
-	if containerPid <= 0 {
-		klog.Errorf("Wrong Pid(%d) value of Container(%s)", containerPid, containerName)
-		return fmt.Errorf("internal error")
-	}
-
-	if err := internalNetlink.SetInterface2Container(containerPid, containerID[:7], isInternal, n.netlinkCfg); err != nil {
-		klog.ErrorS(err, "Set Interface to Container failed", "ContainerName", containerName, "ContainerID", containerID)
-		return err
-	}
-
-	return nil
+	return desc, nil
 }
@@ -0,0 +1,172 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+// fakeNetwork records the settings applied to every container.
+type fakeNetwork struct {
+	interfaces    map[string][]string
+	hardwareAddrs map[string][]string
+	addresses     map[string][]string
+	vlans         map[string]int
+}
+
+func newFakeNetwork() *fakeNetwork {
+	return &fakeNetwork{
+		interfaces:    make(map[string][]string),
+		hardwareAddrs: make(map[string][]string),
+		addresses:     make(map[string][]string),
+		vlans:         make(map[string]int),
+	}
+}
+
+func (f *fakeNetwork) ConnectInterface(desc *containerDesc, isInternal bool) error {
+	name := "ext" + desc.interfaceID()
+	if isInternal {
+		name = "int" + desc.interfaceID()
+	}
+	f.interfaces[desc.containerID] = append(f.interfaces[desc.containerID], name)
+	f.hardwareAddrs[desc.containerID] = append(f.hardwareAddrs[desc.containerID], desc.hardwareAddr(isInternal).String())
+	return nil
+}
+
+func (f *fakeNetwork) ClearInterfaces(desc *containerDesc) error {
+	delete(f.interfaces, desc.containerID)
+	delete(f.addresses, desc.containerID)
+	return nil
+}
+
+func (f *fakeNetwork) SetRouteRule(desc *containerDesc, markNumber int, tableNumber int) error {
+	return nil
+}
+
+func (f *fakeNetwork) SetDefaultRoute(desc *containerDesc, gatewayIP string) error {
+	return nil
+}
+
+func (f *fakeNetwork) AssignVlan(desc *containerDesc, newVlan int, oldVlan int) error {
+	f.vlans["int"+desc.interfaceID()] = newVlan
+	return nil
+}
+
+func (f *fakeNetwork) AssignIPaddress(desc *containerDesc, ip string, netmask string, isInternal bool) error {
+	f.addresses[desc.containerID] = append(f.addresses[desc.containerID], ip)
+	return nil
+}
+
+func newRouterPod(virtualRouter *v1.VirtualRouter, name string, containerID string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: virtualRouter.Status.Namespace},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:        virtualRouter.Name,
+					ContainerID: "cri-o://" + containerID,
+					State:       corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				},
+			},
+		},
+	}
+}
+
+func newReplicatedRouter() *v1.VirtualRouter {
+	return &v1.VirtualRouter{
+		ObjectMeta: metav1.ObjectMeta{Name: "vr1", Namespace: "default"},
+		Spec: v1.VirtualRouterSpec{
+			DeploymentName:    "router",
+			VlanNumber:        210,
+			InternalIP:        "10.10.10.10",
+			ExternalIP:        "192.168.8.150",
+			GatewayIP:         "192.168.8.1",
+			ReplicaAddressing: &v1.ReplicaAddressing{External: &v1.AddressPool{Range: "192.168.8.151-192.168.8.152"}},
+		},
+		Status: v1.VirtualRouterStatus{
+			Namespace: "default-vr1",
+			ReplicaAddresses: []v1.ReplicaAddress{
+				{Pod: "router-a", ExternalIP: "192.168.8.151"},
+				{Pod: "router-b", ExternalIP: "192.168.8.152"},
+			},
+		},
+	}
+}
+
+func TestAttachingReplicasOnOneNode(t *testing.T) {
+	n := NewDaemon(nil, nil)
+	network := newFakeNetwork()
+	n.network = network
+	virtualRouter := newReplicatedRouter()
+	podA := newRouterPod(virtualRouter, "router-a", "aaaaaaaaaaaa")
+	podB := newRouterPod(virtualRouter, "router-b", "bbbbbbbbbbbb")
+
+	for _, pod := range []*corev1.Pod{podA, podB} {
+		if err := n.AttachingPod(pod, virtualRouter, nil); err != nil {
+			t.Fatalf("attaching %s: %v", pod.Name, err)
+		}
+	}
+
+	// Both replicas are wired, each with its own container and address
+	expected := map[string][]string{
+		"aaaaaaaaaaaa": {"10.10.10.10", "192.168.8.151"},
+		"bbbbbbbbbbbb": {"10.10.10.10", "192.168.8.152"},
+	}
+	if !reflect.DeepEqual(network.addresses, expected) {
+		t.Errorf("expected addresses %v, got %v", expected, network.addresses)
+	}
+	if len(network.interfaces["aaaaaaaaaaaa"]) != 2 || len(network.interfaces["bbbbbbbbbbbb"]) != 2 {
+		t.Errorf("expected both containers to get their interfaces, got %v", network.interfaces)
+	}
+	if pods := n.AttachedPods(virtualRouter); !reflect.DeepEqual(pods, []string{"default-vr1/router-a", "default-vr1/router-b"}) {
+		t.Errorf("expected both replicas to be attached, got %v", pods)
+	}
+	if attachment := n.PodAttachment(podB); attachment.ContainerID != "bbbbbbbbbbbb" || attachment.ExternalIP != "192.168.8.152" {
+		t.Errorf("expected the attachment of %s, got %+v", podB.Name, attachment)
+	}
+
+	// Detaching one replica leaves the other one attached
+	if err := n.DettachingPod("default-vr1/router-a", nil); err != nil {
+		t.Fatal(err)
+	}
+	if pods := n.AttachedPods(virtualRouter); !reflect.DeepEqual(pods, []string{"default-vr1/router-b"}) {
+		t.Errorf("expected only router-b to be attached, got %v", pods)
+	}
+	if _, exist := network.addresses["bbbbbbbbbbbb"]; !exist {
+		t.Errorf("expected router-b to keep its addresses")
+	}
+}
+
+func TestAttachingPodWithoutRunningContainer(t *testing.T) {
+	n := NewDaemon(nil, nil)
+	n.network = newFakeNetwork()
+	virtualRouter := newReplicatedRouter()
+	pod := newRouterPod(virtualRouter, "router-a", "aaaaaaaaaaaa")
+	pod.Status.ContainerStatuses[0].State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{}}
+
+	if err := n.AttachingPod(pod, virtualRouter, nil); err == nil {
+		t.Error("expected a pod without running router container not to be attached")
+	}
+	if pods := n.AttachedPods(virtualRouter); len(pods) != 0 {
+		t.Errorf("expected no attached pod, got %v", pods)
+	}
+}
+
+func TestRouterContainerID(t *testing.T) {
+	virtualRouter := newReplicatedRouter()
+	pod := newRouterPod(virtualRouter, "router-a", "aaaaaaaaaaaa")
+	pod.Status.ContainerStatuses = append([]corev1.ContainerStatus{
+		{Name: "sidecar", ContainerID: "cri-o://ffffffffffff", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+	}, pod.Status.ContainerStatuses...)
+
+	if id := routerContainerID(pod, virtualRouter.Name); id != "aaaaaaaaaaaa" {
+		t.Errorf("expected the ID of the router container, got %q", id)
+	}
+	if id := routerContainerID(pod, "other"); id != "" {
+		t.Errorf("expected no container, got %q", id)
+	}
+}
@@ -0,0 +1,124 @@
+package daemon
+
+import (
+	"fmt"
+
+	internalCrio "github.com/tmax-cloud/virtualrouter-controller/internal/daemon/crio"
+	internalNetlink "github.com/tmax-cloud/virtualrouter-controller/internal/daemon/netlink"
+	"k8s.io/klog/v2"
+)
+
+// containerNetwork wires up the interfaces of router containers. The daemon
+// uses hostNetwork; tests replace it.
+type containerNetwork interface {
+	ConnectInterface(desc *containerDesc, isInternal bool) error
+	ClearInterfaces(desc *containerDesc) error
+	SetRouteRule(desc *containerDesc, markNumber int, tableNumber int) error
+	SetDefaultRoute(desc *containerDesc, gatewayIP string) error
+	AssignVlan(desc *containerDesc, newVlan int, oldVlan int) error
+	AssignIPaddress(desc *containerDesc, ip string, netmask string, isInternal bool) error
+}
+
+// hostNetwork finds the router containers through the CRI runtime and wires
+// them up to the bridges of the node with netlink.
+type hostNetwork struct {
+	crioCfg    *internalCrio.CrioConfig
+	netlinkCfg *internalNetlink.Config
+}
+
+func (h *hostNetwork) containerPid(desc *containerDesc) (int, error) {
+	containerPid := internalCrio.GetContainerPid(desc.containerID, h.crioCfg)
+	if containerPid <= 0 {
+		klog.Errorf("Wrong Pid(%d) value of Container(%s) of pod %s", containerPid, desc.containerID, desc.pod)
+		return 0, fmt.Errorf("internal error")
+	}
+	return containerPid, nil
+}
+
+func (h *hostNetwork) ConnectInterface(desc *containerDesc, isInternal bool) error {
+	containerPid, err := h.containerPid(desc)
+	if err != nil {
+		return err
+	}
+	if err := internalNetlink.SetInterface2Container(containerPid, desc.interfaceID(), desc.hardwareAddr(isInternal), isInternal, h.netlinkCfg); err != nil {
+		netlinkFailed("SetInterface2Container")
+		klog.ErrorS(err, "Set Interface to Container failed", "pod", desc.pod, "ContainerID", desc.containerID)
+		return err
+	}
+	return nil
+}
+
+func (h *hostNetwork) ClearInterfaces(desc *containerDesc) error {
+	interfaceID := desc.interfaceID()
+	if err := internalNetlink.ClearVethInterface(interfaceID, true); err != nil {
+		netlinkFailed("ClearVethInterface")
+		klog.ErrorS(err, "ClearVethInterface failed", "interfaceID", interfaceID, "isInternal", true)
+		return err
+	}
+	if err := internalNetlink.ClearVethInterface(interfaceID, false); err != nil {
+		netlinkFailed("ClearVethInterface")
+		klog.ErrorS(err, "ClearVethInterface failed", "interfaceID", interfaceID, "isInternal", false)
+		return err
+	}
+	return nil
+}
+
+func (h *hostNetwork) SetRouteRule(desc *containerDesc, markNumber int, tableNumber int) error {
+	containerPid, err := h.containerPid(desc)
+	if err != nil {
+		return err
+	}
+	if err := internalNetlink.SetRouteRule2Container(containerPid, markNumber, tableNumber); err != nil {
+		netlinkFailed("SetRouteRule2Container")
+		klog.ErrorS(err, "Set Route rule to Container failed", "pod", desc.pod, "ContainerID", desc.containerID)
+		return err
+	}
+	return nil
+}
+
+func (h *hostNetwork) SetDefaultRoute(desc *containerDesc, gatewayIP string) error {
+	containerPid, err := h.containerPid(desc)
+	if err != nil {
+		return err
+	}
+	if err := internalNetlink.SetDefaultRoute2Container(containerPid, gatewayIP, DEFAULT_TABLE_NUMBER); err != nil {
+		netlinkFailed("SetDefaultRoute2Container")
+		klog.ErrorS(err, "Set Routing rule to Container failed", "pod", desc.pod, "ContainerID", desc.containerID)
+		return err
+	}
+	return nil
+}
+
+func (h *hostNetwork) AssignVlan(desc *containerDesc, newVlan int, oldVlan int) error {
+	if err := internalNetlink.SetVlan("int"+desc.interfaceID(), newVlan, oldVlan, h.netlinkCfg); err != nil {
+		netlinkFailed("SetVlan")
+		klog.ErrorS(err, "SetVlan failed", "vlan", newVlan)
+		return err
+	}
+	return nil
+}
+
+func (h *hostNetwork) AssignIPaddress(desc *containerDesc, ip string, netmask string, isInternal bool) error {
+	containerPid, err := h.containerPid(desc)
+	if err != nil {
+		return err
+	}
+	if err := internalNetlink.SetIPaddress2Container(containerPid, ip, netmask, isInternal); err != nil {
+		netlinkFailed("SetIPaddress2Container")
+		klog.ErrorS(err, "Set Interface to Container failed", "pod", desc.pod, "ContainerID", desc.containerID)
+		return err
+	}
+
+	var interfaceName string
+	if isInternal {
+		interfaceName = DEFAULT_VIRTURALROUTER_INTERNAL_INTERFACE_NAME
+	} else {
+		interfaceName = DEFAULT_VIRTURALROUTER_EXTERNAL_INTERFACE_NAME
+	}
+	if err := internalNetlink.SetRoute2Container(containerPid, interfaceName, DEFAULT_TABLE_NUMBER); err != nil {
+		netlinkFailed("SetRoute2Container")
+		klog.ErrorS(err, "Set Interface to Container failed", "pod", desc.pod, "ContainerID", desc.containerID)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package daemon
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"strconv"
+	"strings"
+
+	v1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+// podOrdinal returns the ordinal of a router pod of a VirtualRouter with the
+// StatefulSet workload kind, or -1 for the pods of a Deployment.
+func podOrdinal(virtualRouter *v1.VirtualRouter, podName string) int {
+	if virtualRouter.Spec.WorkloadKind != v1.WorkloadKindStatefulSet {
+		return -1
+	}
+	prefix := virtualRouter.Spec.DeploymentName + "-"
+	if !strings.HasPrefix(podName, prefix) {
+		return -1
+	}
+	ordinal, err := strconv.Atoi(strings.TrimPrefix(podName, prefix))
+	if err != nil || ordinal < 0 {
+		return -1
+	}
+	return ordinal
+}
+
+// podIdentity identifies a router pod across restarts by its VirtualRouter
+// and ordinal. Pods without an ordinal have no identity.
+func podIdentity(virtualRouter *v1.VirtualRouter, podName string) string {
+	ordinal := podOrdinal(virtualRouter, podName)
+	if ordinal < 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/%d", virtualRouter.Namespace, virtualRouter.Name, ordinal)
+}
+
+// stableInterfaceID replaces the container ID prefix in the names of the host
+// side veths of a pod with an identity.
+func stableInterfaceID(identity string) string {
+	return fmt.Sprintf("%08x", identityHash(identity))[:7]
+}
+
+// stableHardwareAddr returns the locally administered MAC address of the pod
+// side veth of a pod with an identity.
+func stableHardwareAddr(identity string, isInternal bool) net.HardwareAddr {
+	addr := net.HardwareAddr{0x02, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(addr[1:5], identityHash(identity))
+	if !isInternal {
+		addr[5] = 1
+	}
+	return addr
+}
+
+func identityHash(identity string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(identity))
+	return h.Sum32()
+}
@@ -0,0 +1,101 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+func TestPodIdentity(t *testing.T) {
+	virtualRouter := &v1.VirtualRouter{
+		ObjectMeta: metav1.ObjectMeta{Name: "vr1", Namespace: "default"},
+		Spec:       v1.VirtualRouterSpec{DeploymentName: "router", WorkloadKind: v1.WorkloadKindStatefulSet},
+	}
+
+	testCases := []struct {
+		podName  string
+		identity string
+	}{
+		{"router-0", "default/vr1/0"},
+		{"router-12", "default/vr1/12"},
+		{"router-abc", ""},
+		{"other-1", ""},
+	}
+	for _, tc := range testCases {
+		if identity := podIdentity(virtualRouter, tc.podName); identity != tc.identity {
+			t.Errorf("%s: expected identity %q, got %q", tc.podName, tc.identity, identity)
+		}
+	}
+
+	virtualRouter.Spec.WorkloadKind = v1.WorkloadKindDeployment
+	if identity := podIdentity(virtualRouter, "router-0"); identity != "" {
+		t.Errorf("expected no identity for the pods of a Deployment, got %q", identity)
+	}
+}
+
+func TestStableInterface(t *testing.T) {
+	desc := &containerDesc{pod: "default-vr1/router-0", containerID: "0123456789abcdef", identity: "default/vr1/0"}
+	restarted := &containerDesc{pod: "default-vr1/router-0", containerID: "fedcba9876543210", identity: "default/vr1/0"}
+	if desc.interfaceID() != restarted.interfaceID() || len(desc.interfaceID()) != 7 {
+		t.Errorf("expected the same 7 character interface ID across restarts, got %q and %q", desc.interfaceID(), restarted.interfaceID())
+	}
+	if desc.hardwareAddr(true).String() != restarted.hardwareAddr(true).String() {
+		t.Errorf("expected the same MAC address across restarts, got %s and %s", desc.hardwareAddr(true), restarted.hardwareAddr(true))
+	}
+	if internal, external := desc.hardwareAddr(true), desc.hardwareAddr(false); internal.String() == external.String() || internal[0] != 0x02 {
+		t.Errorf("expected distinct locally administered MAC addresses, got %s and %s", internal, external)
+	}
+
+	other := &containerDesc{pod: "default-vr1/router-0", containerID: "0123456789abcdef", identity: "default/vr1/1"}
+	if desc.interfaceID() == other.interfaceID() {
+		t.Errorf("expected distinct interface IDs for distinct ordinals, got %q", desc.interfaceID())
+	}
+
+	deploymentPod := &containerDesc{pod: "default-vr1/router-0", containerID: "0123456789abcdef"}
+	if deploymentPod.interfaceID() != "0123456" || deploymentPod.hardwareAddr(true) != nil {
+		t.Errorf("expected the container ID prefix and a random MAC address, got %q and %s", deploymentPod.interfaceID(), deploymentPod.hardwareAddr(true))
+	}
+}
+
+func TestAttachingOrdinalsOnOneNode(t *testing.T) {
+	n := NewDaemon(nil, nil)
+	network := newFakeNetwork()
+	n.network = network
+	virtualRouter := newReplicatedRouter()
+	virtualRouter.Spec.WorkloadKind = v1.WorkloadKindStatefulSet
+	virtualRouter.Spec.ReplicaAddressing = nil
+	pod0 := newRouterPod(virtualRouter, "router-0", "000000000000")
+	pod1 := newRouterPod(virtualRouter, "router-1", "111111111111")
+
+	for _, pod := range []*corev1.Pod{pod0, pod1} {
+		if err := n.AttachingPod(pod, virtualRouter, nil); err != nil {
+			t.Fatalf("attaching %s: %v", pod.Name, err)
+		}
+	}
+
+	// Both ordinals are wired with the interfaces and MAC addresses of their
+	// identity, whatever their container
+	for _, tc := range []struct {
+		containerID string
+		identity    string
+	}{
+		{"000000000000", "default/vr1/0"},
+		{"111111111111", "default/vr1/1"},
+	} {
+		interfaceID := stableInterfaceID(tc.identity)
+		if interfaces := network.interfaces[tc.containerID]; !reflect.DeepEqual(interfaces, []string{"int" + interfaceID, "ext" + interfaceID}) {
+			t.Errorf("%s: expected the interfaces of %s, got %v", tc.containerID, tc.identity, interfaces)
+		}
+		expected := []string{stableHardwareAddr(tc.identity, true).String(), stableHardwareAddr(tc.identity, false).String()}
+		if addrs := network.hardwareAddrs[tc.containerID]; !reflect.DeepEqual(addrs, expected) {
+			t.Errorf("%s: expected the MAC addresses %v, got %v", tc.containerID, expected, addrs)
+		}
+		if network.vlans["int"+interfaceID] != 210 {
+			t.Errorf("%s: expected VLAN 210 on int%s, got %v", tc.containerID, interfaceID, network.vlans)
+		}
+	}
+}
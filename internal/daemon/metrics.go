@@ -0,0 +1,72 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/metrics"
+)
+
+const METRICS_SUBSYSTEM = "virtualrouter_daemon"
+
+var (
+	podOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: METRICS_SUBSYSTEM,
+		Name:      "pod_operation_duration_seconds",
+		Help:      "Time taken to attach or detach a router pod.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "result"})
+
+	netlinkFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: METRICS_SUBSYSTEM,
+		Name:      "netlink_failures_total",
+		Help:      "Number of failed netlink operations by function.",
+	}, []string{"function"})
+
+	attachedRouters = prometheus.NewGauge(prometheus.GaugeOpts{
+		Subsystem: METRICS_SUBSYSTEM,
+		Name:      "attached_routers",
+		Help:      "Number of router containers attached on this node.",
+	})
+
+	vlansInUse = prometheus.NewGauge(prometheus.GaugeOpts{
+		Subsystem: METRICS_SUBSYSTEM,
+		Name:      "vlans_in_use",
+		Help:      "Number of VLANs used by router containers on this node.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(podOperationDuration, netlinkFailures, attachedRouters, vlansInUse)
+}
+
+// observePodOperation records the duration and result of attaching or
+// detaching a pod.
+func observePodOperation(operation string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	podOperationDuration.WithLabelValues(operation, result).Observe(time.Since(start).Seconds())
+}
+
+// netlinkFailed counts a failure of the given internal netlink function.
+func netlinkFailed(function string) {
+	netlinkFailures.WithLabelValues(function).Inc()
+}
+
+// updateStateMetrics exports the number of attached routers and the VLANs
+// they use. vlanUse is not pruned when a router is detached, so the VLANs are
+// counted from runnigState. It must be called from the goroutine which owns
+// the daemon state.
+func (n *NetworkDaemon) updateStateMetrics() {
+	attachedRouters.Set(float64(len(n.runnigState)))
+	vlans := make(map[int32]struct{})
+	for _, spec := range n.runnigState {
+		if spec.VlanNumber != 0 {
+			vlans[spec.VlanNumber] = struct{}{}
+		}
+	}
+	vlansInUse.Set(float64(len(vlans)))
+}
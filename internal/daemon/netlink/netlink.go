@@ -78,16 +78,23 @@ func Initialize(cfg *Config) error {
 
 	var defaultGW net.IP = getDefaultGW()
 
+	// Keep going when an interface fails so the default gateway is restored,
+	// but report the failure to the caller.
+	var interfaceErr error
 	if err := initInternalInterface(rootNetlinkHandle, cfg); err != nil {
 		klog.ErrorS(err, "Initializing failed while setting InternalInterface")
+		interfaceErr = err
 	}
 
 	if err := initExternalInterface(rootNetlinkHandle, cfg); err != nil {
-		klog.ErrorS(err, "Initializing failed while setting InternalInterface")
+		klog.ErrorS(err, "Initializing failed while setting ExternalInterface")
+		if interfaceErr == nil {
+			interfaceErr = err
+		}
 	}
 
 	if defaultGW == nil {
-		return nil
+		return interfaceErr
 	} else {
 		if err := setDefaultGW(rootNetlinkHandle, defaultGW); err != nil {
 			klog.ErrorS(err, "setDefaultGW failed", "gw", defaultGW)
@@ -97,6 +104,48 @@ func Initialize(cfg *Config) error {
 		}
 	}
 
+	return interfaceErr
+}
+
+// Check verifies that the bridges created by Initialize exist and are up, and
+// that the origin interfaces are attached to them.
+func Check(cfg *Config) error {
+	rootNetlinkHandle, err := GetRootNetlinkHandle()
+	if err != nil {
+		return err
+	}
+	defer rootNetlinkHandle.Delete()
+
+	internalBridgeName, externalBridgeName := cfg.InternalBridgeName, cfg.ExternalBridgeName
+	if internalBridgeName == "" {
+		internalBridgeName = DefaultInternalBridgeName
+	}
+	if externalBridgeName == "" {
+		externalBridgeName = DefaultExternalBridgeName
+	}
+
+	if err := checkBridgePort(rootNetlinkHandle, internalBridgeName, cfg.OriginInternalInterfaceName); err != nil {
+		return err
+	}
+	return checkBridgePort(rootNetlinkHandle, externalBridgeName, cfg.OriginExternalInterfaceName)
+}
+
+func checkBridgePort(rootNetlinkHandle *remoteNetlink.Handle, bridgeName string, interfaceName string) error {
+	bridge, err := rootNetlinkHandle.LinkByName(bridgeName)
+	if err != nil {
+		return fmt.Errorf("bridge %s not found: %v", bridgeName, err)
+	}
+	if bridge.Attrs().Flags&net.FlagUp == 0 {
+		return fmt.Errorf("bridge %s is down", bridgeName)
+	}
+
+	link, err := rootNetlinkHandle.LinkByName(interfaceName)
+	if err != nil {
+		return fmt.Errorf("interface %s not found: %v", interfaceName, err)
+	}
+	if link.Attrs().MasterIndex != bridge.Attrs().Index {
+		return fmt.Errorf("interface %s is not attached to bridge %s", interfaceName, bridgeName)
+	}
 	return nil
 }
 
@@ -707,7 +756,10 @@ func SetIPaddress2Container(containerPid int, ip string, netmask string, isInter
 	return nil
 }
 
-func SetInterface2Container(containerPid int, interfaceName string, isInternal bool, cfg *Config) error {
+// SetInterface2Container moves the peer of a new veth into the container and
+// plugs the veth into the bridge. A nil hardwareAddr leaves the MAC address of
+// the peer random.
+func SetInterface2Container(containerPid int, interfaceName string, hardwareAddr net.HardwareAddr, isInternal bool, cfg *Config) error {
 	var rootNetlinkHandle *remoteNetlink.Handle
 	var err error
 
@@ -743,8 +795,8 @@ func SetInterface2Container(containerPid int, interfaceName string, isInternal b
 		LinkAttrs: remoteNetlink.LinkAttrs{
 			Name: newinterfaceName,
 		},
-		PeerName: newinterfacePeerName,
-		// PeerHardwareAddr: originLink.Attrs().HardwareAddr,
+		PeerName:         newinterfacePeerName,
+		PeerHardwareAddr: hardwareAddr,
 	}
 
 	// if link, peerLink, err := SetVethInterface(rootNetlinkHandle, newinterfaceName); err != nil {
@@ -0,0 +1,56 @@
+package v1
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// MaxAddressPoolSize bounds the number of addresses an AddressPool expands to
+const MaxAddressPoolSize = 1024
+
+// List returns the addresses of the pool in order, expanding Range.
+func (p *AddressPool) List() ([]string, error) {
+	if p.Range == "" {
+		return p.Addresses, nil
+	}
+	start, end, err := parseRange(p.Range)
+	if err != nil {
+		return nil, err
+	}
+	if end-start >= MaxAddressPoolSize {
+		return nil, fmt.Errorf("range %q must not hold more than %d addresses", p.Range, MaxAddressPoolSize)
+	}
+	addresses := make([]string, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		addresses = append(addresses, uint32ToIP(uint32(i)).String())
+	}
+	return addresses, nil
+}
+
+// parseRange returns the bounds of an inclusive range of IPv4 addresses of
+// the form first-last.
+func parseRange(r string) (uint64, uint64, error) {
+	bounds := strings.SplitN(r, "-", 2)
+	if len(bounds) != 2 {
+		return 0, 0, fmt.Errorf("range %q must be of the form first-last", r)
+	}
+	first := net.ParseIP(strings.TrimSpace(bounds[0])).To4()
+	last := net.ParseIP(strings.TrimSpace(bounds[1])).To4()
+	if first == nil || last == nil {
+		return 0, 0, fmt.Errorf("range %q must be bounded by IPv4 addresses", r)
+	}
+	start := uint64(binary.BigEndian.Uint32(first))
+	end := uint64(binary.BigEndian.Uint32(last))
+	if start > end {
+		return 0, 0, fmt.Errorf("range %q must not start after its end", r)
+	}
+	return start, end, nil
+}
+
+func uint32ToIP(i uint32) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, i)
+	return ip
+}
@@ -0,0 +1,57 @@
+package v1
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Subnet returns the subnet addresses are allocated from.
+func (s *IPPoolSpec) Subnet() (*net.IPNet, error) {
+	ip, subnet, err := net.ParseCIDR(s.CIDR)
+	if err != nil || ip.To4() == nil {
+		return nil, fmt.Errorf("cidr %q must be an IPv4 CIDR", s.CIDR)
+	}
+	return subnet, nil
+}
+
+// ParseExclusion returns the first and last address excluded by exclusion,
+// which is an address or an inclusive range of addresses.
+func ParseExclusion(exclusion string) (net.IP, net.IP, error) {
+	if !strings.Contains(exclusion, "-") {
+		ip := net.ParseIP(exclusion).To4()
+		if ip == nil {
+			return nil, nil, fmt.Errorf("exclusion %q must be an IPv4 address or range", exclusion)
+		}
+		return ip, ip, nil
+	}
+	start, end, err := parseRange(exclusion)
+	if err != nil {
+		return nil, nil, err
+	}
+	return uint32ToIP(uint32(start)), uint32ToIP(uint32(end)), nil
+}
+
+// Excludes reports whether ip is the gateway or one of the exclusions of the
+// pool. Exclusions that cannot be parsed are ignored.
+func (s *IPPoolSpec) Excludes(ip net.IP) bool {
+	ip = ip.To4()
+	if ip == nil {
+		return false
+	}
+	if ip.Equal(net.ParseIP(s.Gateway)) {
+		return true
+	}
+	for _, exclusion := range s.Exclusions {
+		first, last, err := ParseExclusion(exclusion)
+		if err != nil {
+			continue
+		}
+		value := binary.BigEndian.Uint32(ip)
+		if value >= binary.BigEndian.Uint32(first) && value <= binary.BigEndian.Uint32(last) {
+			return true
+		}
+	}
+	return false
+}
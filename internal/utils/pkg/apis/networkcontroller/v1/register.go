@@ -49,6 +49,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&VirtualRouter{},
 		&VirtualRouterList{},
+		&IPPool{},
+		&IPPoolList{},
+		&VlanPool{},
+		&VlanPoolList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
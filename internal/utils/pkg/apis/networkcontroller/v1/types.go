@@ -18,42 +18,313 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=vr
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="VLAN",type=integer,JSONPath=`.spec.vlanNumber`
+// +kubebuilder:printcolumn:name="Internal-IP",type=string,JSONPath=`.spec.internalIP`
+// +kubebuilder:printcolumn:name="External-IP",type=string,JSONPath=`.spec.externalIP`
+// +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.spec.replicas`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.availableReplicas`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // VirtualRouter is a specification for a VirtualRouter resource
 type VirtualRouter struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec   VirtualRouterSpec   `json:"spec"`
+	Spec VirtualRouterSpec `json:"spec"`
+	// +optional
 	Status VirtualRouterStatus `json:"status"`
 }
 
 // VirtualRouterSpec is the spec for a VirtualRouter resource
 type VirtualRouterSpec struct {
-	DeploymentName  string          `json:"deploymentName"`
-	Replicas        *int32          `json:"replicas"`
-	VlanNumber      int32           `json:"vlanNumber" `
-	InternalIP      string          `json:"internalIP"`
-	InternalNetmask string          `json:"internalNetmask"`
-	ExternalIP      string          `json:"externalIP"`
-	ExternalNetmask string          `json:"externalNetmask"`
-	GatewayIP       string          `json:"gatewayIP"`
-	Image           string          `json:"image"`
-	NodeSelector    []NodeSelector  `json:"nodeSelector"`
-	Affinity        corev1.Affinity `json:"affinity"`
+	// DeploymentName is the name of the router Deployment. It cannot be
+	// changed once set.
+	// +kubebuilder:validation:MinLength=1
+	DeploymentName string `json:"deploymentName"`
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	Replicas *int32 `json:"replicas"`
+	// WorkloadKind is the kind of workload the router pods run in. The pods
+	// of a StatefulSet keep their name across restarts, and the daemon derives
+	// their interface names, MAC addresses and replica addresses from their
+	// ordinal. It cannot be changed once set.
+	// +optional
+	// +kubebuilder:default=Deployment
+	// +kubebuilder:validation:Enum=Deployment;StatefulSet
+	WorkloadKind string `json:"workloadKind,omitempty"`
+	// VlanNumber tags the internal interface. 0 leaves it untagged, unless
+	// VlanPool is set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4094
+	VlanNumber int32 `json:"vlanNumber" `
+	// VlanPool names the VlanPool a VLAN is allocated from when VlanNumber
+	// is 0. A VlanNumber which is set is claimed from it instead.
+	// +optional
+	VlanPool string `json:"vlanPool,omitempty"`
+	// InternalIP is required unless InternalIPPool is set
+	// +optional
+	// +kubebuilder:validation:Format=ipv4
+	InternalIP string `json:"internalIP,omitempty"`
+	// +optional
+	// +kubebuilder:validation:Format=ipv4
+	InternalNetmask string `json:"internalNetmask,omitempty"`
+	// InternalIPPool names the IPPool the internal address and netmask are
+	// allocated from, in place of InternalIP and InternalNetmask
+	// +optional
+	InternalIPPool string `json:"internalIPPool,omitempty"`
+	// ExternalIP is required unless ExternalIPPool is set
+	// +optional
+	// +kubebuilder:validation:Format=ipv4
+	ExternalIP string `json:"externalIP,omitempty"`
+	// +optional
+	// +kubebuilder:validation:Format=ipv4
+	ExternalNetmask string `json:"externalNetmask,omitempty"`
+	// ExternalIPPool names the IPPool the external address and netmask are
+	// allocated from, in place of ExternalIP and ExternalNetmask. The gateway
+	// of the pool is used when GatewayIP is not set.
+	// +optional
+	ExternalIPPool string `json:"externalIPPool,omitempty"`
+	// GatewayIP is required unless ExternalIPPool is set
+	// +optional
+	// +kubebuilder:validation:Format=ipv4
+	GatewayIP string `json:"gatewayIP,omitempty"`
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+	// +optional
+	NodeSelector []NodeSelector `json:"nodeSelector"`
+	// +optional
+	Affinity corev1.Affinity `json:"affinity"`
+	// Tolerations of the router pods
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// PriorityClassName of the router pods
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// Resources of the router container. Requests default to the limits.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// ImagePullPolicy of the router container. Defaults to Always.
+	// +optional
+	// +kubebuilder:validation:Enum=Always;Never;IfNotPresent
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// ImagePullSecrets are used to pull Image from a private registry
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// Privileged runs the router container privileged. Defaults to true.
+	// +optional
+	Privileged *bool `json:"privileged,omitempty"`
+	// PodTemplateOverlay is a strategic merge patch of a PodTemplateSpec
+	// applied to the router pod template, e.g. to add sidecars, volumes or
+	// host aliases. The labels, annotations, finalizers, service account and
+	// router container the manager relies on cannot be changed.
+	// +optional
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	PodTemplateOverlay *runtime.RawExtension `json:"podTemplateOverlay,omitempty"`
+	// DisruptionBudget configures the PodDisruptionBudget of the router pods.
+	// Defaults to maxUnavailable 1.
+	// +optional
+	DisruptionBudget *DisruptionBudgetSpec `json:"disruptionBudget,omitempty"`
+	// ReplicaAddressing gives every router replica its own address instead
+	// of configuring all of them with the same one. The addresses assigned
+	// to the router pods are reported in status.replicaAddresses.
+	// +optional
+	ReplicaAddressing *ReplicaAddressing `json:"replicaAddressing,omitempty"`
+	// AdditionalRoleRules are granted to the router pods on top of the
+	// rules configured for the manager. Every rule has to be allowed by the
+	// manager configuration.
+	// +optional
+	AdditionalRoleRules []rbacv1.PolicyRule `json:"additionalRoleRules,omitempty"`
+}
+
+// DisruptionBudgetSpec sets at most one of MinAvailable and MaxUnavailable.
+// An integer MinAvailable is lowered below the replicas so that nodes can
+// still be drained one router pod at a time.
+type DisruptionBudgetSpec struct {
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// ReplicaAddressing lists the addresses handed out to the router pods. A
+// pool that is not set leaves the interface with the address of the spec.
+type ReplicaAddressing struct {
+	// Internal addresses replace the internal address of the spec
+	// +optional
+	Internal *AddressPool `json:"internal,omitempty"`
+	// External addresses replace the external address of the spec
+	// +optional
+	External *AddressPool `json:"external,omitempty"`
+}
+
+// AddressPool sets exactly one of Addresses and Range. The addresses use the
+// InternalNetmask and ExternalNetmask.
+type AddressPool struct {
+	// +optional
+	// +kubebuilder:validation:items:Format=ipv4
+	Addresses []string `json:"addresses,omitempty"`
+	// Range is an inclusive range of addresses, e.g. 10.0.0.10-10.0.0.19
+	// +optional
+	Range string `json:"range,omitempty"`
+}
+
+// ReplicaAddress is the address assigned to a router pod
+type ReplicaAddress struct {
+	Pod string `json:"pod"`
+	// +optional
+	InternalIP string `json:"internalIP,omitempty"`
+	// +optional
+	ExternalIP string `json:"externalIP,omitempty"`
 }
 
 // VirtualRouterStatus is the status for a VirtualRouter resource
 type VirtualRouterStatus struct {
+	// +optional
 	AvailableReplicas int32 `json:"availableReplicas"`
+	// Namespace is the namespace generated for the router resources
+	Namespace string `json:"namespace,omitempty"`
+	// ObservedGeneration is the most recent generation observed by the manager
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions describe the current state of the resources generated for
+	// the VirtualRouter
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ReplicaAddresses are the addresses assigned to the router pods when
+	// spec.replicaAddressing is set
+	// +optional
+	// +listType=map
+	// +listMapKey=pod
+	ReplicaAddresses []ReplicaAddress `json:"replicaAddresses,omitempty"`
+	// IPAllocations are the addresses allocated to the VirtualRouter from
+	// the IPPools of the spec
+	// +optional
+	// +listType=map
+	// +listMapKey=interface
+	IPAllocations []IPAllocation `json:"ipAllocations,omitempty"`
+	// VlanAllocation is the VLAN claimed by the VirtualRouter from a VlanPool
+	// +optional
+	VlanAllocation *VlanAllocation `json:"vlanAllocation,omitempty"`
+	// PodAttachments are reported by the daemons for the router pods on
+	// their node
+	// +optional
+	// +listType=map
+	// +listMapKey=pod
+	PodAttachments []PodAttachment `json:"podAttachments,omitempty"`
 }
 
+// PodAttachment is the state of the interfaces the daemon set up for a router
+// pod
+type PodAttachment struct {
+	Pod string `json:"pod"`
+	// Node the router pod runs on
+	Node string `json:"node"`
+	// +optional
+	ContainerID string `json:"containerID,omitempty"`
+	// InternalInterface and ExternalInterface are the host side veths of
+	// the router pod
+	// +optional
+	InternalInterface string `json:"internalInterface,omitempty"`
+	// +optional
+	ExternalInterface string `json:"externalInterface,omitempty"`
+	// VlanNumber, InternalIP, ExternalIP and GatewayIP are the settings
+	// applied to the router pod
+	// +optional
+	VlanNumber int32 `json:"vlanNumber,omitempty"`
+	// +optional
+	InternalIP string `json:"internalIP,omitempty"`
+	// +optional
+	ExternalIP string `json:"externalIP,omitempty"`
+	// +optional
+	GatewayIP string `json:"gatewayIP,omitempty"`
+	// LastError is the error of the last attach, if it failed
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+	// LastUpdateTime is the last time the attachment changed
+	// +optional
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// VlanAllocation is a VLAN claimed from a VlanPool
+type VlanAllocation struct {
+	// Pool is the name of the VlanPool the VLAN is claimed from
+	Pool       string `json:"pool"`
+	VlanNumber int32  `json:"vlanNumber"`
+}
+
+// IPAllocation is an address allocated to an interface of a VirtualRouter
+type IPAllocation struct {
+	// Interface is IPAllocationInternal or IPAllocationExternal
+	Interface string `json:"interface"`
+	// Pool is the name of the IPPool the address is allocated from
+	Pool    string `json:"pool"`
+	Address string `json:"address"`
+	Netmask string `json:"netmask"`
+	// Gateway of the pool
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
+}
+
+// Interfaces addresses are allocated to
+const (
+	IPAllocationInternal string = "internal"
+	IPAllocationExternal string = "external"
+)
+
+// Kinds of workload the router pods run in
+const (
+	WorkloadKindDeployment  string = "Deployment"
+	WorkloadKindStatefulSet string = "StatefulSet"
+)
+
+// Condition types reported in VirtualRouterStatus.Conditions
+const (
+	// ConditionNamespaceReady is True when the generated namespace exists
+	ConditionNamespaceReady string = "NamespaceReady"
+	// ConditionRBACReady is True when the ServiceAccount, Role and RoleBinding
+	// used by the router pods exist
+	ConditionRBACReady string = "RBACReady"
+	// ConditionDeploymentAvailable is True when every desired router replica
+	// is available
+	ConditionDeploymentAvailable string = "DeploymentAvailable"
+	// ConditionNetworkAttached is reported by the daemon and is True when the
+	// router pods are wired up to the host bridges
+	ConditionNetworkAttached string = "NetworkAttached"
+	// ConditionDisruptionBudgetReady is True when the PodDisruptionBudget of
+	// the router pods is up to date
+	ConditionDisruptionBudgetReady string = "DisruptionBudgetReady"
+	// ConditionIPAllocated is True when the addresses of the IPPools the
+	// VirtualRouter references are allocated
+	ConditionIPAllocated string = "IPAllocated"
+	// ConditionVlanAllocated is True when the VLAN of the VirtualRouter is
+	// claimed from a VlanPool, and False when it is owned by another tenant
+	ConditionVlanAllocated string = "VlanAllocated"
+	// ConditionConflict is True while an address of the VirtualRouter is
+	// used by a VirtualRouter created before it, and is not reported otherwise
+	ConditionConflict string = "Conflict"
+	// ConditionDegraded is True when any of the conditions above is False, or
+	// ConditionConflict is True
+	ConditionDegraded string = "Degraded"
+	// ConditionTerminating is True while the resources generated for a deleted
+	// VirtualRouter are being torn down
+	ConditionTerminating string = "Terminating"
+)
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
 
 // VirtualRouterList is a list of VirtualRouter resources
 type VirtualRouterList struct {
@@ -67,3 +338,147 @@ type NodeSelector struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
 }
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=ipp
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="CIDR",type=string,JSONPath=`.spec.cidr`
+// +kubebuilder:printcolumn:name="Gateway",type=string,JSONPath=`.spec.gateway`
+// +kubebuilder:printcolumn:name="Allocated",type=integer,JSONPath=`.status.allocated`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// IPPool is a cluster-wide range of addresses the manager allocates to the
+// VirtualRouters referencing it
+type IPPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec IPPoolSpec `json:"spec"`
+	// +optional
+	Status IPPoolStatus `json:"status"`
+}
+
+// IPPoolSpec is the spec for an IPPool resource
+type IPPoolSpec struct {
+	// CIDR is the subnet addresses are allocated from, e.g. 10.0.0.0/24. It
+	// cannot be changed once set.
+	// +kubebuilder:validation:Format=cidr
+	CIDR string `json:"cidr"`
+	// Exclusions are addresses, or inclusive ranges of addresses such as
+	// 10.0.0.1-10.0.0.9, that are never allocated
+	// +optional
+	Exclusions []string `json:"exclusions,omitempty"`
+	// Gateway of the subnet. It is never allocated.
+	// +optional
+	// +kubebuilder:validation:Format=ipv4
+	Gateway string `json:"gateway,omitempty"`
+}
+
+// IPPoolStatus is the status for an IPPool resource
+type IPPoolStatus struct {
+	// Allocated is the number of allocated addresses
+	// +optional
+	Allocated int32 `json:"allocated"`
+	// Allocations are the addresses allocated from the pool
+	// +optional
+	// +listType=map
+	// +listMapKey=address
+	Allocations []IPPoolAllocation `json:"allocations,omitempty"`
+}
+
+// IPPoolAllocation is an address allocated to an interface of a VirtualRouter
+type IPPoolAllocation struct {
+	Address string `json:"address"`
+	// Namespace and Name of the VirtualRouter
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Interface is IPAllocationInternal or IPAllocationExternal
+	Interface string `json:"interface"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// IPPoolList is a list of IPPool resources
+type IPPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []IPPool `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=vlp
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ranges",type=string,JSONPath=`.spec.ranges`
+// +kubebuilder:printcolumn:name="Allocated",type=integer,JSONPath=`.status.allocated`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VlanPool is a cluster-wide range of VLANs. Every VLAN of the pool belongs to
+// the namespace of the VirtualRouters claiming it, so that the VirtualRouters
+// of different tenants never share a VLAN.
+type VlanPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VlanPoolSpec `json:"spec"`
+	// +optional
+	Status VlanPoolStatus `json:"status"`
+}
+
+// VlanPoolSpec is the spec for a VlanPool resource
+type VlanPoolSpec struct {
+	// Ranges are VLANs, or inclusive ranges of VLANs such as 100-199
+	// +kubebuilder:validation:MinItems=1
+	Ranges []string `json:"ranges"`
+	// Reservations set VLANs of the ranges aside for a namespace
+	// +optional
+	Reservations []VlanReservation `json:"reservations,omitempty"`
+}
+
+// VlanReservation sets VLANs aside for the VirtualRouters of a namespace.
+// They are allocated to its VirtualRouters before the unreserved ones.
+type VlanReservation struct {
+	Namespace string `json:"namespace"`
+	// Ranges are VLANs, or inclusive ranges of VLANs, of the pool
+	// +kubebuilder:validation:MinItems=1
+	Ranges []string `json:"ranges"`
+}
+
+// VlanPoolStatus is the status for a VlanPool resource
+type VlanPoolStatus struct {
+	// Allocated is the number of claimed VLANs
+	// +optional
+	Allocated int32 `json:"allocated"`
+	// Allocations are the VLANs claimed from the pool
+	// +optional
+	// +listType=map
+	// +listMapKey=vlanNumber
+	Allocations []VlanPoolAllocation `json:"allocations,omitempty"`
+}
+
+// VlanPoolAllocation is a VLAN claimed by the VirtualRouters of a namespace
+type VlanPoolAllocation struct {
+	VlanNumber int32 `json:"vlanNumber"`
+	// Namespace of the VirtualRouters, which owns the VLAN
+	Namespace string `json:"namespace"`
+	// VirtualRouters are the names of the VirtualRouters using the VLAN
+	VirtualRouters []string `json:"virtualRouters"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// VlanPoolList is a list of VlanPool resources
+type VlanPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []VlanPool `json:"items"`
+}
@@ -0,0 +1,437 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apimachineryvalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	v1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+const (
+	MIN_VLAN_NUMBER = 1
+	MAX_VLAN_NUMBER = 4094
+)
+
+// ValidateVirtualRouter validates a VirtualRouter on create.
+func ValidateVirtualRouter(virtualRouter *v1.VirtualRouter) field.ErrorList {
+	return ValidateVirtualRouterSpec(&virtualRouter.Spec, field.NewPath("spec"))
+}
+
+// ValidateVirtualRouterUpdate validates a VirtualRouter on update, including
+// the fields which may not change once set. The spec is only validated when it
+// changes, so metadata updates such as removing a finalizer still succeed for
+// VirtualRouters created before validation existed.
+func ValidateVirtualRouterUpdate(newVirtualRouter, oldVirtualRouter *v1.VirtualRouter) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if !equality.Semantic.DeepEqual(newVirtualRouter.Spec, oldVirtualRouter.Spec) {
+		allErrs = append(allErrs, ValidateVirtualRouter(newVirtualRouter)...)
+	}
+	specPath := field.NewPath("spec")
+	allErrs = append(allErrs, apimachineryvalidation.ValidateImmutableField(newVirtualRouter.Spec.DeploymentName, oldVirtualRouter.Spec.DeploymentName, specPath.Child("deploymentName"))...)
+	allErrs = append(allErrs, apimachineryvalidation.ValidateImmutableField(workloadKind(&newVirtualRouter.Spec), workloadKind(&oldVirtualRouter.Spec), specPath.Child("workloadKind"))...)
+	return allErrs
+}
+
+// ValidateIPPool validates an IPPool on create.
+func ValidateIPPool(pool *v1.IPPool) field.ErrorList {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	subnet, err := pool.Spec.Subnet()
+	if err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("cidr"), pool.Spec.CIDR, err.Error()))
+	} else if ip, _, _ := net.ParseCIDR(pool.Spec.CIDR); !ip.Equal(subnet.IP) {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("cidr"), pool.Spec.CIDR, fmt.Sprintf("must be the network address of the subnet, e.g. %s", subnet.String())))
+	}
+
+	if pool.Spec.Gateway != "" {
+		gateway, errs := validateIPv4(pool.Spec.Gateway, specPath.Child("gateway"))
+		allErrs = append(allErrs, errs...)
+		if gateway != nil && subnet != nil {
+			if !subnet.Contains(gateway) {
+				allErrs = append(allErrs, field.Invalid(specPath.Child("gateway"), pool.Spec.Gateway, fmt.Sprintf("must be inside the subnet %s", subnet.String())))
+			} else {
+				allErrs = append(allErrs, validateHostAddress(gateway, subnet.Mask, pool.Spec.Gateway, specPath.Child("gateway"))...)
+			}
+		}
+	}
+
+	for i, exclusion := range pool.Spec.Exclusions {
+		if _, _, err := v1.ParseExclusion(exclusion); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("exclusions").Index(i), exclusion, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// ValidateIPPoolUpdate validates an IPPool on update. The CIDR cannot change,
+// since the addresses already allocated from the pool would leave it.
+func ValidateIPPoolUpdate(newPool, oldPool *v1.IPPool) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if !equality.Semantic.DeepEqual(newPool.Spec, oldPool.Spec) {
+		allErrs = append(allErrs, ValidateIPPool(newPool)...)
+	}
+	allErrs = append(allErrs, apimachineryvalidation.ValidateImmutableField(newPool.Spec.CIDR, oldPool.Spec.CIDR, field.NewPath("spec", "cidr"))...)
+	return allErrs
+}
+
+// ValidateVlanPool validates a VlanPool on create. Every VLAN can only be
+// reserved for one namespace, and only within the ranges of the pool.
+func ValidateVlanPool(pool *v1.VlanPool) field.ErrorList {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	if len(pool.Spec.Ranges) == 0 {
+		allErrs = append(allErrs, field.Required(specPath.Child("ranges"), ""))
+	}
+	allErrs = append(allErrs, validateVlanRanges(pool.Spec.Ranges, specPath.Child("ranges"))...)
+
+	reserved := map[int32]string{}
+	for i, reservation := range pool.Spec.Reservations {
+		reservationPath := specPath.Child("reservations").Index(i)
+		for _, msg := range apimachineryvalidation.ValidateNamespaceName(reservation.Namespace, false) {
+			allErrs = append(allErrs, field.Invalid(reservationPath.Child("namespace"), reservation.Namespace, msg))
+		}
+		if len(reservation.Ranges) == 0 {
+			allErrs = append(allErrs, field.Required(reservationPath.Child("ranges"), ""))
+		}
+		allErrs = append(allErrs, validateVlanRanges(reservation.Ranges, reservationPath.Child("ranges"))...)
+		for j, r := range reservation.Ranges {
+			first, last, err := v1.ParseVlanRange(r)
+			if err != nil {
+				continue
+			}
+			for vlan := first; vlan <= last; vlan++ {
+				if !pool.Spec.Contains(vlan) {
+					allErrs = append(allErrs, field.Invalid(reservationPath.Child("ranges").Index(j), r, fmt.Sprintf("VLAN %d is not in the ranges of the pool", vlan)))
+					break
+				}
+				if namespace, ok := reserved[vlan]; ok && namespace != reservation.Namespace {
+					allErrs = append(allErrs, field.Invalid(reservationPath.Child("ranges").Index(j), r, fmt.Sprintf("VLAN %d is already reserved for namespace %q", vlan, namespace)))
+					break
+				}
+				reserved[vlan] = reservation.Namespace
+			}
+		}
+	}
+	return allErrs
+}
+
+// ValidateVlanPoolUpdate validates a VlanPool on update. The VLANs already
+// claimed from the pool stay claimed when its ranges shrink.
+func ValidateVlanPoolUpdate(newPool, oldPool *v1.VlanPool) field.ErrorList {
+	if equality.Semantic.DeepEqual(newPool.Spec, oldPool.Spec) {
+		return field.ErrorList{}
+	}
+	return ValidateVlanPool(newPool)
+}
+
+func validateVlanRanges(ranges []string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for i, r := range ranges {
+		first, last, err := v1.ParseVlanRange(r)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), r, err.Error()))
+		} else if first < MIN_VLAN_NUMBER || last > MAX_VLAN_NUMBER {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), r, fmt.Sprintf("must be between %d and %d", MIN_VLAN_NUMBER, MAX_VLAN_NUMBER)))
+		}
+	}
+	return allErrs
+}
+
+// workloadKind returns the workload kind of spec, which defaults to a
+// Deployment for VirtualRouters created before the field existed.
+func workloadKind(spec *v1.VirtualRouterSpec) string {
+	if spec.WorkloadKind == "" {
+		return v1.WorkloadKindDeployment
+	}
+	return spec.WorkloadKind
+}
+
+// ValidateVirtualRouterSpec validates the addressing of a VirtualRouterSpec.
+// The daemon only handles IPv4 addresses with dotted netmasks.
+func ValidateVirtualRouterSpec(spec *v1.VirtualRouterSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if spec.DeploymentName == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("deploymentName"), ""))
+	} else {
+		for _, msg := range apimachineryvalidation.NameIsDNSSubdomain(spec.DeploymentName, false) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("deploymentName"), spec.DeploymentName, msg))
+		}
+	}
+
+	// 0 leaves the internal interface untagged
+	if spec.VlanNumber != 0 && (spec.VlanNumber < MIN_VLAN_NUMBER || spec.VlanNumber > MAX_VLAN_NUMBER) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("vlanNumber"), spec.VlanNumber,
+			fmt.Sprintf("must be between %d and %d, or 0 for no VLAN", MIN_VLAN_NUMBER, MAX_VLAN_NUMBER)))
+	}
+	if spec.VlanPool != "" {
+		for _, msg := range apimachineryvalidation.NameIsDNSSubdomain(spec.VlanPool, false) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("vlanPool"), spec.VlanPool, msg))
+		}
+	}
+
+	var internalIP, externalIP net.IP
+	var internalMask, externalMask net.IPMask
+	var errs field.ErrorList
+	if spec.InternalIPPool != "" {
+		allErrs = append(allErrs, validateIPPoolReference(spec.InternalIPPool, spec.InternalIP, spec.InternalNetmask,
+			fldPath.Child("internalIPPool"), fldPath.Child("internalIP"), fldPath.Child("internalNetmask"))...)
+	} else {
+		internalIP, errs = validateIPv4(spec.InternalIP, fldPath.Child("internalIP"))
+		allErrs = append(allErrs, errs...)
+		internalMask, errs = validateNetmask(spec.InternalNetmask, fldPath.Child("internalNetmask"))
+		allErrs = append(allErrs, errs...)
+		if internalIP != nil && internalMask != nil {
+			allErrs = append(allErrs, validateHostAddress(internalIP, internalMask, spec.InternalIP, fldPath.Child("internalIP"))...)
+		}
+	}
+
+	if spec.ExternalIPPool != "" {
+		allErrs = append(allErrs, validateIPPoolReference(spec.ExternalIPPool, spec.ExternalIP, spec.ExternalNetmask,
+			fldPath.Child("externalIPPool"), fldPath.Child("externalIP"), fldPath.Child("externalNetmask"))...)
+	} else {
+		externalIP, errs = validateIPv4(spec.ExternalIP, fldPath.Child("externalIP"))
+		allErrs = append(allErrs, errs...)
+		externalMask, errs = validateNetmask(spec.ExternalNetmask, fldPath.Child("externalNetmask"))
+		allErrs = append(allErrs, errs...)
+		if externalIP != nil && externalMask != nil {
+			allErrs = append(allErrs, validateHostAddress(externalIP, externalMask, spec.ExternalIP, fldPath.Child("externalIP"))...)
+		}
+	}
+
+	// The gateway of the external IPPool is used when none is set
+	if spec.GatewayIP != "" || spec.ExternalIPPool == "" {
+		gatewayIP, errs := validateIPv4(spec.GatewayIP, fldPath.Child("gatewayIP"))
+		allErrs = append(allErrs, errs...)
+		if gatewayIP != nil && externalIP != nil && externalMask != nil {
+			subnet := &net.IPNet{IP: externalIP.Mask(externalMask), Mask: externalMask}
+			if !subnet.Contains(gatewayIP) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("gatewayIP"), spec.GatewayIP,
+					fmt.Sprintf("must be inside the external subnet %s", subnet.String())))
+			} else if gatewayIP.Equal(externalIP) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("gatewayIP"), spec.GatewayIP, "must differ from externalIP"))
+			}
+		}
+	}
+
+	if spec.ReplicaAddressing != nil {
+		var replicas int32 = 1
+		if spec.Replicas != nil {
+			replicas = *spec.Replicas
+		}
+		addressingPath := fldPath.Child("replicaAddressing")
+		allErrs = append(allErrs, validateAddressPool(spec.ReplicaAddressing.Internal, replicas, subnetOf(internalIP, internalMask), addressingPath.Child("internal"))...)
+		allErrs = append(allErrs, validateAddressPool(spec.ReplicaAddressing.External, replicas, subnetOf(externalIP, externalMask), addressingPath.Child("external"))...)
+	}
+
+	allErrs = append(allErrs, validateResources(&spec.Resources, fldPath.Child("resources"))...)
+	allErrs = append(allErrs, validatePodTemplateOverlay(spec.PodTemplateOverlay, fldPath.Child("podTemplateOverlay"))...)
+	allErrs = append(allErrs, validateDisruptionBudget(spec.DisruptionBudget, fldPath.Child("disruptionBudget"))...)
+	allErrs = append(allErrs, validateRoleRules(spec.AdditionalRoleRules, fldPath.Child("additionalRoleRules"))...)
+
+	return allErrs
+}
+
+// validateIPPoolReference checks the name of a referenced IPPool, and that
+// the address and netmask the pool provides are not set as well.
+func validateIPPoolReference(pool string, ip string, netmask string, fldPath *field.Path, ipPath *field.Path, netmaskPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for _, msg := range apimachineryvalidation.NameIsDNSSubdomain(pool, false) {
+		allErrs = append(allErrs, field.Invalid(fldPath, pool, msg))
+	}
+	if ip != "" {
+		allErrs = append(allErrs, field.Forbidden(ipPath, fmt.Sprintf("must not be set together with %s", fldPath.String())))
+	}
+	if netmask != "" {
+		allErrs = append(allErrs, field.Forbidden(netmaskPath, fmt.Sprintf("must not be set together with %s", fldPath.String())))
+	}
+	return allErrs
+}
+
+// validateAddressPool checks that a pool holds a distinct host address for
+// every replica, inside the subnet of the address it replaces if it is valid.
+func validateAddressPool(pool *v1.AddressPool, replicas int32, subnet *net.IPNet, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if pool == nil {
+		return allErrs
+	}
+	if (len(pool.Addresses) == 0) == (pool.Range == "") {
+		return append(allErrs, field.Invalid(fldPath, "", "exactly one of addresses and range must be set"))
+	}
+	addresses, err := pool.List()
+	if err != nil {
+		return append(allErrs, field.Invalid(fldPath.Child("range"), pool.Range, err.Error()))
+	}
+
+	seen := map[string]bool{}
+	for i, address := range addresses {
+		addressPath := fldPath.Child("addresses").Index(i)
+		if pool.Range != "" {
+			addressPath = fldPath.Child("range")
+		}
+		ip, errs := validateIPv4(address, addressPath)
+		if ip == nil {
+			allErrs = append(allErrs, errs...)
+			continue
+		}
+		if seen[ip.String()] {
+			allErrs = append(allErrs, field.Duplicate(addressPath, address))
+			continue
+		}
+		seen[ip.String()] = true
+		if subnet == nil {
+			continue
+		}
+		if !subnet.Contains(ip) {
+			errs = field.ErrorList{field.Invalid(addressPath, address, fmt.Sprintf("must be inside the subnet %s", subnet.String()))}
+		} else {
+			errs = validateHostAddress(ip, subnet.Mask, address, addressPath)
+		}
+		allErrs = append(allErrs, errs...)
+		// A range is reported once
+		if len(errs) != 0 && pool.Range != "" {
+			break
+		}
+	}
+	if len(addresses) < int(replicas) {
+		allErrs = append(allErrs, field.Invalid(fldPath, len(addresses), fmt.Sprintf("must hold at least %d addresses, one per replica", replicas)))
+	}
+	return allErrs
+}
+
+func subnetOf(ip net.IP, mask net.IPMask) *net.IPNet {
+	if ip == nil || mask == nil {
+		return nil
+	}
+	return &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+}
+
+// validatePodTemplateOverlay rejects overlays that are not a strategic merge
+// patch of a PodTemplateSpec.
+func validatePodTemplateOverlay(overlay *runtime.RawExtension, fldPath *field.Path) field.ErrorList {
+	if overlay == nil || len(overlay.Raw) == 0 {
+		return nil
+	}
+	patched, err := strategicpatch.StrategicMergePatch([]byte("{}"), overlay.Raw, corev1.PodTemplateSpec{})
+	if err == nil {
+		err = json.Unmarshal(patched, &corev1.PodTemplateSpec{})
+	}
+	if err != nil {
+		return field.ErrorList{field.Invalid(fldPath, string(overlay.Raw), fmt.Sprintf("must be a PodTemplateSpec patch: %v", err))}
+	}
+	return nil
+}
+
+// validateDisruptionBudget allows at most one of minAvailable and
+// maxUnavailable, each a non-negative integer or a percentage.
+func validateDisruptionBudget(budget *v1.DisruptionBudgetSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if budget == nil {
+		return allErrs
+	}
+	if budget.MinAvailable != nil && budget.MaxUnavailable != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath, "", "minAvailable and maxUnavailable cannot be both set"))
+	}
+	allErrs = append(allErrs, validateIntOrPercent(budget.MinAvailable, fldPath.Child("minAvailable"))...)
+	allErrs = append(allErrs, validateIntOrPercent(budget.MaxUnavailable, fldPath.Child("maxUnavailable"))...)
+	return allErrs
+}
+
+// validateRoleRules requires the rules of a Role to grant verbs on resources.
+// Whether a rule is allowed is decided by the manager configuration.
+func validateRoleRules(rules []rbacv1.PolicyRule, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for i, rule := range rules {
+		rulePath := fldPath.Index(i)
+		if len(rule.Verbs) == 0 {
+			allErrs = append(allErrs, field.Required(rulePath.Child("verbs"), ""))
+		}
+		if len(rule.APIGroups) == 0 {
+			allErrs = append(allErrs, field.Required(rulePath.Child("apiGroups"), ""))
+		}
+		if len(rule.Resources) == 0 {
+			allErrs = append(allErrs, field.Required(rulePath.Child("resources"), ""))
+		}
+		if len(rule.NonResourceURLs) != 0 {
+			allErrs = append(allErrs, field.Forbidden(rulePath.Child("nonResourceURLs"), "a Role cannot grant non-resource URLs"))
+		}
+	}
+	return allErrs
+}
+
+func validateIntOrPercent(value *intstr.IntOrString, fldPath *field.Path) field.ErrorList {
+	if value == nil {
+		return nil
+	}
+	if v, err := intstr.GetValueFromIntOrPercent(value, 100, false); err != nil || v < 0 {
+		return field.ErrorList{field.Invalid(fldPath, value.String(), "must be a non-negative integer or a percentage")}
+	}
+	return nil
+}
+
+// validateResources rejects requests above their limit.
+func validateResources(resources *corev1.ResourceRequirements, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for name, request := range resources.Requests {
+		if limit, ok := resources.Limits[name]; ok && request.Cmp(limit) > 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("requests").Key(string(name)), request.String(),
+				fmt.Sprintf("must be less than or equal to %s limit", name)))
+		}
+	}
+	return allErrs
+}
+
+func validateIPv4(value string, fldPath *field.Path) (net.IP, field.ErrorList) {
+	if value == "" {
+		return nil, field.ErrorList{field.Required(fldPath, "")}
+	}
+	ip := net.ParseIP(value).To4()
+	if ip == nil {
+		return nil, field.ErrorList{field.Invalid(fldPath, value, "must be a valid IPv4 address")}
+	}
+	return ip, nil
+}
+
+func validateNetmask(value string, fldPath *field.Path) (net.IPMask, field.ErrorList) {
+	if value == "" {
+		return nil, field.ErrorList{field.Required(fldPath, "")}
+	}
+	ip := net.ParseIP(value).To4()
+	if ip == nil {
+		return nil, field.ErrorList{field.Invalid(fldPath, value, "must be a dotted IPv4 netmask, e.g. 255.255.255.0")}
+	}
+	mask := net.IPMask(ip)
+	if ones, bits := mask.Size(); bits == 0 || ones == 0 {
+		return nil, field.ErrorList{field.Invalid(fldPath, value, "must be a contiguous, non-zero netmask")}
+	}
+	return mask, nil
+}
+
+// validateHostAddress rejects the network and broadcast addresses of subnets
+// which have host addresses.
+func validateHostAddress(ip net.IP, mask net.IPMask, value string, fldPath *field.Path) field.ErrorList {
+	if ones, bits := mask.Size(); bits-ones < 2 {
+		return nil
+	}
+	network := ip.Mask(mask)
+	broadcast := make(net.IP, len(network))
+	for i := range network {
+		broadcast[i] = network[i] | ^mask[i]
+	}
+	if ip.Equal(network) || ip.Equal(broadcast) {
+		return field.ErrorList{field.Invalid(fldPath, value, "must not be the network or broadcast address of its subnet")}
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package validation
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	v1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+func newVirtualRouter() *v1.VirtualRouter {
+	return &v1.VirtualRouter{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault},
+		Spec: v1.VirtualRouterSpec{
+			DeploymentName:  "test-deployment",
+			VlanNumber:      210,
+			InternalIP:      "10.10.10.11",
+			InternalNetmask: "255.255.255.0",
+			ExternalIP:      "192.168.8.153",
+			ExternalNetmask: "255.255.255.0",
+			GatewayIP:       "192.168.8.1",
+		},
+	}
+}
+
+func TestValidateVirtualRouter(t *testing.T) {
+	testCases := []struct {
+		name   string
+		mutate func(*v1.VirtualRouterSpec)
+		field  string
+		errTyp field.ErrorType
+	}{
+		{"valid", func(*v1.VirtualRouterSpec) {}, "", ""},
+		{"no vlan", func(s *v1.VirtualRouterSpec) { s.VlanNumber = 0 }, "", ""},
+		{"vlan too large", func(s *v1.VirtualRouterSpec) { s.VlanNumber = 4095 }, "spec.vlanNumber", field.ErrorTypeInvalid},
+		{"negative vlan", func(s *v1.VirtualRouterSpec) { s.VlanNumber = -1 }, "spec.vlanNumber", field.ErrorTypeInvalid},
+		{"vlan pool", func(s *v1.VirtualRouterSpec) { s.VlanNumber, s.VlanPool = 0, "tenants" }, "", ""},
+		{"invalid vlan pool name", func(s *v1.VirtualRouterSpec) { s.VlanPool = "Tenant_VLANs" }, "spec.vlanPool", field.ErrorTypeInvalid},
+		{"empty deploymentName", func(s *v1.VirtualRouterSpec) { s.DeploymentName = "" }, "spec.deploymentName", field.ErrorTypeRequired},
+		{"invalid deploymentName", func(s *v1.VirtualRouterSpec) { s.DeploymentName = "Router_1" }, "spec.deploymentName", field.ErrorTypeInvalid},
+		{"non-IP internalIP", func(s *v1.VirtualRouterSpec) { s.InternalIP = "10.10.10" }, "spec.internalIP", field.ErrorTypeInvalid},
+		{"IPv6 externalIP", func(s *v1.VirtualRouterSpec) { s.ExternalIP = "fd00::1" }, "spec.externalIP", field.ErrorTypeInvalid},
+		{"network address", func(s *v1.VirtualRouterSpec) { s.InternalIP = "10.10.10.0" }, "spec.internalIP", field.ErrorTypeInvalid},
+		{"non-contiguous netmask", func(s *v1.VirtualRouterSpec) { s.InternalNetmask = "255.0.255.0" }, "spec.internalNetmask", field.ErrorTypeInvalid},
+		{"prefix netmask", func(s *v1.VirtualRouterSpec) { s.ExternalNetmask = "24" }, "spec.externalNetmask", field.ErrorTypeInvalid},
+		{"gateway outside subnet", func(s *v1.VirtualRouterSpec) { s.GatewayIP = "192.168.9.1" }, "spec.gatewayIP", field.ErrorTypeInvalid},
+		{"gateway is externalIP", func(s *v1.VirtualRouterSpec) { s.GatewayIP = s.ExternalIP }, "spec.gatewayIP", field.ErrorTypeInvalid},
+		{"missing gateway", func(s *v1.VirtualRouterSpec) { s.GatewayIP = "" }, "spec.gatewayIP", field.ErrorTypeRequired},
+		{"guaranteed resources", func(s *v1.VirtualRouterSpec) {
+			s.Resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+			s.Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+		}, "", ""},
+		{"request above limit", func(s *v1.VirtualRouterSpec) {
+			s.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")}
+			s.Resources.Requests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}
+		}, "spec.resources.requests[memory]", field.ErrorTypeInvalid},
+		{"sidecar overlay", func(s *v1.VirtualRouterSpec) {
+			s.PodTemplateOverlay = &runtime.RawExtension{Raw: []byte(`{"spec":{"containers":[{"name":"exporter","image":"exporter"}]}}`)}
+		}, "", ""},
+		{"percent budget", func(s *v1.VirtualRouterSpec) {
+			s.DisruptionBudget = &v1.DisruptionBudgetSpec{MaxUnavailable: intOrStringPtr(intstr.FromString("50%"))}
+		}, "", ""},
+		{"both budgets", func(s *v1.VirtualRouterSpec) {
+			s.DisruptionBudget = &v1.DisruptionBudgetSpec{MinAvailable: intOrStringPtr(intstr.FromInt(1)), MaxUnavailable: intOrStringPtr(intstr.FromInt(1))}
+		}, "spec.disruptionBudget", field.ErrorTypeInvalid},
+		{"negative budget", func(s *v1.VirtualRouterSpec) {
+			s.DisruptionBudget = &v1.DisruptionBudgetSpec{MinAvailable: intOrStringPtr(intstr.FromInt(-1))}
+		}, "spec.disruptionBudget.minAvailable", field.ErrorTypeInvalid},
+		{"additional role rule", func(s *v1.VirtualRouterSpec) {
+			s.AdditionalRoleRules = []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}}}
+		}, "", ""},
+		{"role rule without verbs", func(s *v1.VirtualRouterSpec) {
+			s.AdditionalRoleRules = []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"configmaps"}}}
+		}, "spec.additionalRoleRules[0].verbs", field.ErrorTypeRequired},
+		{"non-resource role rule", func(s *v1.VirtualRouterSpec) {
+			s.AdditionalRoleRules = []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}, NonResourceURLs: []string{"/healthz"}}}
+		}, "spec.additionalRoleRules[0].nonResourceURLs", field.ErrorTypeForbidden},
+		{"replica addresses", func(s *v1.VirtualRouterSpec) {
+			s.Replicas = int32Ptr(3)
+			s.ReplicaAddressing = &v1.ReplicaAddressing{
+				Internal: &v1.AddressPool{Range: "10.10.10.20-10.10.10.22"},
+				External: &v1.AddressPool{Addresses: []string{"192.168.8.160", "192.168.8.161", "192.168.8.162"}},
+			}
+		}, "", ""},
+		{"too few replica addresses", func(s *v1.VirtualRouterSpec) {
+			s.Replicas = int32Ptr(3)
+			s.ReplicaAddressing = &v1.ReplicaAddressing{Internal: &v1.AddressPool{Range: "10.10.10.20-10.10.10.21"}}
+		}, "spec.replicaAddressing.internal", field.ErrorTypeInvalid},
+		{"replica address outside subnet", func(s *v1.VirtualRouterSpec) {
+			s.ReplicaAddressing = &v1.ReplicaAddressing{External: &v1.AddressPool{Addresses: []string{"192.168.9.160"}}}
+		}, "spec.replicaAddressing.external.addresses[0]", field.ErrorTypeInvalid},
+		{"duplicate replica address", func(s *v1.VirtualRouterSpec) {
+			s.Replicas = int32Ptr(2)
+			s.ReplicaAddressing = &v1.ReplicaAddressing{External: &v1.AddressPool{Addresses: []string{"192.168.8.160", "192.168.8.160", "192.168.8.161"}}}
+		}, "spec.replicaAddressing.external.addresses[1]", field.ErrorTypeDuplicate},
+		{"reversed replica address range", func(s *v1.VirtualRouterSpec) {
+			s.ReplicaAddressing = &v1.ReplicaAddressing{Internal: &v1.AddressPool{Range: "10.10.10.22-10.10.10.20"}}
+		}, "spec.replicaAddressing.internal.range", field.ErrorTypeInvalid},
+		{"broadcast in replica address range", func(s *v1.VirtualRouterSpec) {
+			s.ReplicaAddressing = &v1.ReplicaAddressing{Internal: &v1.AddressPool{Range: "10.10.10.250-10.10.10.255"}}
+		}, "spec.replicaAddressing.internal.range", field.ErrorTypeInvalid},
+		{"internal IP pool", func(s *v1.VirtualRouterSpec) {
+			s.InternalIP, s.InternalNetmask, s.InternalIPPool = "", "", "internal"
+		}, "", ""},
+		{"external IP pool with its gateway", func(s *v1.VirtualRouterSpec) {
+			s.ExternalIP, s.ExternalNetmask, s.GatewayIP, s.ExternalIPPool = "", "", "", "external"
+		}, "", ""},
+		{"IP pool and address", func(s *v1.VirtualRouterSpec) {
+			s.InternalNetmask, s.InternalIPPool = "", "internal"
+		}, "spec.internalIP", field.ErrorTypeForbidden},
+		{"invalid IP pool name", func(s *v1.VirtualRouterSpec) {
+			s.ExternalIP, s.ExternalNetmask, s.ExternalIPPool = "", "", "External_Pool"
+		}, "spec.externalIPPool", field.ErrorTypeInvalid},
+		{"invalid overlay", func(s *v1.VirtualRouterSpec) {
+			s.PodTemplateOverlay = &runtime.RawExtension{Raw: []byte(`{"spec":{"containers":{"name":"exporter"}}}`)}
+		}, "spec.podTemplateOverlay", field.ErrorTypeInvalid},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			virtualRouter := newVirtualRouter()
+			tc.mutate(&virtualRouter.Spec)
+			errs := ValidateVirtualRouter(virtualRouter)
+			if tc.field == "" {
+				if len(errs) != 0 {
+					t.Errorf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tc.field || errs[0].Type != tc.errTyp {
+				t.Errorf("expected a single %s error on %s, got %v", tc.errTyp, tc.field, errs)
+			}
+		})
+	}
+}
+
+func TestValidateVirtualRouterUpdate(t *testing.T) {
+	oldVirtualRouter := newVirtualRouter()
+
+	newVirtualRouter := oldVirtualRouter.DeepCopy()
+	newVirtualRouter.Spec.DeploymentName = "renamed"
+	if errs := ValidateVirtualRouterUpdate(newVirtualRouter, oldVirtualRouter); len(errs) != 1 || errs[0].Field != "spec.deploymentName" {
+		t.Errorf("expected deploymentName to be immutable, got %v", errs)
+	}
+
+	newVirtualRouter = oldVirtualRouter.DeepCopy()
+	newVirtualRouter.Spec.WorkloadKind = v1.WorkloadKindStatefulSet
+	if errs := ValidateVirtualRouterUpdate(newVirtualRouter, oldVirtualRouter); len(errs) != 1 || errs[0].Field != "spec.workloadKind" {
+		t.Errorf("expected workloadKind to be immutable, got %v", errs)
+	}
+
+	// The default of a VirtualRouter created before workloadKind existed
+	newVirtualRouter.Spec.WorkloadKind = v1.WorkloadKindDeployment
+	if errs := ValidateVirtualRouterUpdate(newVirtualRouter, oldVirtualRouter); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+
+	// An invalid spec which does not change must not block metadata updates
+	oldVirtualRouter.Spec.VlanNumber = 5000
+	newVirtualRouter = oldVirtualRouter.DeepCopy()
+	newVirtualRouter.Finalizers = nil
+	if errs := ValidateVirtualRouterUpdate(newVirtualRouter, oldVirtualRouter); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
+
+func TestValidateIPPool(t *testing.T) {
+	testCases := []struct {
+		name   string
+		spec   v1.IPPoolSpec
+		field  string
+		errTyp field.ErrorType
+	}{
+		{"valid", v1.IPPoolSpec{CIDR: "10.0.0.0/24", Gateway: "10.0.0.1", Exclusions: []string{"10.0.0.2", "10.0.0.10-10.0.0.19"}}, "", ""},
+		{"invalid cidr", v1.IPPoolSpec{CIDR: "10.0.0.0"}, "spec.cidr", field.ErrorTypeInvalid},
+		{"IPv6 cidr", v1.IPPoolSpec{CIDR: "fd00::/64"}, "spec.cidr", field.ErrorTypeInvalid},
+		{"host bits in cidr", v1.IPPoolSpec{CIDR: "10.0.0.1/24"}, "spec.cidr", field.ErrorTypeInvalid},
+		{"gateway outside subnet", v1.IPPoolSpec{CIDR: "10.0.0.0/24", Gateway: "10.0.1.1"}, "spec.gateway", field.ErrorTypeInvalid},
+		{"broadcast gateway", v1.IPPoolSpec{CIDR: "10.0.0.0/24", Gateway: "10.0.0.255"}, "spec.gateway", field.ErrorTypeInvalid},
+		{"reversed exclusion", v1.IPPoolSpec{CIDR: "10.0.0.0/24", Exclusions: []string{"10.0.0.1", "10.0.0.9-10.0.0.2"}}, "spec.exclusions[1]", field.ErrorTypeInvalid},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := ValidateIPPool(&v1.IPPool{ObjectMeta: metav1.ObjectMeta{Name: "pool"}, Spec: tc.spec})
+			if tc.field == "" {
+				if len(errs) != 0 {
+					t.Errorf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tc.field || errs[0].Type != tc.errTyp {
+				t.Errorf("expected a single %s error on %s, got %v", tc.errTyp, tc.field, errs)
+			}
+		})
+	}
+
+	oldPool := &v1.IPPool{ObjectMeta: metav1.ObjectMeta{Name: "pool"}, Spec: v1.IPPoolSpec{CIDR: "10.0.0.0/24"}}
+	newPool := oldPool.DeepCopy()
+	newPool.Spec.CIDR = "10.0.0.0/23"
+	if errs := ValidateIPPoolUpdate(newPool, oldPool); len(errs) != 1 || errs[0].Field != "spec.cidr" {
+		t.Errorf("expected cidr to be immutable, got %v", errs)
+	}
+}
+
+func intOrStringPtr(v intstr.IntOrString) *intstr.IntOrString { return &v }
+
+func int32Ptr(i int32) *int32 { return &i }
+
+func TestValidateVlanPool(t *testing.T) {
+	testCases := []struct {
+		name   string
+		spec   v1.VlanPoolSpec
+		field  string
+		errTyp field.ErrorType
+	}{
+		{"valid", v1.VlanPoolSpec{Ranges: []string{"100-199", "210"}, Reservations: []v1.VlanReservation{{Namespace: "tenant-a", Ranges: []string{"100-109", "210"}}}}, "", ""},
+		{"no ranges", v1.VlanPoolSpec{}, "spec.ranges", field.ErrorTypeRequired},
+		{"invalid range", v1.VlanPoolSpec{Ranges: []string{"100-199", "two"}}, "spec.ranges[1]", field.ErrorTypeInvalid},
+		{"reversed range", v1.VlanPoolSpec{Ranges: []string{"199-100"}}, "spec.ranges[0]", field.ErrorTypeInvalid},
+		{"range too large", v1.VlanPoolSpec{Ranges: []string{"4000-4095"}}, "spec.ranges[0]", field.ErrorTypeInvalid},
+		{"invalid namespace", v1.VlanPoolSpec{Ranges: []string{"100"}, Reservations: []v1.VlanReservation{{Namespace: "Tenant", Ranges: []string{"100"}}}}, "spec.reservations[0].namespace", field.ErrorTypeInvalid},
+		{"reservation outside ranges", v1.VlanPoolSpec{Ranges: []string{"100-199"}, Reservations: []v1.VlanReservation{{Namespace: "tenant-a", Ranges: []string{"190-209"}}}}, "spec.reservations[0].ranges[0]", field.ErrorTypeInvalid},
+		{"reserved twice", v1.VlanPoolSpec{Ranges: []string{"100-199"}, Reservations: []v1.VlanReservation{
+			{Namespace: "tenant-a", Ranges: []string{"100-109"}},
+			{Namespace: "tenant-b", Ranges: []string{"105"}},
+		}}, "spec.reservations[1].ranges[0]", field.ErrorTypeInvalid},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := ValidateVlanPool(&v1.VlanPool{ObjectMeta: metav1.ObjectMeta{Name: "pool"}, Spec: tc.spec})
+			if tc.field == "" {
+				if len(errs) != 0 {
+					t.Errorf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tc.field || errs[0].Type != tc.errTyp {
+				t.Errorf("expected a single %s error on %s, got %v", tc.errTyp, tc.field, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseVlanRange returns the first and last VLAN of r, which is a VLAN or an
+// inclusive range of VLANs such as 100-199.
+func ParseVlanRange(r string) (int32, int32, error) {
+	parts := strings.SplitN(r, "-", 2)
+	first, err := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q must be a VLAN or a range of VLANs, e.g. 100-199", r)
+	}
+	last := first
+	if len(parts) == 2 {
+		if last, err = strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 32); err != nil {
+			return 0, 0, fmt.Errorf("%q must be a VLAN or a range of VLANs, e.g. 100-199", r)
+		}
+	}
+	if first > last {
+		return 0, 0, fmt.Errorf("range %q must not end before it starts", r)
+	}
+	return int32(first), int32(last), nil
+}
+
+// Contains reports whether vlan is in the ranges of the pool. Ranges that
+// cannot be parsed are ignored.
+func (s *VlanPoolSpec) Contains(vlan int32) bool {
+	return vlanRangesContain(s.Ranges, vlan)
+}
+
+// ReservedFor returns the namespace vlan is reserved for, or "".
+func (s *VlanPoolSpec) ReservedFor(vlan int32) string {
+	for _, reservation := range s.Reservations {
+		if vlanRangesContain(reservation.Ranges, vlan) {
+			return reservation.Namespace
+		}
+	}
+	return ""
+}
+
+// List returns the VLANs of the pool in the order of its ranges. Ranges that
+// cannot be parsed are ignored.
+func (s *VlanPoolSpec) List() []int32 {
+	var vlans []int32
+	seen := map[int32]bool{}
+	for _, r := range s.Ranges {
+		first, last, err := ParseVlanRange(r)
+		if err != nil {
+			continue
+		}
+		for vlan := first; vlan <= last; vlan++ {
+			if !seen[vlan] {
+				seen[vlan] = true
+				vlans = append(vlans, vlan)
+			}
+		}
+	}
+	return vlans
+}
+
+func vlanRangesContain(ranges []string, vlan int32) bool {
+	for _, r := range ranges {
+		first, last, err := ParseVlanRange(r)
+		if err == nil && vlan >= first && vlan <= last {
+			return true
+		}
+	}
+	return false
+}
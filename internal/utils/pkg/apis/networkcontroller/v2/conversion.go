@@ -0,0 +1,292 @@
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+
+	v1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+// AttachmentsAnnotation keeps the attachments of a v2 VirtualRouter that the
+// v1 fields cannot express, so that converting to v1 and back is lossless.
+const AttachmentsAnnotation = "tmax.hypercloud.com/v2-attachments"
+
+// ConvertToV1 converts in to the v1 API. The InternalInterface and
+// ExternalInterface attachments fill the v1 addressing fields.
+func ConvertToV1(in *VirtualRouter, out *v1.VirtualRouter) error {
+	out.TypeMeta = in.TypeMeta
+	out.APIVersion = v1.SchemeGroupVersion.String()
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+
+	spec := in.Spec.DeepCopy()
+	out.Spec = v1.VirtualRouterSpec{
+		DeploymentName:     spec.DeploymentName,
+		Replicas:           spec.Replicas,
+		WorkloadKind:       spec.WorkloadKind,
+		Image:              spec.Image,
+		Affinity:           spec.Affinity,
+		Tolerations:        spec.Tolerations,
+		PriorityClassName:  spec.PriorityClassName,
+		Resources:          spec.Resources,
+		ImagePullPolicy:    spec.ImagePullPolicy,
+		ImagePullSecrets:   spec.ImagePullSecrets,
+		Privileged:         spec.Privileged,
+		PodTemplateOverlay: spec.PodTemplateOverlay,
+		DisruptionBudget:   (*v1.DisruptionBudgetSpec)(spec.DisruptionBudget),
+		ReplicaAddressing:  replicaAddressingToV1(spec.ReplicaAddressing),
+	}
+	out.Spec.AdditionalRoleRules = spec.AdditionalRoleRules
+	for _, selector := range in.Spec.NodeSelector {
+		out.Spec.NodeSelector = append(out.Spec.NodeSelector, v1.NodeSelector{Key: selector.Key, Value: selector.Value})
+	}
+	if internal := findAttachment(in.Spec.Attachments, InternalInterface); internal != nil {
+		out.Spec.VlanNumber = internal.VlanNumber
+		out.Spec.VlanPool = internal.VlanPool
+		out.Spec.InternalIPPool = internal.IPPool
+		if len(internal.Addresses) != 0 {
+			out.Spec.InternalIP, out.Spec.InternalNetmask = splitAddress(internal.Addresses[0])
+		}
+	}
+	if external := findAttachment(in.Spec.Attachments, ExternalInterface); external != nil {
+		out.Spec.ExternalIPPool = external.IPPool
+		if len(external.Addresses) != 0 {
+			out.Spec.ExternalIP, out.Spec.ExternalNetmask = splitAddress(external.Addresses[0])
+		}
+		if route := findRoute(external.Routes, DefaultRouteDestination); route != nil {
+			out.Spec.GatewayIP = route.Gateway
+		}
+	}
+	convertStatusToV1(&in.Status, &out.Status)
+
+	delete(out.Annotations, AttachmentsAnnotation)
+	if !reflect.DeepEqual(attachmentsFromV1(&out.Spec), in.Spec.Attachments) {
+		raw, err := json.Marshal(in.Spec.Attachments)
+		if err != nil {
+			return err
+		}
+		if out.Annotations == nil {
+			out.Annotations = map[string]string{}
+		}
+		out.Annotations[AttachmentsAnnotation] = string(raw)
+	}
+	if len(out.Annotations) == 0 {
+		out.Annotations = nil
+	}
+	return nil
+}
+
+// ConvertFromV1 converts in to the v2 API. Attachments preserved in
+// AttachmentsAnnotation are restored, with the v1 addressing fields applied
+// on top of them.
+func ConvertFromV1(in *v1.VirtualRouter, out *VirtualRouter) error {
+	out.TypeMeta = in.TypeMeta
+	out.APIVersion = SchemeGroupVersion.String()
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+
+	spec := in.Spec.DeepCopy()
+	out.Spec = VirtualRouterSpec{
+		DeploymentName:     spec.DeploymentName,
+		Replicas:           spec.Replicas,
+		WorkloadKind:       spec.WorkloadKind,
+		Image:              spec.Image,
+		Affinity:           spec.Affinity,
+		Tolerations:        spec.Tolerations,
+		PriorityClassName:  spec.PriorityClassName,
+		Resources:          spec.Resources,
+		ImagePullPolicy:    spec.ImagePullPolicy,
+		ImagePullSecrets:   spec.ImagePullSecrets,
+		Privileged:         spec.Privileged,
+		PodTemplateOverlay: spec.PodTemplateOverlay,
+		DisruptionBudget:   (*DisruptionBudgetSpec)(spec.DisruptionBudget),
+		ReplicaAddressing:  replicaAddressingFromV1(spec.ReplicaAddressing),
+	}
+	out.Spec.AdditionalRoleRules = spec.AdditionalRoleRules
+	for _, selector := range in.Spec.NodeSelector {
+		out.Spec.NodeSelector = append(out.Spec.NodeSelector, NodeSelector{Key: selector.Key, Value: selector.Value})
+	}
+	convertStatusFromV1(&in.Status, &out.Status)
+
+	fromV1 := attachmentsFromV1(&in.Spec)
+	raw, preserved := out.Annotations[AttachmentsAnnotation]
+	if !preserved {
+		out.Spec.Attachments = fromV1
+		return nil
+	}
+	delete(out.Annotations, AttachmentsAnnotation)
+	if len(out.Annotations) == 0 {
+		out.Annotations = nil
+	}
+	if err := json.Unmarshal([]byte(raw), &out.Spec.Attachments); err != nil {
+		return fmt.Errorf("invalid %s annotation: %v", AttachmentsAnnotation, err)
+	}
+	for _, attachment := range fromV1 {
+		mergeAttachment(&out.Spec.Attachments, attachment)
+	}
+	return nil
+}
+
+// attachmentsFromV1 returns the two attachments described by the v1
+// addressing fields.
+func attachmentsFromV1(spec *v1.VirtualRouterSpec) []Attachment {
+	internal := Attachment{
+		Network:    InternalNetwork,
+		Interface:  InternalInterface,
+		VlanNumber: spec.VlanNumber,
+		VlanPool:   spec.VlanPool,
+		IPPool:     spec.InternalIPPool,
+	}
+	if spec.InternalIP != "" {
+		internal.Addresses = []string{joinAddress(spec.InternalIP, spec.InternalNetmask)}
+	}
+	external := Attachment{
+		Network:   ExternalNetwork,
+		Interface: ExternalInterface,
+		IPPool:    spec.ExternalIPPool,
+	}
+	if spec.ExternalIP != "" {
+		external.Addresses = []string{joinAddress(spec.ExternalIP, spec.ExternalNetmask)}
+	}
+	if spec.GatewayIP != "" {
+		external.Routes = []Route{{Destination: DefaultRouteDestination, Gateway: spec.GatewayIP}}
+	}
+	return []Attachment{internal, external}
+}
+
+// mergeAttachment applies the fields v1 knows about from attachment to the
+// attachment of the same interface in attachments, or appends it.
+func mergeAttachment(attachments *[]Attachment, attachment Attachment) {
+	current := findAttachment(*attachments, attachment.Interface)
+	if current == nil {
+		*attachments = append(*attachments, attachment)
+		return
+	}
+	current.VlanNumber = attachment.VlanNumber
+	current.VlanPool = attachment.VlanPool
+	current.IPPool = attachment.IPPool
+	switch {
+	case len(attachment.Addresses) == 0:
+		current.Addresses = nil
+	case len(current.Addresses) == 0:
+		current.Addresses = attachment.Addresses
+	default:
+		current.Addresses[0] = attachment.Addresses[0]
+	}
+	if attachment.Interface != ExternalInterface {
+		return
+	}
+	var routes []Route
+	for _, route := range current.Routes {
+		if route.Destination != DefaultRouteDestination {
+			routes = append(routes, route)
+		}
+	}
+	current.Routes = append(routes, attachment.Routes...)
+}
+
+func findAttachment(attachments []Attachment, interfaceName string) *Attachment {
+	for i := range attachments {
+		if attachments[i].Interface == interfaceName {
+			return &attachments[i]
+		}
+	}
+	return nil
+}
+
+func findRoute(routes []Route, destination string) *Route {
+	for i := range routes {
+		if routes[i].Destination == destination {
+			return &routes[i]
+		}
+	}
+	return nil
+}
+
+// joinAddress returns ip in CIDR form. A netmask that is not a valid prefix is
+// kept in dotted form so that the conversion stays lossless.
+func joinAddress(ip string, netmask string) string {
+	if netmask == "" {
+		return ip
+	}
+	mask := net.IPMask(net.ParseIP(netmask).To4())
+	if ones, bits := mask.Size(); bits != 0 {
+		return fmt.Sprintf("%s/%d", ip, ones)
+	}
+	return ip + "/" + netmask
+}
+
+// splitAddress is the reverse of joinAddress.
+func splitAddress(address string) (string, string) {
+	i := strings.Index(address, "/")
+	if i < 0 {
+		return address, ""
+	}
+	ip, prefix := address[:i], address[i+1:]
+	_, ipNet, err := net.ParseCIDR("0.0.0.0/" + prefix)
+	if err != nil || ipNet.IP.To4() == nil {
+		return ip, prefix
+	}
+	return ip, net.IP(ipNet.Mask).String()
+}
+
+func replicaAddressingToV1(in *ReplicaAddressing) *v1.ReplicaAddressing {
+	if in == nil {
+		return nil
+	}
+	return &v1.ReplicaAddressing{
+		Internal: (*v1.AddressPool)(in.Internal),
+		External: (*v1.AddressPool)(in.External),
+	}
+}
+
+func replicaAddressingFromV1(in *v1.ReplicaAddressing) *ReplicaAddressing {
+	if in == nil {
+		return nil
+	}
+	return &ReplicaAddressing{
+		Internal: (*AddressPool)(in.Internal),
+		External: (*AddressPool)(in.External),
+	}
+}
+
+func convertStatusToV1(in *VirtualRouterStatus, out *v1.VirtualRouterStatus) {
+	status := in.DeepCopy()
+	*out = v1.VirtualRouterStatus{
+		AvailableReplicas:  status.AvailableReplicas,
+		Namespace:          status.Namespace,
+		ObservedGeneration: status.ObservedGeneration,
+		Conditions:         status.Conditions,
+	}
+	for _, address := range status.ReplicaAddresses {
+		out.ReplicaAddresses = append(out.ReplicaAddresses, v1.ReplicaAddress(address))
+	}
+	for _, allocation := range status.IPAllocations {
+		out.IPAllocations = append(out.IPAllocations, v1.IPAllocation(allocation))
+	}
+	out.VlanAllocation = (*v1.VlanAllocation)(status.VlanAllocation)
+	for _, attachment := range status.PodAttachments {
+		out.PodAttachments = append(out.PodAttachments, v1.PodAttachment(attachment))
+	}
+}
+
+func convertStatusFromV1(in *v1.VirtualRouterStatus, out *VirtualRouterStatus) {
+	status := in.DeepCopy()
+	*out = VirtualRouterStatus{
+		AvailableReplicas:  status.AvailableReplicas,
+		Namespace:          status.Namespace,
+		ObservedGeneration: status.ObservedGeneration,
+		Conditions:         status.Conditions,
+	}
+	for _, address := range status.ReplicaAddresses {
+		out.ReplicaAddresses = append(out.ReplicaAddresses, ReplicaAddress(address))
+	}
+	for _, allocation := range status.IPAllocations {
+		out.IPAllocations = append(out.IPAllocations, IPAllocation(allocation))
+	}
+	out.VlanAllocation = (*VlanAllocation)(status.VlanAllocation)
+	for _, attachment := range status.PodAttachments {
+		out.PodAttachments = append(out.PodAttachments, PodAttachment(attachment))
+	}
+}
@@ -0,0 +1,177 @@
+package v2
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/diff"
+
+	v1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+func newV1VirtualRouter() *v1.VirtualRouter {
+	replicas := int32(2)
+	return &v1.VirtualRouter{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1.SchemeGroupVersion.String(), Kind: "VirtualRouter"},
+		ObjectMeta: metav1.ObjectMeta{Name: "vr1", Namespace: "default"},
+		Spec: v1.VirtualRouterSpec{
+			DeploymentName:  "vr1-deployment",
+			Replicas:        &replicas,
+			VlanNumber:      210,
+			InternalIP:      "10.10.10.11",
+			InternalNetmask: "255.255.255.0",
+			ExternalIP:      "192.168.8.153",
+			ExternalNetmask: "255.255.254.0",
+			GatewayIP:       "192.168.8.1",
+			Image:           "tmaxcloudck/virtualrouter:v0.1.0",
+			NodeSelector:    []v1.NodeSelector{{Key: "kubernetes.io/hostname", Value: "node1"}},
+			ReplicaAddressing: &v1.ReplicaAddressing{
+				Internal: &v1.AddressPool{Range: "10.10.10.20-10.10.10.29"},
+			},
+		},
+		Status: v1.VirtualRouterStatus{
+			AvailableReplicas: 2,
+			Namespace:         "default-vr1",
+			ReplicaAddresses:  []v1.ReplicaAddress{{Pod: "vr1-deployment-7d4b9-x2x8q", InternalIP: "10.10.10.20"}},
+			PodAttachments: []v1.PodAttachment{{
+				Pod: "vr1-deployment-7d4b9-x2x8q", Node: "node1", ContainerID: "0123456789abcdef",
+				InternalInterface: "int0123456", ExternalInterface: "ext0123456", VlanNumber: 210,
+				InternalIP: "10.10.10.20", ExternalIP: "192.168.8.153", GatewayIP: "192.168.8.1",
+			}},
+		},
+	}
+}
+
+func TestConvertFromV1(t *testing.T) {
+	out := &VirtualRouter{}
+	if err := ConvertFromV1(newV1VirtualRouter(), out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Attachment{
+		{Network: InternalNetwork, Interface: InternalInterface, Addresses: []string{"10.10.10.11/24"}, VlanNumber: 210},
+		{Network: ExternalNetwork, Interface: ExternalInterface, Addresses: []string{"192.168.8.153/23"},
+			Routes: []Route{{Destination: DefaultRouteDestination, Gateway: "192.168.8.1"}}},
+	}
+	if !reflect.DeepEqual(out.Spec.Attachments, expected) {
+		t.Errorf("unexpected attachments:\n%s", diff.ObjectGoPrintSideBySide(expected, out.Spec.Attachments))
+	}
+	if out.APIVersion != SchemeGroupVersion.String() {
+		t.Errorf("expected apiVersion %s, got %s", SchemeGroupVersion.String(), out.APIVersion)
+	}
+}
+
+func TestConvertV1RoundTrip(t *testing.T) {
+	in := newV1VirtualRouter()
+	v2 := &VirtualRouter{}
+	if err := ConvertFromV1(in, v2); err != nil {
+		t.Fatal(err)
+	}
+	out := &v1.VirtualRouter{}
+	if err := ConvertToV1(v2, out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip changed the object:\n%s", diff.ObjectGoPrintSideBySide(in, out))
+	}
+}
+
+func TestConvertV1IPPoolRoundTrip(t *testing.T) {
+	in := newV1VirtualRouter()
+	in.Spec.ExternalIP, in.Spec.ExternalNetmask, in.Spec.GatewayIP = "", "", ""
+	in.Spec.ExternalIPPool = "external"
+	in.Status.IPAllocations = []v1.IPAllocation{
+		{Interface: v1.IPAllocationExternal, Pool: "external", Address: "192.168.8.10", Netmask: "255.255.254.0", Gateway: "192.168.8.1"},
+	}
+
+	v2 := &VirtualRouter{}
+	if err := ConvertFromV1(in, v2); err != nil {
+		t.Fatal(err)
+	}
+	if external := findAttachment(v2.Spec.Attachments, ExternalInterface); external == nil || external.IPPool != "external" || len(external.Addresses) != 0 {
+		t.Errorf("expected an external attachment allocated from the pool, got %v", external)
+	}
+	out := &v1.VirtualRouter{}
+	if err := ConvertToV1(v2, out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip changed the object:\n%s", diff.ObjectGoPrintSideBySide(in, out))
+	}
+}
+
+func TestConvertV1VlanPoolRoundTrip(t *testing.T) {
+	in := newV1VirtualRouter()
+	in.Spec.VlanNumber = 0
+	in.Spec.VlanPool = "tenants"
+	in.Status.VlanAllocation = &v1.VlanAllocation{Pool: "tenants", VlanNumber: 120}
+
+	v2 := &VirtualRouter{}
+	if err := ConvertFromV1(in, v2); err != nil {
+		t.Fatal(err)
+	}
+	if internal := findAttachment(v2.Spec.Attachments, InternalInterface); internal == nil || internal.VlanPool != "tenants" {
+		t.Errorf("expected an internal attachment tagged from the pool, got %v", internal)
+	}
+	if v2.Status.VlanAllocation == nil || v2.Status.VlanAllocation.VlanNumber != 120 {
+		t.Errorf("expected the VLAN allocation to be converted, got %v", v2.Status.VlanAllocation)
+	}
+	out := &v1.VirtualRouter{}
+	if err := ConvertToV1(v2, out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip changed the object:\n%s", diff.ObjectGoPrintSideBySide(in, out))
+	}
+}
+
+func TestConvertV2RoundTrip(t *testing.T) {
+	replicas := int32(1)
+	in := &VirtualRouter{
+		TypeMeta:   metav1.TypeMeta{APIVersion: SchemeGroupVersion.String(), Kind: "VirtualRouter"},
+		ObjectMeta: metav1.ObjectMeta{Name: "vr1", Namespace: "default", Annotations: map[string]string{"a": "b"}},
+		Spec: VirtualRouterSpec{
+			DeploymentName: "vr1-deployment",
+			Replicas:       &replicas,
+			Attachments: []Attachment{
+				{Network: InternalNetwork, Interface: InternalInterface, Addresses: []string{"10.10.10.11/24", "10.10.11.1/24"}, VlanNumber: 210},
+				{Network: "storage", Interface: "ethsto", Addresses: []string{"172.16.0.1/16"}, VlanNumber: 300},
+				{Network: ExternalNetwork, Interface: ExternalInterface, Addresses: []string{"192.168.8.153/23"},
+					Routes: []Route{{Destination: "10.0.0.0/8", Gateway: "192.168.8.2"}, {Destination: DefaultRouteDestination, Gateway: "192.168.8.1"}}},
+			},
+			Image: "tmaxcloudck/virtualrouter:v0.1.0",
+		},
+	}
+
+	legacy := &v1.VirtualRouter{}
+	if err := ConvertToV1(in, legacy); err != nil {
+		t.Fatal(err)
+	}
+	if legacy.Spec.InternalIP != "10.10.10.11" || legacy.Spec.InternalNetmask != "255.255.255.0" || legacy.Spec.VlanNumber != 210 ||
+		legacy.Spec.ExternalIP != "192.168.8.153" || legacy.Spec.ExternalNetmask != "255.255.254.0" || legacy.Spec.GatewayIP != "192.168.8.1" {
+		t.Errorf("unexpected v1 spec: %#v", legacy.Spec)
+	}
+	if _, ok := legacy.Annotations[AttachmentsAnnotation]; !ok {
+		t.Errorf("expected the attachments to be preserved in %s", AttachmentsAnnotation)
+	}
+
+	out := &VirtualRouter{}
+	if err := ConvertFromV1(legacy, out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip changed the object:\n%s", diff.ObjectGoPrintSideBySide(in, out))
+	}
+
+	// v1 clients still edit the first two legs.
+	legacy.Spec.GatewayIP = "192.168.8.254"
+	out = &VirtualRouter{}
+	if err := ConvertFromV1(legacy, out); err != nil {
+		t.Fatal(err)
+	}
+	routes := []Route{{Destination: "10.0.0.0/8", Gateway: "192.168.8.2"}, {Destination: DefaultRouteDestination, Gateway: "192.168.8.254"}}
+	if !reflect.DeepEqual(out.Spec.Attachments[2].Routes, routes) || len(out.Spec.Attachments) != 3 {
+		t.Errorf("unexpected attachments: %#v", out.Spec.Attachments)
+	}
+}
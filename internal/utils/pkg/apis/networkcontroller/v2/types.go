@@ -0,0 +1,314 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:resource:shortName=vr
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.spec.replicas`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.availableReplicas`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VirtualRouter is a specification for a VirtualRouter resource
+type VirtualRouter struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VirtualRouterSpec `json:"spec"`
+	// +optional
+	Status VirtualRouterStatus `json:"status"`
+}
+
+// VirtualRouterSpec is the spec for a VirtualRouter resource
+type VirtualRouterSpec struct {
+	// DeploymentName is the name of the router Deployment. It cannot be
+	// changed once set.
+	// +kubebuilder:validation:MinLength=1
+	DeploymentName string `json:"deploymentName"`
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	Replicas *int32 `json:"replicas"`
+	// WorkloadKind is the kind of workload the router pods run in. The pods
+	// of a StatefulSet keep their name across restarts, and the daemon derives
+	// their interface names, MAC addresses and replica addresses from their
+	// ordinal. It cannot be changed once set.
+	// +optional
+	// +kubebuilder:default=Deployment
+	// +kubebuilder:validation:Enum=Deployment;StatefulSet
+	WorkloadKind string `json:"workloadKind,omitempty"`
+	// Attachments are the network interfaces of the router pods
+	// +kubebuilder:validation:MinItems=1
+	// +listType=map
+	// +listMapKey=interface
+	Attachments []Attachment `json:"attachments"`
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+	// +optional
+	NodeSelector []NodeSelector `json:"nodeSelector"`
+	// +optional
+	Affinity corev1.Affinity `json:"affinity"`
+	// Tolerations of the router pods
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// PriorityClassName of the router pods
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// Resources of the router container. Requests default to the limits.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// ImagePullPolicy of the router container. Defaults to Always.
+	// +optional
+	// +kubebuilder:validation:Enum=Always;Never;IfNotPresent
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// ImagePullSecrets are used to pull Image from a private registry
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// Privileged runs the router container privileged. Defaults to true.
+	// +optional
+	Privileged *bool `json:"privileged,omitempty"`
+	// PodTemplateOverlay is a strategic merge patch of a PodTemplateSpec
+	// applied to the router pod template, e.g. to add sidecars, volumes or
+	// host aliases. The labels, annotations, finalizers, service account and
+	// router container the manager relies on cannot be changed.
+	// +optional
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	PodTemplateOverlay *runtime.RawExtension `json:"podTemplateOverlay,omitempty"`
+	// DisruptionBudget configures the PodDisruptionBudget of the router pods.
+	// Defaults to maxUnavailable 1.
+	// +optional
+	DisruptionBudget *DisruptionBudgetSpec `json:"disruptionBudget,omitempty"`
+	// ReplicaAddressing gives every router replica its own address instead
+	// of configuring all of them with the same one. The addresses assigned
+	// to the router pods are reported in status.replicaAddresses.
+	// +optional
+	ReplicaAddressing *ReplicaAddressing `json:"replicaAddressing,omitempty"`
+	// AdditionalRoleRules are granted to the router pods on top of the
+	// rules configured for the manager. Every rule has to be allowed by the
+	// manager configuration.
+	// +optional
+	AdditionalRoleRules []rbacv1.PolicyRule `json:"additionalRoleRules,omitempty"`
+}
+
+// DisruptionBudgetSpec sets at most one of MinAvailable and MaxUnavailable.
+// An integer MinAvailable is lowered below the replicas so that nodes can
+// still be drained one router pod at a time.
+type DisruptionBudgetSpec struct {
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// Attachment plugs an interface of the router pods into a host network
+type Attachment struct {
+	// Network is the host network the interface is attached to, e.g.
+	// InternalNetwork or ExternalNetwork
+	// +kubebuilder:validation:MinLength=1
+	Network string `json:"network"`
+	// Interface is the name of the interface inside the router pods
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=15
+	Interface string `json:"interface"`
+	// Addresses are assigned to the interface in CIDR form, e.g. 10.0.0.1/24
+	// +optional
+	// +kubebuilder:validation:items:Format=cidr
+	Addresses []string `json:"addresses,omitempty"`
+	// IPPool names the IPPool the address of the interface is allocated
+	// from when Addresses is empty
+	// +optional
+	IPPool string `json:"ipPool,omitempty"`
+	// VlanNumber tags the interface. 0 leaves it untagged, unless VlanPool
+	// is set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4094
+	VlanNumber int32 `json:"vlanNumber,omitempty"`
+	// VlanPool names the VlanPool a VLAN is allocated from when VlanNumber
+	// is 0. Only the InternalInterface attachment is tagged from a pool.
+	// +optional
+	VlanPool string `json:"vlanPool,omitempty"`
+	// Routes are added to the router pods through the interface
+	// +optional
+	Routes []Route `json:"routes,omitempty"`
+}
+
+// Route sends the traffic for Destination to Gateway
+type Route struct {
+	// Destination in CIDR form. DefaultRouteDestination is the default route.
+	// +kubebuilder:validation:Format=cidr
+	Destination string `json:"destination"`
+	// +kubebuilder:validation:Format=ipv4
+	Gateway string `json:"gateway"`
+}
+
+// Networks and interfaces the v1 API is limited to
+const (
+	InternalNetwork   string = "internal"
+	ExternalNetwork   string = "external"
+	InternalInterface string = "ethint"
+	ExternalInterface string = "ethext"
+
+	DefaultRouteDestination string = "0.0.0.0/0"
+)
+
+// ReplicaAddressing lists the addresses handed out to the router pods. A
+// pool that is not set leaves the interface with the address of the spec.
+type ReplicaAddressing struct {
+	// Internal addresses replace the internal address of the spec
+	// +optional
+	Internal *AddressPool `json:"internal,omitempty"`
+	// External addresses replace the external address of the spec
+	// +optional
+	External *AddressPool `json:"external,omitempty"`
+}
+
+// AddressPool sets exactly one of Addresses and Range. The addresses use the
+// prefix of the first address of their attachment.
+type AddressPool struct {
+	// +optional
+	// +kubebuilder:validation:items:Format=ipv4
+	Addresses []string `json:"addresses,omitempty"`
+	// Range is an inclusive range of addresses, e.g. 10.0.0.10-10.0.0.19
+	// +optional
+	Range string `json:"range,omitempty"`
+}
+
+// ReplicaAddress is the address assigned to a router pod
+type ReplicaAddress struct {
+	Pod string `json:"pod"`
+	// +optional
+	InternalIP string `json:"internalIP,omitempty"`
+	// +optional
+	ExternalIP string `json:"externalIP,omitempty"`
+}
+
+// VirtualRouterStatus is the status for a VirtualRouter resource
+type VirtualRouterStatus struct {
+	// +optional
+	AvailableReplicas int32 `json:"availableReplicas"`
+	// Namespace is the namespace generated for the router resources
+	Namespace string `json:"namespace,omitempty"`
+	// ObservedGeneration is the most recent generation observed by the manager
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions describe the current state of the resources generated for
+	// the VirtualRouter
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ReplicaAddresses are the addresses assigned to the router pods when
+	// spec.replicaAddressing is set
+	// +optional
+	// +listType=map
+	// +listMapKey=pod
+	ReplicaAddresses []ReplicaAddress `json:"replicaAddresses,omitempty"`
+	// IPAllocations are the addresses allocated to the VirtualRouter from
+	// the IPPools of the attachments
+	// +optional
+	// +listType=map
+	// +listMapKey=interface
+	IPAllocations []IPAllocation `json:"ipAllocations,omitempty"`
+	// VlanAllocation is the VLAN claimed by the VirtualRouter from a VlanPool
+	// +optional
+	VlanAllocation *VlanAllocation `json:"vlanAllocation,omitempty"`
+	// PodAttachments are reported by the daemons for the router pods on
+	// their node
+	// +optional
+	// +listType=map
+	// +listMapKey=pod
+	PodAttachments []PodAttachment `json:"podAttachments,omitempty"`
+}
+
+// PodAttachment is the state of the interfaces the daemon set up for a router
+// pod
+type PodAttachment struct {
+	Pod string `json:"pod"`
+	// Node the router pod runs on
+	Node string `json:"node"`
+	// +optional
+	ContainerID string `json:"containerID,omitempty"`
+	// InternalInterface and ExternalInterface are the host side veths of
+	// the router pod
+	// +optional
+	InternalInterface string `json:"internalInterface,omitempty"`
+	// +optional
+	ExternalInterface string `json:"externalInterface,omitempty"`
+	// VlanNumber, InternalIP, ExternalIP and GatewayIP are the settings
+	// applied to the router pod
+	// +optional
+	VlanNumber int32 `json:"vlanNumber,omitempty"`
+	// +optional
+	InternalIP string `json:"internalIP,omitempty"`
+	// +optional
+	ExternalIP string `json:"externalIP,omitempty"`
+	// +optional
+	GatewayIP string `json:"gatewayIP,omitempty"`
+	// LastError is the error of the last attach, if it failed
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+	// LastUpdateTime is the last time the attachment changed
+	// +optional
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// VlanAllocation is a VLAN claimed from a VlanPool
+type VlanAllocation struct {
+	// Pool is the name of the VlanPool the VLAN is claimed from
+	Pool       string `json:"pool"`
+	VlanNumber int32  `json:"vlanNumber"`
+}
+
+// IPAllocation is an address allocated to an attachment of a VirtualRouter
+type IPAllocation struct {
+	// Interface is the network of the attachment, InternalNetwork or
+	// ExternalNetwork
+	Interface string `json:"interface"`
+	// Pool is the name of the IPPool the address is allocated from
+	Pool    string `json:"pool"`
+	Address string `json:"address"`
+	Netmask string `json:"netmask"`
+	// Gateway of the pool
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// VirtualRouterList is a list of VirtualRouter resources
+type VirtualRouterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []VirtualRouter `json:"items"`
+}
+
+type NodeSelector struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
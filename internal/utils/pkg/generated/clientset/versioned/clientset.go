@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	tmaxv1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/clientset/versioned/typed/networkcontroller/v1"
+	tmaxv2 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/clientset/versioned/typed/networkcontroller/v2"
 	discovery "k8s.io/client-go/discovery"
 	rest "k8s.io/client-go/rest"
 	flowcontrol "k8s.io/client-go/util/flowcontrol"
@@ -30,6 +31,7 @@ import (
 type Interface interface {
 	Discovery() discovery.DiscoveryInterface
 	TmaxV1() tmaxv1.TmaxV1Interface
+	TmaxV2() tmaxv2.TmaxV2Interface
 }
 
 // Clientset contains the clients for groups. Each group has exactly one
@@ -37,6 +39,7 @@ type Interface interface {
 type Clientset struct {
 	*discovery.DiscoveryClient
 	tmaxV1 *tmaxv1.TmaxV1Client
+	tmaxV2 *tmaxv2.TmaxV2Client
 }
 
 // TmaxV1 retrieves the TmaxV1Client
@@ -44,6 +47,11 @@ func (c *Clientset) TmaxV1() tmaxv1.TmaxV1Interface {
 	return c.tmaxV1
 }
 
+// TmaxV2 retrieves the TmaxV2Client
+func (c *Clientset) TmaxV2() tmaxv2.TmaxV2Interface {
+	return c.tmaxV2
+}
+
 // Discovery retrieves the DiscoveryClient
 func (c *Clientset) Discovery() discovery.DiscoveryInterface {
 	if c == nil {
@@ -69,6 +77,10 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 	if err != nil {
 		return nil, err
 	}
+	cs.tmaxV2, err = tmaxv2.NewForConfig(&configShallowCopy)
+	if err != nil {
+		return nil, err
+	}
 
 	cs.DiscoveryClient, err = discovery.NewDiscoveryClientForConfig(&configShallowCopy)
 	if err != nil {
@@ -82,6 +94,7 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 func NewForConfigOrDie(c *rest.Config) *Clientset {
 	var cs Clientset
 	cs.tmaxV1 = tmaxv1.NewForConfigOrDie(c)
+	cs.tmaxV2 = tmaxv2.NewForConfigOrDie(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClientForConfigOrDie(c)
 	return &cs
@@ -91,6 +104,7 @@ func NewForConfigOrDie(c *rest.Config) *Clientset {
 func New(c rest.Interface) *Clientset {
 	var cs Clientset
 	cs.tmaxV1 = tmaxv1.New(c)
+	cs.tmaxV2 = tmaxv2.New(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClient(c)
 	return &cs
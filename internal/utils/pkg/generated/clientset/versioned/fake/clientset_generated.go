@@ -22,6 +22,8 @@ import (
 	clientset "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/clientset/versioned"
 	tmaxv1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/clientset/versioned/typed/networkcontroller/v1"
 	faketmaxv1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/clientset/versioned/typed/networkcontroller/v1/fake"
+	tmaxv2 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/clientset/versioned/typed/networkcontroller/v2"
+	faketmaxv2 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/clientset/versioned/typed/networkcontroller/v2/fake"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/discovery"
@@ -80,3 +82,8 @@ var _ clientset.Interface = &Clientset{}
 func (c *Clientset) TmaxV1() tmaxv1.TmaxV1Interface {
 	return &faketmaxv1.FakeTmaxV1{Fake: &c.Fake}
 }
+
+// TmaxV2 retrieves the TmaxV2Client
+func (c *Clientset) TmaxV2() tmaxv2.TmaxV2Interface {
+	return &faketmaxv2.FakeTmaxV2{Fake: &c.Fake}
+}
@@ -28,10 +28,18 @@ type FakeTmaxV1 struct {
 	*testing.Fake
 }
 
+func (c *FakeTmaxV1) IPPools() v1.IPPoolInterface {
+	return &FakeIPPools{c}
+}
+
 func (c *FakeTmaxV1) VirtualRouters(namespace string) v1.VirtualRouterInterface {
 	return &FakeVirtualRouters{c, namespace}
 }
 
+func (c *FakeTmaxV1) VlanPools() v1.VlanPoolInterface {
+	return &FakeVlanPools{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeTmaxV1) RESTClient() rest.Interface {
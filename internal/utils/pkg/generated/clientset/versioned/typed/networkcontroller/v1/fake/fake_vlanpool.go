@@ -0,0 +1,133 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	networkcontrollerv1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeVlanPools implements VlanPoolInterface
+type FakeVlanPools struct {
+	Fake *FakeTmaxV1
+}
+
+var vlanpoolsResource = schema.GroupVersionResource{Group: "tmax.hypercloud.com", Version: "v1", Resource: "vlanpools"}
+
+var vlanpoolsKind = schema.GroupVersionKind{Group: "tmax.hypercloud.com", Version: "v1", Kind: "VlanPool"}
+
+// Get takes name of the vlanPool, and returns the corresponding vlanPool object, and an error if there is any.
+func (c *FakeVlanPools) Get(ctx context.Context, name string, options v1.GetOptions) (result *networkcontrollerv1.VlanPool, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(vlanpoolsResource, name), &networkcontrollerv1.VlanPool{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*networkcontrollerv1.VlanPool), err
+}
+
+// List takes label and field selectors, and returns the list of VlanPools that match those selectors.
+func (c *FakeVlanPools) List(ctx context.Context, opts v1.ListOptions) (result *networkcontrollerv1.VlanPoolList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(vlanpoolsResource, vlanpoolsKind, opts), &networkcontrollerv1.VlanPoolList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &networkcontrollerv1.VlanPoolList{ListMeta: obj.(*networkcontrollerv1.VlanPoolList).ListMeta}
+	for _, item := range obj.(*networkcontrollerv1.VlanPoolList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested vlanPools.
+func (c *FakeVlanPools) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(vlanpoolsResource, opts))
+}
+
+// Create takes the representation of a vlanPool and creates it.  Returns the server's representation of the vlanPool, and an error, if there is any.
+func (c *FakeVlanPools) Create(ctx context.Context, vlanPool *networkcontrollerv1.VlanPool, opts v1.CreateOptions) (result *networkcontrollerv1.VlanPool, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(vlanpoolsResource, vlanPool), &networkcontrollerv1.VlanPool{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*networkcontrollerv1.VlanPool), err
+}
+
+// Update takes the representation of a vlanPool and updates it. Returns the server's representation of the vlanPool, and an error, if there is any.
+func (c *FakeVlanPools) Update(ctx context.Context, vlanPool *networkcontrollerv1.VlanPool, opts v1.UpdateOptions) (result *networkcontrollerv1.VlanPool, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(vlanpoolsResource, vlanPool), &networkcontrollerv1.VlanPool{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*networkcontrollerv1.VlanPool), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeVlanPools) UpdateStatus(ctx context.Context, vlanPool *networkcontrollerv1.VlanPool, opts v1.UpdateOptions) (*networkcontrollerv1.VlanPool, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateSubresourceAction(vlanpoolsResource, "status", vlanPool), &networkcontrollerv1.VlanPool{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*networkcontrollerv1.VlanPool), err
+}
+
+// Delete takes name of the vlanPool and deletes it. Returns an error if one occurs.
+func (c *FakeVlanPools) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(vlanpoolsResource, name), &networkcontrollerv1.VlanPool{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeVlanPools) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(vlanpoolsResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &networkcontrollerv1.VlanPoolList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched vlanPool.
+func (c *FakeVlanPools) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *networkcontrollerv1.VlanPool, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(vlanpoolsResource, name, pt, data, subresources...), &networkcontrollerv1.VlanPool{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*networkcontrollerv1.VlanPool), err
+}
@@ -18,4 +18,8 @@ limitations under the License.
 
 package v1
 
+type IPPoolExpansion interface{}
+
 type VirtualRouterExpansion interface{}
+
+type VlanPoolExpansion interface{}
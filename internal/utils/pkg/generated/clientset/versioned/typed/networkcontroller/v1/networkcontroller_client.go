@@ -26,7 +26,9 @@ import (
 
 type TmaxV1Interface interface {
 	RESTClient() rest.Interface
+	IPPoolsGetter
 	VirtualRoutersGetter
+	VlanPoolsGetter
 }
 
 // TmaxV1Client is used to interact with features provided by the tmax.hypercloud.com group.
@@ -34,10 +36,18 @@ type TmaxV1Client struct {
 	restClient rest.Interface
 }
 
+func (c *TmaxV1Client) IPPools() IPPoolInterface {
+	return newIPPools(c)
+}
+
 func (c *TmaxV1Client) VirtualRouters(namespace string) VirtualRouterInterface {
 	return newVirtualRouters(c, namespace)
 }
 
+func (c *TmaxV1Client) VlanPools() VlanPoolInterface {
+	return newVlanPools(c)
+}
+
 // NewForConfig creates a new TmaxV1Client for the given config.
 func NewForConfig(c *rest.Config) (*TmaxV1Client, error) {
 	config := *c
@@ -0,0 +1,184 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+	scheme "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// VlanPoolsGetter has a method to return a VlanPoolInterface.
+// A group's client should implement this interface.
+type VlanPoolsGetter interface {
+	VlanPools() VlanPoolInterface
+}
+
+// VlanPoolInterface has methods to work with VlanPool resources.
+type VlanPoolInterface interface {
+	Create(ctx context.Context, vlanPool *v1.VlanPool, opts metav1.CreateOptions) (*v1.VlanPool, error)
+	Update(ctx context.Context, vlanPool *v1.VlanPool, opts metav1.UpdateOptions) (*v1.VlanPool, error)
+	UpdateStatus(ctx context.Context, vlanPool *v1.VlanPool, opts metav1.UpdateOptions) (*v1.VlanPool, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.VlanPool, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.VlanPoolList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.VlanPool, err error)
+	VlanPoolExpansion
+}
+
+// vlanPools implements VlanPoolInterface
+type vlanPools struct {
+	client rest.Interface
+}
+
+// newVlanPools returns a VlanPools
+func newVlanPools(c *TmaxV1Client) *vlanPools {
+	return &vlanPools{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the vlanPool, and returns the corresponding vlanPool object, and an error if there is any.
+func (c *vlanPools) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.VlanPool, err error) {
+	result = &v1.VlanPool{}
+	err = c.client.Get().
+		Resource("vlanpools").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of VlanPools that match those selectors.
+func (c *vlanPools) List(ctx context.Context, opts metav1.ListOptions) (result *v1.VlanPoolList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.VlanPoolList{}
+	err = c.client.Get().
+		Resource("vlanpools").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested vlanPools.
+func (c *vlanPools) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("vlanpools").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a vlanPool and creates it.  Returns the server's representation of the vlanPool, and an error, if there is any.
+func (c *vlanPools) Create(ctx context.Context, vlanPool *v1.VlanPool, opts metav1.CreateOptions) (result *v1.VlanPool, err error) {
+	result = &v1.VlanPool{}
+	err = c.client.Post().
+		Resource("vlanpools").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(vlanPool).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a vlanPool and updates it. Returns the server's representation of the vlanPool, and an error, if there is any.
+func (c *vlanPools) Update(ctx context.Context, vlanPool *v1.VlanPool, opts metav1.UpdateOptions) (result *v1.VlanPool, err error) {
+	result = &v1.VlanPool{}
+	err = c.client.Put().
+		Resource("vlanpools").
+		Name(vlanPool.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(vlanPool).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *vlanPools) UpdateStatus(ctx context.Context, vlanPool *v1.VlanPool, opts metav1.UpdateOptions) (result *v1.VlanPool, err error) {
+	result = &v1.VlanPool{}
+	err = c.client.Put().
+		Resource("vlanpools").
+		Name(vlanPool.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(vlanPool).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the vlanPool and deletes it. Returns an error if one occurs.
+func (c *vlanPools) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("vlanpools").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *vlanPools) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("vlanpools").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched vlanPool.
+func (c *vlanPools) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.VlanPool, err error) {
+	result = &v1.VlanPool{}
+	err = c.client.Patch(pt).
+		Resource("vlanpools").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	v1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+	v2 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v2"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
 	cache "k8s.io/client-go/tools/cache"
 )
@@ -53,8 +54,16 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=tmax.hypercloud.com, Version=v1
+	case v1.SchemeGroupVersion.WithResource("ippools"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tmax().V1().IPPools().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("virtualrouters"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Tmax().V1().VirtualRouters().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("vlanpools"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tmax().V1().VlanPools().Informer()}, nil
+
+		// Group=tmax.hypercloud.com, Version=v2
+	case v2.SchemeGroupVersion.WithResource("virtualrouters"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tmax().V2().VirtualRouters().Informer()}, nil
 
 	}
 
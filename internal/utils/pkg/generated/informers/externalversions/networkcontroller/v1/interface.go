@@ -24,8 +24,12 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// IPPools returns a IPPoolInformer.
+	IPPools() IPPoolInformer
 	// VirtualRouters returns a VirtualRouterInformer.
 	VirtualRouters() VirtualRouterInformer
+	// VlanPools returns a VlanPoolInformer.
+	VlanPools() VlanPoolInformer
 }
 
 type version struct {
@@ -39,7 +43,17 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// IPPools returns a IPPoolInformer.
+func (v *version) IPPools() IPPoolInformer {
+	return &iPPoolInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // VirtualRouters returns a VirtualRouterInformer.
 func (v *version) VirtualRouters() VirtualRouterInformer {
 	return &virtualRouterInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// VlanPools returns a VlanPoolInformer.
+func (v *version) VlanPools() VlanPoolInformer {
+	return &vlanPoolInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -18,6 +18,10 @@ limitations under the License.
 
 package v1
 
+// IPPoolListerExpansion allows custom methods to be added to
+// IPPoolLister.
+type IPPoolListerExpansion interface{}
+
 // VirtualRouterListerExpansion allows custom methods to be added to
 // VirtualRouterLister.
 type VirtualRouterListerExpansion interface{}
@@ -25,3 +29,7 @@ type VirtualRouterListerExpansion interface{}
 // VirtualRouterNamespaceListerExpansion allows custom methods to be added to
 // VirtualRouterNamespaceLister.
 type VirtualRouterNamespaceListerExpansion interface{}
+
+// VlanPoolListerExpansion allows custom methods to be added to
+// VlanPoolLister.
+type VlanPoolListerExpansion interface{}
@@ -0,0 +1,60 @@
+package healthz
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	HEALTHZ_PATH = "/healthz"
+	READYZ_PATH  = "/readyz"
+)
+
+// Checker is a named check served by Handler. Check returns nil when healthy.
+type Checker struct {
+	Name  string
+	Check func() error
+}
+
+// Ping always succeeds. It reports that the process is able to serve HTTP.
+var Ping = Checker{Name: "ping", Check: func() error { return nil }}
+
+// Handler runs every checker on each request and responds 200 "ok" when all of
+// them pass, or 500 listing the failed checks.
+func Handler(checkers ...Checker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var out bytes.Buffer
+		failed := false
+		for _, checker := range checkers {
+			if err := checker.Check(); err != nil {
+				failed = true
+				fmt.Fprintf(&out, "[-]%s failed: %v\n", checker.Name, err)
+				klog.V(2).Infof("%s check %s failed: %v", r.URL.Path, checker.Name, err)
+				continue
+			}
+			fmt.Fprintf(&out, "[+]%s ok\n", checker.Name)
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if failed {
+			w.WriteHeader(http.StatusInternalServerError)
+			out.WriteTo(w)
+			return
+		}
+		if _, verbose := r.URL.Query()["verbose"]; verbose {
+			out.WriteTo(w)
+		}
+		fmt.Fprint(w, "ok")
+	})
+}
+
+// InstallHandlers serves the liveness checks on HEALTHZ_PATH and the liveness
+// and readiness checks on READYZ_PATH.
+func InstallHandlers(mux *http.ServeMux, liveness []Checker, readiness []Checker) {
+	mux.Handle(HEALTHZ_PATH, Handler(append([]Checker{Ping}, liveness...)...))
+	mux.Handle(READYZ_PATH, Handler(append(append([]Checker{Ping}, liveness...), readiness...)...))
+}
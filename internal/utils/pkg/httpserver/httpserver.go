@@ -0,0 +1,27 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+
+	"k8s.io/klog/v2"
+)
+
+// Serve serves handler on addr until stopCh is closed. An empty addr disables
+// the server.
+func Serve(addr string, handler http.Handler, stopCh <-chan struct{}) {
+	if addr == "" || addr == "0" {
+		return
+	}
+	server := &http.Server{Addr: addr, Handler: handler}
+	go func() {
+		<-stopCh
+		server.Shutdown(context.Background())
+	}()
+	go func() {
+		klog.Infof("Serving HTTP on %s", addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			klog.Errorf("Error serving HTTP on %s: %s", addr, err.Error())
+		}
+	}()
+}
@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const METRICS_PATH = "/metrics"
+
+// Registry is the registry served on METRICS_PATH. Packages register their
+// collectors here so that both binaries expose only their own metrics.
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(prometheus.NewGoCollector())
+	Registry.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+}
+
+// Handler returns the HTTP handler exposing the metrics in Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,199 @@
+package virtualroutermanager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	samplev1alpha1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+// ADDRESS_INDEX indexes the VirtualRouters by the addresses they claim, see
+// addressKeys.
+const ADDRESS_INDEX = "address"
+
+// addressIndexFunc is the cache.IndexFunc of ADDRESS_INDEX.
+func addressIndexFunc(obj interface{}) ([]string, error) {
+	virtualRouter, ok := obj.(*samplev1alpha1.VirtualRouter)
+	if !ok {
+		return nil, nil
+	}
+	var keys []string
+	for key := range addressKeys(virtualRouter) {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// addressKeys returns the addresses a VirtualRouter configures its router
+// pods with, described by their index key. External addresses share the
+// external bridge, internal ones only clash on the same VLAN.
+func addressKeys(virtualRouter *samplev1alpha1.VirtualRouter) map[string]string {
+	spec := &virtualRouter.Spec
+	internal := []string{spec.InternalIP}
+	external := []string{spec.ExternalIP}
+	for _, allocation := range virtualRouter.Status.IPAllocations {
+		switch allocation.Interface {
+		case samplev1alpha1.IPAllocationInternal:
+			internal = append(internal, allocation.Address)
+		case samplev1alpha1.IPAllocationExternal:
+			external = append(external, allocation.Address)
+		}
+	}
+	// The addresses of the replicas replace the shared ones
+	if spec.ReplicaAddressing != nil {
+		if spec.ReplicaAddressing.Internal != nil {
+			internal, _ = spec.ReplicaAddressing.Internal.List()
+		}
+		if spec.ReplicaAddressing.External != nil {
+			external, _ = spec.ReplicaAddressing.External.List()
+		}
+	}
+
+	keys := map[string]string{}
+	vlan := vlanOf(virtualRouter)
+	for _, address := range internal {
+		if address == "" {
+			continue
+		}
+		description := fmt.Sprintf("internal address %s", address)
+		if vlan != 0 {
+			description = fmt.Sprintf("internal address %s on VLAN %d", address, vlan)
+		}
+		keys[fmt.Sprintf("internal/%d/%s", vlan, address)] = description
+	}
+	for _, address := range external {
+		if address != "" {
+			keys["external/"+address] = fmt.Sprintf("external address %s", address)
+		}
+	}
+	return keys
+}
+
+// addressConflict returns an address of a VirtualRouter, and the oldest
+// VirtualRouter created before it which claims the address as well, if any.
+// The addresses of other VirtualRouters are looked up in ADDRESS_INDEX.
+func (c *Controller) addressConflict(virtualRouter *samplev1alpha1.VirtualRouter) (string, *samplev1alpha1.VirtualRouter) {
+	keys := addressKeys(virtualRouter)
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	for _, key := range sortedKeys {
+		objs, err := c.virtualRoutersIndexer.ByIndex(ADDRESS_INDEX, key)
+		if err != nil {
+			utilruntime.HandleError(err)
+			continue
+		}
+		owner := virtualRouter
+		for _, obj := range objs {
+			other, ok := obj.(*samplev1alpha1.VirtualRouter)
+			if !ok || (other.Namespace == virtualRouter.Namespace && other.Name == virtualRouter.Name) {
+				continue
+			}
+			if createdBefore(other, owner) {
+				owner = other
+			}
+		}
+		if owner != virtualRouter {
+			return keys[key], owner
+		}
+	}
+	return "", nil
+}
+
+// enqueueAddressConflicts enqueues the VirtualRouters which claim an address
+// old claimed and new no longer does, so that the one which lost the conflict
+// over it is deployed right away instead of after its backoff. The claimants
+// are looked up in ADDRESS_INDEX, which covers every namespace. new is nil
+// when old was deleted.
+func (c *Controller) enqueueAddressConflicts(old, new *samplev1alpha1.VirtualRouter) {
+	var kept map[string]string
+	if new != nil {
+		kept = addressKeys(new)
+	}
+	for key := range addressKeys(old) {
+		if _, exist := kept[key]; exist {
+			continue
+		}
+		objs, err := c.virtualRoutersIndexer.ByIndex(ADDRESS_INDEX, key)
+		if err != nil {
+			utilruntime.HandleError(err)
+			continue
+		}
+		for _, obj := range objs {
+			other, ok := obj.(*samplev1alpha1.VirtualRouter)
+			if !ok || (other.Namespace == old.Namespace && other.Name == old.Name) {
+				continue
+			}
+			klog.V(4).Infof("VirtualRouter %s/%s released %s, requeueing VirtualRouter %s/%s", old.Namespace, old.Name, key, other.Namespace, other.Name)
+			c.enqueueVirtualRouter(other)
+		}
+	}
+}
+
+// handleVirtualRouterDeletion releases the addresses of a deleted
+// VirtualRouter to the VirtualRouters which conflicted with it.
+func (c *Controller) handleVirtualRouterDeletion(obj interface{}) {
+	virtualRouter, ok := obj.(*samplev1alpha1.VirtualRouter)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("error decoding object, invalid type"))
+			return
+		}
+		virtualRouter, ok = tombstone.Obj.(*samplev1alpha1.VirtualRouter)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("error decoding object tombstone, invalid type"))
+			return
+		}
+		klog.V(4).Infof("Recovered deleted object '%s' from tombstone", virtualRouter.Name)
+	}
+	c.enqueueAddressConflicts(virtualRouter, nil)
+}
+
+// clearConflict removes the Conflict condition of a VirtualRouter whose
+// addresses no longer clash with an older VirtualRouter. It returns the
+// updated VirtualRouter.
+func (c *Controller) clearConflict(virtualRouter *samplev1alpha1.VirtualRouter) (*samplev1alpha1.VirtualRouter, error) {
+	if meta.FindStatusCondition(virtualRouter.Status.Conditions, samplev1alpha1.ConditionConflict) == nil {
+		return virtualRouter, nil
+	}
+	latest := virtualRouter
+	firstTry := true
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if !firstTry {
+			var err error
+			latest, err = c.sampleclientset.TmaxV1().VirtualRouters(virtualRouter.Namespace).Get(context.TODO(), virtualRouter.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+		}
+		firstTry = false
+
+		if meta.FindStatusCondition(latest.Status.Conditions, samplev1alpha1.ConditionConflict) == nil {
+			return nil
+		}
+		virtualRouterCopy := latest.DeepCopy()
+		meta.RemoveStatusCondition(&virtualRouterCopy.Status.Conditions, samplev1alpha1.ConditionConflict)
+		updated, err := c.sampleclientset.TmaxV1().VirtualRouters(virtualRouter.Namespace).UpdateStatus(context.TODO(), virtualRouterCopy, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		latest = updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return latest, nil
+}
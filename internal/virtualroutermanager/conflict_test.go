@@ -0,0 +1,193 @@
+package virtualroutermanager
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	networkcontroller "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+func TestAddressKeys(t *testing.T) {
+	virtualRouter := newVirtualRouter("test", int32Ptr(2))
+	virtualRouter.Spec.VlanNumber = 210
+	virtualRouter.Spec.InternalIP = "10.10.10.11"
+	virtualRouter.Spec.ExternalIPPool = "external"
+	virtualRouter.Spec.ReplicaAddressing = &networkcontroller.ReplicaAddressing{
+		Internal: &networkcontroller.AddressPool{Range: "10.10.10.20-10.10.10.21"},
+	}
+	virtualRouter.Status.IPAllocations = []networkcontroller.IPAllocation{
+		{Interface: networkcontroller.IPAllocationExternal, Pool: "external", Address: "192.168.8.10"},
+	}
+
+	expected := map[string]string{
+		"internal/210/10.10.10.20": "internal address 10.10.10.20 on VLAN 210",
+		"internal/210/10.10.10.21": "internal address 10.10.10.21 on VLAN 210",
+		"external/192.168.8.10":    "external address 192.168.8.10",
+	}
+	if keys := addressKeys(virtualRouter); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected %v, got %v", expected, keys)
+	}
+}
+
+func TestExternalAddressConflict(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Spec.ExternalIP = "192.168.8.153"
+	virtualRouter.CreationTimestamp = metav1.Now()
+	older := newVirtualRouter("older", int32Ptr(1))
+	older.Namespace = "other"
+	older.Spec.ExternalIP = "192.168.8.153"
+	older.CreationTimestamp = metav1.NewTime(virtualRouter.CreationTimestamp.Add(-time.Hour))
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter, older)
+	f.objects = append(f.objects, virtualRouter)
+
+	expVirtualRouter := virtualRouter.DeepCopy()
+	expVirtualRouter.Status.Conditions = []metav1.Condition{
+		newCondition(networkcontroller.ConditionConflict, metav1.ConditionTrue, ReasonAddressConflict,
+			"external address 192.168.8.153 is already used by VirtualRouter other/older"),
+		newCondition(networkcontroller.ConditionNetworkAttached, metav1.ConditionUnknown, ReasonWaitingForDaemon, MessageWaitingForDaemon),
+	}
+	expVirtualRouter.Status.Conditions = append(expVirtualRouter.Status.Conditions, degradedCondition(expVirtualRouter.Status.Conditions))
+
+	f.expectUpdateVirtualRouterStatusAction(expVirtualRouter)
+	f.runExpectError(getKey(virtualRouter, t))
+}
+
+func TestInternalAddressOnAnotherVlan(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Spec.InternalIP = "10.10.10.11"
+	virtualRouter.Spec.VlanNumber = 210
+	other := newVirtualRouter("other", int32Ptr(1))
+	other.Spec.InternalIP = "10.10.10.11"
+	other.Spec.VlanNumber = 211
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter, other)
+	c, _, _ := f.newController()
+	if address, owner := c.addressConflict(virtualRouter); owner != nil {
+		t.Errorf("expected no conflict, got %s of %s", address, owner.Name)
+	}
+	if address, owner := c.addressConflict(other); owner != nil {
+		t.Errorf("expected no conflict, got %s of %s", address, owner.Name)
+	}
+}
+
+func TestClearsResolvedConflict(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Spec.ExternalIP = "192.168.8.153"
+	virtualRouter.Status.Conditions = []metav1.Condition{
+		newCondition(networkcontroller.ConditionConflict, metav1.ConditionTrue, ReasonAddressConflict,
+			"external address 192.168.8.153 is already used by VirtualRouter other/older"),
+	}
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+
+	newNS := virtualRouter.Status.Namespace
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
+	expDeployment := newDeployment(newNS, virtualRouter)
+
+	cleared := virtualRouter.DeepCopy()
+	cleared.Status.Conditions = []metav1.Condition{}
+
+	f.expectUpdateVirtualRouterStatusAction(cleared)
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.expectCreateDeploymentAction(expDeployment)
+	f.expectUpdateVirtualRouterStatusAction(withStatus(cleared, expDeployment))
+
+	f.run(getKey(virtualRouter, t))
+}
+
+func TestRequeuesConflictLoserOfAnotherNamespace(t *testing.T) {
+	f := newFixture(t)
+	owner := newVirtualRouter("older", int32Ptr(1))
+	owner.Namespace = "other"
+	owner.Spec.ExternalIP = "192.168.8.153"
+	owner.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+	loser := newVirtualRouter("test", int32Ptr(1))
+	loser.Spec.ExternalIP = "192.168.8.153"
+	loser.CreationTimestamp = metav1.Now()
+
+	// The owner lives in a namespace this manager does not reconcile
+	f.watchNamespaces = []string{loser.Namespace}
+	f.virtualRouterLister = append(f.virtualRouterLister, owner, loser)
+	c, i, _ := f.newController()
+	indexer := i.Tmax().V1().VirtualRouters().Informer().GetIndexer()
+
+	// An update keeping the address does not requeue the loser
+	c.enqueueAddressConflicts(owner, owner.DeepCopy())
+	if c.workqueue.Len() != 0 {
+		t.Fatalf("expected no VirtualRouter to be requeued, got %d", c.workqueue.Len())
+	}
+
+	// The owner moving to another address releases it
+	moved := owner.DeepCopy()
+	moved.Spec.ExternalIP = "192.168.8.154"
+	indexer.Update(moved)
+	c.enqueueAddressConflicts(owner, moved)
+	if key, _ := c.workqueue.Get(); key != getKey(loser, t) {
+		t.Errorf("expected %s to be requeued, got %v", getKey(loser, t), key)
+	}
+	c.workqueue.Done(getKey(loser, t))
+	c.workqueue.Forget(getKey(loser, t))
+
+	// So does deleting it, even when only its tombstone is left
+	indexer.Update(owner)
+	indexer.Delete(owner)
+	c.handleVirtualRouterDeletion(cache.DeletedFinalStateUnknown{Key: getKey(owner, t), Obj: owner})
+	if key, _ := c.workqueue.Get(); key != getKey(loser, t) {
+		t.Errorf("expected %s to be requeued, got %v", getKey(loser, t), key)
+	}
+
+	// Synced again, the loser is deployed
+	loser.Status.Conditions = []metav1.Condition{
+		newCondition(networkcontroller.ConditionConflict, metav1.ConditionTrue, ReasonAddressConflict,
+			"external address 192.168.8.153 is already used by VirtualRouter other/older"),
+	}
+	f = newFixture(t)
+	f.virtualRouterLister = append(f.virtualRouterLister, loser)
+	f.objects = append(f.objects, loser)
+	newNS := loser.Status.Namespace
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(loser)...)
+	expDeployment := newDeployment(newNS, loser)
+
+	cleared := loser.DeepCopy()
+	cleared.Status.Conditions = []metav1.Condition{}
+
+	f.expectUpdateVirtualRouterStatusAction(cleared)
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.expectCreateDeploymentAction(expDeployment)
+	f.expectUpdateVirtualRouterStatusAction(withStatus(cleared, expDeployment))
+	f.run(getKey(loser, t))
+}
+
+func TestAddressConflictOutsideWatchedNamespace(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Spec.ExternalIP = "192.168.8.153"
+	virtualRouter.CreationTimestamp = metav1.Now()
+	older := newVirtualRouter("older", int32Ptr(1))
+	older.Namespace = "other"
+	older.Spec.ExternalIP = "192.168.8.153"
+	older.CreationTimestamp = metav1.NewTime(virtualRouter.CreationTimestamp.Add(-time.Hour))
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter, older)
+	c, _, _ := f.newController()
+	c.SetWatchNamespaces([]string{virtualRouter.Namespace})
+
+	// The VirtualRouters of other namespaces are not reconciled, but still
+	// own their addresses
+	c.enqueueVirtualRouter(older)
+	if c.workqueue.Len() != 0 {
+		t.Errorf("expected %s not to be queued", getKey(older, t))
+	}
+	if _, owner := c.addressConflict(virtualRouter); owner != older {
+		t.Errorf("expected the address to be owned by %s, got %v", getKey(older, t), owner)
+	}
+}
@@ -2,23 +2,36 @@ package virtualroutermanager
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	rbac_v1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 	appsinformers "k8s.io/client-go/informers/apps/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	policyinformers "k8s.io/client-go/informers/policy/v1beta1"
+	rbacinformers "k8s.io/client-go/informers/rbac/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	policylisters "k8s.io/client-go/listers/policy/v1beta1"
+	rbaclisters "k8s.io/client-go/listers/rbac/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
@@ -27,7 +40,6 @@ import (
 	samplescheme "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/clientset/versioned/scheme"
 	informers "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/informers/externalversions/networkcontroller/v1"
 	listers "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/listers/networkcontroller/v1"
-	virtualrouter "github.com/tmax-cloud/virtualrouter/pkg/apis/networkcontroller"
 )
 
 const controllerAgentName = "virtual-router"
@@ -38,8 +50,19 @@ const (
 	ROLE_BINDING_NAME              string = "virtualrouter-rb"
 	VIRTUALROUTER_LABEL            string = "virtualrouterInstance"
 	VIRTUALROUTER_DAEMON_FINALIZER string = "virtualrouter/daemon-finalizer"
+	VIRTUALROUTER_FINALIZER        string = "virtualrouter/namespace-finalizer"
+	VIRTUALROUTER_NAMESPACE_LABEL  string = "virtualrouter/owner-namespace"
+	VIRTUALROUTER_NAME_LABEL       string = "virtualrouter/owner-name"
+	RESTARTED_AT_ANNOTATION        string = "kubectl.kubernetes.io/restartedAt"
+	POD_TEMPLATE_HASH_ANNOTATION   string = "virtualrouter/pod-template-hash"
+
+	DEFAULT_IMAGE_PULL_POLICY corev1.PullPolicy = corev1.PullAlways
 )
 
+// namespaceDeletionPollInterval is how often a deleted VirtualRouter is
+// requeued while its generated namespace is terminating.
+const namespaceDeletionPollInterval = 5 * time.Second
+
 const (
 	// SuccessSynced is used as part of the Event 'reason' when a VirtualRouter is synced
 	SuccessSynced = "Synced"
@@ -55,6 +78,36 @@ const (
 	MessageResourceSynced = "VirtualRouter synced successfully"
 )
 
+// Reasons used for the conditions in VirtualRouterStatus
+const (
+	ReasonNamespaceCreated          = "NamespaceCreated"
+	ReasonNamespaceFailed           = "NamespaceFailed"
+	ReasonRBACCreated               = "RBACCreated"
+	ReasonRBACFailed                = "RBACFailed"
+	ReasonDeploymentFailed          = "DeploymentFailed"
+	ReasonDeploymentNotOwned        = "DeploymentNotOwned"
+	ReasonInvalidPodTemplateOverlay = "InvalidPodTemplateOverlay"
+	ReasonRoleRulesNotAllowed       = "RoleRulesNotAllowed"
+	ReasonDisruptionBudgetCreated   = "DisruptionBudgetCreated"
+	ReasonDisruptionBudgetFailed    = "DisruptionBudgetFailed"
+	ReasonIPAllocated               = "IPAllocated"
+	ReasonIPAllocationFailed        = "IPAllocationFailed"
+	ReasonVlanAllocated             = "VlanAllocated"
+	ReasonVlanAllocationFailed      = "VlanAllocationFailed"
+	ReasonVlanConflict              = "VlanConflict"
+	ReasonAddressConflict           = "AddressConflict"
+	ReasonReplicasAvailable         = "MinimumReplicasAvailable"
+	ReasonReplicasUnavailable       = "ReplicasUnavailable"
+	ReasonWaitingForDaemon          = "WaitingForDaemon"
+	ReasonAsExpected                = "AsExpected"
+	ReasonDeletingNamespace         = "DeletingNamespace"
+	ReasonNamespaceDeleted          = "NamespaceDeleted"
+	MessageWaitingForDaemon         = "Waiting for the daemon to attach router pods"
+	MessageReplicasAvailability     = "%d of %d replicas available"
+	MessageDeletingNamespace        = "Waiting for namespace %q to be deleted"
+	MessageNamespaceDeleted         = "Namespace %q deleted"
+)
+
 const networkGroupName = "network.tmaxanc.com"
 
 // Controller is the controller implementation for VirtualRouter resources
@@ -66,8 +119,30 @@ type Controller struct {
 
 	deploymentsLister    appslisters.DeploymentLister
 	deploymentsSynced    cache.InformerSynced
+	statefulSetsLister   appslisters.StatefulSetLister
+	statefulSetsSynced   cache.InformerSynced
+	pdbLister            policylisters.PodDisruptionBudgetLister
+	pdbSynced            cache.InformerSynced
+	saLister             corelisters.ServiceAccountLister
+	saSynced             cache.InformerSynced
+	rolesLister          rbaclisters.RoleLister
+	rolesSynced          cache.InformerSynced
+	roleBindingsLister   rbaclisters.RoleBindingLister
+	roleBindingsSynced   cache.InformerSynced
 	virtualRoutersLister listers.VirtualRouterLister
 	virtualRoutersSynced cache.InformerSynced
+	ipPoolsLister        listers.IPPoolLister
+	ipPoolsSynced        cache.InformerSynced
+	vlanPoolsLister      listers.VlanPoolLister
+	vlanPoolsSynced      cache.InformerSynced
+
+	// virtualRoutersIndexer indexes the VirtualRouters by ADDRESS_INDEX
+	virtualRoutersIndexer cache.Indexer
+	// routerRole describes the Role of the router pods
+	routerRole *RouterRoleConfig
+	// watchNamespaces are the namespaces whose VirtualRouters are
+	// reconciled, all of them when empty
+	watchNamespaces sets.String
 
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
@@ -85,7 +160,14 @@ func NewController(
 	kubeclientset kubernetes.Interface,
 	sampleclientset clientset.Interface,
 	deploymentInformer appsinformers.DeploymentInformer,
-	virtualRouterInformer informers.VirtualRouterInformer) *Controller {
+	statefulSetInformer appsinformers.StatefulSetInformer,
+	pdbInformer policyinformers.PodDisruptionBudgetInformer,
+	saInformer coreinformers.ServiceAccountInformer,
+	roleInformer rbacinformers.RoleInformer,
+	roleBindingInformer rbacinformers.RoleBindingInformer,
+	virtualRouterInformer informers.VirtualRouterInformer,
+	ipPoolInformer informers.IPPoolInformer,
+	vlanPoolInformer informers.VlanPoolInformer) *Controller {
 
 	// Create event broadcaster
 	// Add virtual-router types to the default Kubernetes Scheme so Events can be
@@ -102,19 +184,45 @@ func NewController(
 		sampleclientset:      sampleclientset,
 		deploymentsLister:    deploymentInformer.Lister(),
 		deploymentsSynced:    deploymentInformer.Informer().HasSynced,
+		statefulSetsLister:   statefulSetInformer.Lister(),
+		statefulSetsSynced:   statefulSetInformer.Informer().HasSynced,
+		pdbLister:            pdbInformer.Lister(),
+		pdbSynced:            pdbInformer.Informer().HasSynced,
+		saLister:             saInformer.Lister(),
+		saSynced:             saInformer.Informer().HasSynced,
+		rolesLister:          roleInformer.Lister(),
+		rolesSynced:          roleInformer.Informer().HasSynced,
+		roleBindingsLister:   roleBindingInformer.Lister(),
+		roleBindingsSynced:   roleBindingInformer.Informer().HasSynced,
 		virtualRoutersLister: virtualRouterInformer.Lister(),
 		virtualRoutersSynced: virtualRouterInformer.Informer().HasSynced,
+		ipPoolsLister:        ipPoolInformer.Lister(),
+		ipPoolsSynced:        ipPoolInformer.Informer().HasSynced,
+		vlanPoolsLister:      vlanPoolInformer.Lister(),
+		vlanPoolsSynced:      vlanPoolInformer.Informer().HasSynced,
 		workqueue:            workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "VirtualRouters"),
 		recorder:             recorder,
 	}
 
+	// The addresses of all VirtualRouters are indexed to find the ones
+	// claimed twice
+	if err := virtualRouterInformer.Informer().AddIndexers(cache.Indexers{ADDRESS_INDEX: addressIndexFunc}); err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to add the %s index: %v", ADDRESS_INDEX, err))
+	}
+	controller.virtualRoutersIndexer = virtualRouterInformer.Informer().GetIndexer()
+	controller.routerRole = DefaultRouterRoleConfig()
+
 	klog.Info("Setting up event handlers")
 	// Set up an event handler for when VirtualRouter resources change
 	virtualRouterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: controller.enqueueVirtualRouter,
 		UpdateFunc: func(old, new interface{}) {
 			controller.enqueueVirtualRouter(new)
+			// The VirtualRouters which conflicted with an address it no
+			// longer uses can be deployed now
+			controller.enqueueAddressConflicts(old.(*samplev1alpha1.VirtualRouter), new.(*samplev1alpha1.VirtualRouter))
 		},
+		DeleteFunc: controller.handleVirtualRouterDeletion,
 	})
 	// Set up an event handler for when Deployment resources change. This
 	// handler will lookup the owner of the given Deployment, and if it is
@@ -136,10 +244,64 @@ func NewController(
 		},
 		DeleteFunc: controller.handleObject,
 	})
+	// StatefulSets, PodDisruptionBudgets and the RBAC objects of the router
+	// pods are handled the same way as Deployments.
+	for _, informer := range []cache.SharedIndexInformer{
+		statefulSetInformer.Informer(),
+		pdbInformer.Informer(),
+		saInformer.Informer(),
+		roleInformer.Informer(),
+		roleBindingInformer.Informer(),
+	} {
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: controller.handleObject,
+			UpdateFunc: func(old, new interface{}) {
+				if new.(metav1.Object).GetResourceVersion() == old.(metav1.Object).GetResourceVersion() {
+					return
+				}
+				controller.handleObject(new)
+			},
+			DeleteFunc: controller.handleObject,
+		})
+	}
+	// IPPools and VlanPools are not owned by a VirtualRouter, the
+	// VirtualRouters referencing a pool are synced when it changes.
+	ipPoolInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.handleIPPool,
+		UpdateFunc: func(old, new interface{}) {
+			if new.(metav1.Object).GetResourceVersion() == old.(metav1.Object).GetResourceVersion() {
+				return
+			}
+			controller.handleIPPool(new)
+		},
+	})
+	vlanPoolInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.handleVlanPool,
+		UpdateFunc: func(old, new interface{}) {
+			if new.(metav1.Object).GetResourceVersion() == old.(metav1.Object).GetResourceVersion() {
+				return
+			}
+			controller.handleVlanPool(new)
+		},
+	})
 
 	return controller
 }
 
+// SetRouterRoleConfig replaces the rules of the Role of the router pods. It
+// has to be called before Run.
+func (c *Controller) SetRouterRoleConfig(config *RouterRoleConfig) {
+	c.routerRole = config
+}
+
+// SetWatchNamespaces limits the VirtualRouters reconciled to those of
+// namespaces. The VirtualRouter informer is not filtered, so that the
+// addresses and VLANs of the other namespaces are still known. No namespace
+// means every namespace. It has to be called before Run.
+func (c *Controller) SetWatchNamespaces(namespaces []string) {
+	c.watchNamespaces = sets.NewString(namespaces...)
+}
+
 // Run will set up the event handlers for types we are interested in, as well
 // as syncing informer caches and starting workers. It will block until stopCh
 // is closed, at which point it will shutdown the workqueue and wait for
@@ -153,7 +315,7 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 
 	// Wait for the caches to be synced before starting workers
 	klog.Info("Waiting for informer caches to sync")
-	if ok := cache.WaitForCacheSync(stopCh, c.deploymentsSynced, c.virtualRoutersSynced); !ok {
+	if ok := cache.WaitForCacheSync(stopCh, c.deploymentsSynced, c.statefulSetsSynced, c.pdbSynced, c.saSynced, c.rolesSynced, c.roleBindingsSynced, c.virtualRoutersSynced, c.ipPoolsSynced, c.vlanPoolsSynced); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
@@ -170,6 +332,14 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	return nil
 }
 
+// Ready returns nil once the informer caches have synced.
+func (c *Controller) Ready() error {
+	if !c.deploymentsSynced() || !c.statefulSetsSynced() || !c.pdbSynced() || !c.saSynced() || !c.rolesSynced() || !c.roleBindingsSynced() || !c.virtualRoutersSynced() || !c.ipPoolsSynced() || !c.vlanPoolsSynced() {
+		return fmt.Errorf("informer caches are not synced")
+	}
+	return nil
+}
+
 // runWorker is a long-running function that will continually call the
 // processNextWorkItem function in order to read and process a message on the
 // workqueue.
@@ -213,7 +383,10 @@ func (c *Controller) processNextWorkItem() bool {
 		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// VirtualRouter resource to be synced.
-		if err := c.syncHandler(key); err != nil {
+		start := time.Now()
+		err := c.syncHandler(key)
+		c.observeReconcile(key, start, err)
+		if err != nil {
 			// Put the item back on the workqueue to handle any transient errors.
 			c.workqueue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
@@ -258,6 +431,23 @@ func (c *Controller) syncHandler(key string) error {
 		return err
 	}
 
+	// The resources of the router live in a namespace generated for this
+	// VirtualRouter
+	newNS, err := c.virtualRouterNamespace(virtualRouter)
+	if err != nil {
+		return err
+	}
+
+	// The generated namespace is cluster-scoped, so the garbage collector
+	// does not honour its owner reference to the namespaced VirtualRouter.
+	// A finalizer makes sure it is removed before the VirtualRouter is gone.
+	if !virtualRouter.DeletionTimestamp.IsZero() {
+		return c.finalizeVirtualRouter(key, newNS, virtualRouter)
+	}
+	if virtualRouter, err = c.ensureVirtualRouterFinalizer(virtualRouter); err != nil {
+		return err
+	}
+
 	deploymentName := virtualRouter.Spec.DeploymentName
 	if deploymentName == "" {
 		// We choose to absorb the error here as the worker would requeue the
@@ -267,28 +457,126 @@ func (c *Controller) syncHandler(key string) error {
 		return nil
 	}
 
-	// create deployment with new Namespace same as virtualrouter resource name
-	newNS := virtualRouter.Name
-	if err := c.ensureVirtualRouterNamespace(newNS, virtualRouter); err != nil {
+	// The addresses of the IPPools the VirtualRouter references are recorded
+	// in its status, where the daemon picks them up
+	allocated, err := c.ensureVirtualRouterIPAllocations(virtualRouter)
+	if err != nil {
+		klog.Error(err)
+		return c.syncFailed(virtualRouter, newNS, samplev1alpha1.ConditionIPAllocated, ReasonIPAllocationFailed, err)
+	}
+	virtualRouter = allocated
+
+	// The VLAN is claimed for the namespace of the VirtualRouter, so that the
+	// VirtualRouters of another tenant cannot use it
+	allocated, reason, err := c.ensureVirtualRouterVlan(virtualRouter)
+	if err != nil {
+		klog.Error(err)
+		if reason == ReasonVlanConflict {
+			c.recorder.Event(virtualRouter, corev1.EventTypeWarning, ReasonVlanConflict, err.Error())
+		}
+		return c.syncFailed(virtualRouter, newNS, samplev1alpha1.ConditionVlanAllocated, reason, err)
+	}
+	virtualRouter = allocated
+
+	// Two VirtualRouters with the same address fight over it through ARP, so
+	// only the one created first is deployed
+	if address, owner := c.addressConflict(virtualRouter); owner != nil {
+		err := fmt.Errorf("%s is already used by VirtualRouter %s/%s", address, owner.Namespace, owner.Name)
 		klog.Error(err)
+		c.recorder.Event(virtualRouter, corev1.EventTypeWarning, ReasonAddressConflict, err.Error())
+		conflict := newCondition(samplev1alpha1.ConditionConflict, metav1.ConditionTrue, ReasonAddressConflict, err.Error())
+		if statusErr := c.updateVirtualRouterStatus(virtualRouter, newNS, nil, conflict); statusErr != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to update status of virtualRouter '%s/%s': %s", virtualRouter.Namespace, virtualRouter.Name, statusErr.Error()))
+		}
 		return err
 	}
+	if virtualRouter, err = c.clearConflict(virtualRouter); err != nil {
+		return err
+	}
+
+	if err := c.ensureVirtualRouterNamespace(newNS, virtualRouter); err != nil {
+		klog.Error(err)
+		return c.syncFailed(virtualRouter, newNS, samplev1alpha1.ConditionNamespaceReady, ReasonNamespaceFailed, err)
+	}
+	namespaceReady := newCondition(samplev1alpha1.ConditionNamespaceReady, metav1.ConditionTrue, ReasonNamespaceCreated, "")
 
 	if err := c.ensureVirtualRouterSA(newNS, virtualRouter); err != nil {
 		klog.Error(err)
-		return err
+		return c.syncFailed(virtualRouter, newNS, samplev1alpha1.ConditionRBACReady, ReasonRBACFailed, err, namespaceReady)
 	}
 
-	if err := c.ensureVirtualRouterRole(newNS, virtualRouter); err != nil {
+	// Rules which are not allowed are not retried, the VirtualRouter is
+	// queued again once its spec is fixed.
+	roleRules, err := c.routerRole.rulesFor(virtualRouter)
+	if err != nil {
+		c.recorder.Event(virtualRouter, corev1.EventTypeWarning, ReasonRoleRulesNotAllowed, err.Error())
+		utilruntime.HandleError(c.syncFailed(virtualRouter, newNS, samplev1alpha1.ConditionRBACReady, ReasonRoleRulesNotAllowed, err, namespaceReady))
+		return nil
+	}
+	if err := c.ensureVirtualRouterRole(newNS, virtualRouter, roleRules); err != nil {
 		klog.Error(err)
-		return err
+		return c.syncFailed(virtualRouter, newNS, samplev1alpha1.ConditionRBACReady, ReasonRBACFailed, err, namespaceReady)
 	}
 
 	if err := c.ensureVirtualRouterRoleBinding(newNS, virtualRouter); err != nil {
 		klog.Error(err)
+		return c.syncFailed(virtualRouter, newNS, samplev1alpha1.ConditionRBACReady, ReasonRBACFailed, err, namespaceReady)
+	}
+	rbacReady := newCondition(samplev1alpha1.ConditionRBACReady, metav1.ConditionTrue, ReasonRBACCreated, "")
+
+	// An invalid overlay is not retried, the VirtualRouter is queued again
+	// once its spec is fixed.
+	if _, err := applyPodTemplateOverlay(corev1.PodTemplateSpec{}, &corev1.PodTemplateSpec{}, virtualRouter.Spec.PodTemplateOverlay); err != nil {
+		c.recorder.Event(virtualRouter, corev1.EventTypeWarning, ReasonInvalidPodTemplateOverlay, err.Error())
+		utilruntime.HandleError(c.syncFailed(virtualRouter, newNS, samplev1alpha1.ConditionDeploymentAvailable, ReasonInvalidPodTemplateOverlay, err, namespaceReady, rbacReady))
+		return nil
+	}
+
+	// Webhooks are optional, so workloadKind may have been changed
+	if err := c.deleteOtherWorkloads(newNS, virtualRouter); err != nil {
+		return c.syncFailed(virtualRouter, newNS, samplev1alpha1.ConditionDeploymentAvailable, ReasonDeploymentFailed, err, namespaceReady, rbacReady)
+	}
+
+	var availableReplicas int32
+	var deploymentAvailable metav1.Condition
+	switch virtualRouter.Spec.WorkloadKind {
+	case samplev1alpha1.WorkloadKindStatefulSet:
+		statefulSet, reason, err := c.ensureVirtualRouterStatefulSet(newNS, virtualRouter)
+		if err != nil {
+			return c.syncFailed(virtualRouter, newNS, samplev1alpha1.ConditionDeploymentAvailable, reason, err, namespaceReady, rbacReady)
+		}
+		availableReplicas = statefulSet.Status.ReadyReplicas
+		deploymentAvailable = statefulSetAvailableCondition(statefulSet)
+	default:
+		deployment, reason, err := c.ensureVirtualRouterDeployment(newNS, virtualRouter)
+		if err != nil {
+			return c.syncFailed(virtualRouter, newNS, samplev1alpha1.ConditionDeploymentAvailable, reason, err, namespaceReady, rbacReady)
+		}
+		availableReplicas = deployment.Status.AvailableReplicas
+		deploymentAvailable = deploymentAvailableCondition(deployment)
+	}
+
+	if err := c.ensureVirtualRouterPDB(newNS, virtualRouter); err != nil {
+		return c.syncFailed(virtualRouter, newNS, samplev1alpha1.ConditionDisruptionBudgetReady, ReasonDisruptionBudgetFailed, err, namespaceReady, rbacReady, deploymentAvailable)
+	}
+	disruptionBudgetReady := newCondition(samplev1alpha1.ConditionDisruptionBudgetReady, metav1.ConditionTrue, ReasonDisruptionBudgetCreated, "")
+
+	// Finally, we update the status block of the VirtualRouter resource to reflect the
+	// current state of the world
+	err = c.updateVirtualRouterStatus(virtualRouter, newNS, &availableReplicas, namespaceReady, rbacReady, deploymentAvailable, disruptionBudgetReady)
+	if err != nil {
 		return err
 	}
 
+	c.recorder.Event(virtualRouter, corev1.EventTypeNormal, SuccessSynced, MessageResourceSynced)
+	return nil
+}
+
+// ensureVirtualRouterDeployment creates the router Deployment, or updates it
+// when it drifted from the VirtualRouter. On failure it also returns the reason
+// reported in the DeploymentAvailable condition.
+func (c *Controller) ensureVirtualRouterDeployment(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) (*appsv1.Deployment, string, error) {
+	deploymentName := virtualRouter.Spec.DeploymentName
 	// Get the deployment with the name specified in VirtualRouter.spec
 	deployment, err := c.deploymentsLister.Deployments(newNS).Get(deploymentName)
 	// If the resource doesn't exist, we'll create it
@@ -296,13 +584,18 @@ func (c *Controller) syncHandler(key string) error {
 		klog.Info("NotFound Deploy start")
 
 		deployment, err = c.kubeclientset.AppsV1().Deployments(newNS).Create(context.TODO(), newDeployment(newNS, virtualRouter), metav1.CreateOptions{})
+		// A Deployment created before the generated objects were labelled is
+		// not cached by the informer, it is labelled below
+		if errors.IsAlreadyExists(err) {
+			deployment, err = c.kubeclientset.AppsV1().Deployments(newNS).Get(context.TODO(), deploymentName, metav1.GetOptions{})
+		}
 	}
 
 	// If an error occurs during Get/Create, we'll requeue the item so we can
 	// attempt processing again later. This could have been caused by a
 	// temporary network failure, or any other transient reason.
 	if err != nil {
-		return err
+		return nil, ReasonDeploymentFailed, err
 	}
 
 	// If the Deployment is not controlled by this VirtualRouter resource, we should log
@@ -310,49 +603,201 @@ func (c *Controller) syncHandler(key string) error {
 	if !metav1.IsControlledBy(deployment, virtualRouter) {
 		msg := fmt.Sprintf(MessageResourceExists, deployment.Name)
 		c.recorder.Event(virtualRouter, corev1.EventTypeWarning, ErrResourceExists, msg)
-		return fmt.Errorf(msg)
+		return nil, ReasonDeploymentNotOwned, fmt.Errorf(msg)
 	}
 
-	// If this number of the replicas on the VirtualRouter resource is specified, and the
-	// number does not equal the current desired replicas on the Deployment, we
-	// should update the Deployment resource.
-	if virtualRouter.Spec.Replicas != nil && *virtualRouter.Spec.Replicas != *deployment.Spec.Replicas {
-		klog.V(4).Infof("VirtualRouter %s replicas: %d, deployment replicas: %d", name, *virtualRouter.Spec.Replicas, *deployment.Spec.Replicas)
-		deployment, err = c.kubeclientset.AppsV1().Deployments(newNS).Update(context.TODO(), newDeployment(newNS, virtualRouter), metav1.UpdateOptions{})
+	// If any field the VirtualRouter resource manages on the Deployment has
+	// drifted, either because the VirtualRouter spec changed or because the
+	// Deployment was edited by hand, we should update the Deployment resource.
+	desired := newDeployment(newNS, virtualRouter)
+	updated := mergeDeployment(deployment, desired)
+	if !equality.Semantic.DeepEqual(deployment, updated) {
+		klog.V(4).Infof("VirtualRouter %s: deployment %s/%s drifted from the desired state", virtualRouter.Name, newNS, deploymentName)
+		deployment, err = c.kubeclientset.AppsV1().Deployments(newNS).Update(context.TODO(), updated, metav1.UpdateOptions{})
 	}
 
 	// If an error occurs during Update, we'll requeue the item so we can
 	// attempt processing again later. This could have been caused by a
 	// temporary network failure, or any other transient reason.
 	if err != nil {
+		return nil, ReasonDeploymentFailed, err
+	}
+	return deployment, "", nil
+}
+
+// ensureVirtualRouterFinalizer adds VIRTUALROUTER_FINALIZER to the
+// VirtualRouter and returns the updated object.
+func (c *Controller) ensureVirtualRouterFinalizer(virtualRouter *samplev1alpha1.VirtualRouter) (*samplev1alpha1.VirtualRouter, error) {
+	if containsString(virtualRouter.Finalizers, VIRTUALROUTER_FINALIZER) {
+		return virtualRouter, nil
+	}
+	virtualRouterCopy := virtualRouter.DeepCopy()
+	virtualRouterCopy.Finalizers = append(virtualRouterCopy.Finalizers, VIRTUALROUTER_FINALIZER)
+	return c.sampleclientset.TmaxV1().VirtualRouters(virtualRouter.Namespace).Update(context.TODO(), virtualRouterCopy, metav1.UpdateOptions{})
+}
+
+// finalizeVirtualRouter deletes the generated namespace, and with it every
+// resource created for the VirtualRouter, and releases the VirtualRouter once
+// the namespace is gone. While the namespace is terminating the progress is
+// reported in the Terminating condition and the VirtualRouter is requeued.
+func (c *Controller) finalizeVirtualRouter(key string, newNS string, virtualRouter *samplev1alpha1.VirtualRouter) error {
+	if !containsString(virtualRouter.Finalizers, VIRTUALROUTER_FINALIZER) {
+		return nil
+	}
+
+	ns, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), newNS, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
 		return err
 	}
 
-	// Finally, we update the status block of the VirtualRouter resource to reflect the
-	// current state of the world
-	err = c.updateVirtualRouterStatus(virtualRouter, deployment)
-	if err != nil {
+	// Only delete the namespace if this VirtualRouter created it
+	if err == nil && metav1.IsControlledBy(ns, virtualRouter) {
+		if ns.DeletionTimestamp.IsZero() {
+			err = c.kubeclientset.CoreV1().Namespaces().Delete(context.TODO(), newNS, metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			c.recorder.Eventf(virtualRouter, corev1.EventTypeNormal, ReasonDeletingNamespace, MessageDeletingNamespace, newNS)
+		}
+		terminating := newCondition(samplev1alpha1.ConditionTerminating, metav1.ConditionTrue, ReasonDeletingNamespace, fmt.Sprintf(MessageDeletingNamespace, newNS))
+		if err := c.updateVirtualRouterStatus(virtualRouter, newNS, nil, terminating); err != nil {
+			return err
+		}
+		c.workqueue.AddAfter(key, namespaceDeletionPollInterval)
+		return nil
+	}
+
+	// The router pods are gone, so their addresses and VLAN can be handed out
+	// again
+	if err := c.releaseVirtualRouterIPAddresses(virtualRouter); err != nil {
+		return err
+	}
+	if err := c.releaseVlans(virtualRouter, ""); err != nil {
 		return err
 	}
 
-	c.recorder.Event(virtualRouter, corev1.EventTypeNormal, SuccessSynced, MessageResourceSynced)
+	c.recorder.Eventf(virtualRouter, corev1.EventTypeNormal, ReasonNamespaceDeleted, MessageNamespaceDeleted, newNS)
+	virtualRouterCopy := virtualRouter.DeepCopy()
+	virtualRouterCopy.Finalizers = removeString(virtualRouterCopy.Finalizers, VIRTUALROUTER_FINALIZER)
+	if _, err := c.sampleclientset.TmaxV1().VirtualRouters(virtualRouter.Namespace).Update(context.TODO(), virtualRouterCopy, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	forgetReconcileMetrics(virtualRouter.Namespace, virtualRouter.Name)
 	return nil
 }
 
-func (c *Controller) updateVirtualRouterStatus(virtualRouter *samplev1alpha1.VirtualRouter, deployment *appsv1.Deployment) error {
-	// NEVER modify objects from the store. It's a read-only, local cache.
-	// You can use DeepCopy() to make a deep copy of original object and modify this copy
-	// Or create a copy manually for better performance
-	virtualRouterCopy := virtualRouter.DeepCopy()
-	virtualRouterCopy.Status.AvailableReplicas = deployment.Status.AvailableReplicas
-	// If the CustomResourceSubresources feature gate is not enabled,
-	// we must use Update instead of UpdateStatus to update the Status block of the VirtualRouter resource.
-	// UpdateStatus will not allow changes to the Spec of the resource,
-	// which is ideal for ensuring nothing other than resource status has been updated.
-	_, err := c.sampleclientset.TmaxV1().VirtualRouters(virtualRouter.Namespace).Update(context.TODO(), virtualRouterCopy, metav1.UpdateOptions{})
+// syncFailed records the failed step as a False condition, together with the
+// conditions of the steps that already succeeded, and returns the original
+// error so the VirtualRouter is requeued.
+func (c *Controller) syncFailed(virtualRouter *samplev1alpha1.VirtualRouter, newNS string, conditionType string, reason string, err error, succeeded ...metav1.Condition) error {
+	conditions := append(succeeded, newCondition(conditionType, metav1.ConditionFalse, reason, err.Error()))
+	if statusErr := c.updateVirtualRouterStatus(virtualRouter, newNS, nil, conditions...); statusErr != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to update status of virtualRouter '%s/%s': %s", virtualRouter.Namespace, virtualRouter.Name, statusErr.Error()))
+	}
 	return err
 }
 
+// updateVirtualRouterStatus writes the generated namespace, the given
+// conditions and, if availableReplicas is not nil, the available replicas through
+// the status subresource. Conditions owned by the daemon are preserved, and
+// the update is retried against the latest VirtualRouter on conflict.
+func (c *Controller) updateVirtualRouterStatus(virtualRouter *samplev1alpha1.VirtualRouter, newNS string, availableReplicas *int32, conditions ...metav1.Condition) error {
+	observedGeneration := virtualRouter.Generation
+	latest := virtualRouter
+	firstTry := true
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if !firstTry {
+			var err error
+			latest, err = c.sampleclientset.TmaxV1().VirtualRouters(virtualRouter.Namespace).Get(context.TODO(), virtualRouter.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+		}
+		firstTry = false
+
+		// NEVER modify objects from the store. It's a read-only, local cache.
+		// You can use DeepCopy() to make a deep copy of original object and modify this copy
+		// Or create a copy manually for better performance
+		virtualRouterCopy := latest.DeepCopy()
+		virtualRouterCopy.Status.Namespace = newNS
+		if availableReplicas != nil {
+			virtualRouterCopy.Status.AvailableReplicas = *availableReplicas
+		}
+		virtualRouterCopy.Status.ObservedGeneration = observedGeneration
+		for _, condition := range conditions {
+			condition.ObservedGeneration = observedGeneration
+			meta.SetStatusCondition(&virtualRouterCopy.Status.Conditions, condition)
+		}
+		if meta.FindStatusCondition(virtualRouterCopy.Status.Conditions, samplev1alpha1.ConditionNetworkAttached) == nil {
+			meta.SetStatusCondition(&virtualRouterCopy.Status.Conditions, newCondition(samplev1alpha1.ConditionNetworkAttached, metav1.ConditionUnknown, ReasonWaitingForDaemon, MessageWaitingForDaemon))
+		}
+		degraded := degradedCondition(virtualRouterCopy.Status.Conditions)
+		degraded.ObservedGeneration = observedGeneration
+		meta.SetStatusCondition(&virtualRouterCopy.Status.Conditions, degraded)
+
+		if equality.Semantic.DeepEqual(latest.Status, virtualRouterCopy.Status) {
+			return nil
+		}
+		_, err := c.sampleclientset.TmaxV1().VirtualRouters(virtualRouter.Namespace).UpdateStatus(context.TODO(), virtualRouterCopy, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func newCondition(conditionType string, status metav1.ConditionStatus, reason string, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// deploymentAvailableCondition reports whether every desired replica of the
+// router Deployment is available.
+func deploymentAvailableCondition(deployment *appsv1.Deployment) metav1.Condition {
+	return availableCondition(deployment.Spec.Replicas, deployment.Status.AvailableReplicas)
+}
+
+// statefulSetAvailableCondition reports whether every desired replica of the
+// router StatefulSet is ready.
+func statefulSetAvailableCondition(statefulSet *appsv1.StatefulSet) metav1.Condition {
+	return availableCondition(statefulSet.Spec.Replicas, statefulSet.Status.ReadyReplicas)
+}
+
+func availableCondition(replicas *int32, available int32) metav1.Condition {
+	var desired int32 = 1
+	if replicas != nil {
+		desired = *replicas
+	}
+	msg := fmt.Sprintf(MessageReplicasAvailability, available, desired)
+	if available < desired {
+		return newCondition(samplev1alpha1.ConditionDeploymentAvailable, metav1.ConditionFalse, ReasonReplicasUnavailable, msg)
+	}
+	return newCondition(samplev1alpha1.ConditionDeploymentAvailable, metav1.ConditionTrue, ReasonReplicasAvailable, msg)
+}
+
+// degradedCondition summarizes the other conditions: the VirtualRouter is
+// degraded as soon as one of them is False, or it is in conflict.
+func degradedCondition(conditions []metav1.Condition) metav1.Condition {
+	if condition := meta.FindStatusCondition(conditions, samplev1alpha1.ConditionConflict); condition != nil && condition.Status == metav1.ConditionTrue {
+		return newCondition(samplev1alpha1.ConditionDegraded, metav1.ConditionTrue, condition.Reason, fmt.Sprintf("%s: %s", samplev1alpha1.ConditionConflict, condition.Message))
+	}
+	for _, conditionType := range []string{
+		samplev1alpha1.ConditionNamespaceReady,
+		samplev1alpha1.ConditionRBACReady,
+		samplev1alpha1.ConditionDeploymentAvailable,
+		samplev1alpha1.ConditionDisruptionBudgetReady,
+		samplev1alpha1.ConditionNetworkAttached,
+		samplev1alpha1.ConditionIPAllocated,
+		samplev1alpha1.ConditionVlanAllocated,
+	} {
+		if condition := meta.FindStatusCondition(conditions, conditionType); condition != nil && condition.Status == metav1.ConditionFalse {
+			return newCondition(samplev1alpha1.ConditionDegraded, metav1.ConditionTrue, condition.Reason, fmt.Sprintf("%s: %s", conditionType, condition.Message))
+		}
+	}
+	return newCondition(samplev1alpha1.ConditionDegraded, metav1.ConditionFalse, ReasonAsExpected, "")
+}
+
 // enqueueVirtualRouter takes a VirtualRouter resource and converts it into a namespace/name
 // string which is then put onto the work queue. This method should *not* be
 // passed resources of any type other than VirtualRouter.
@@ -363,6 +808,12 @@ func (c *Controller) enqueueVirtualRouter(obj interface{}) {
 		utilruntime.HandleError(err)
 		return
 	}
+	// VirtualRouters outside the watched namespaces are still indexed, since
+	// their addresses can conflict, but they are left to another manager
+	if namespace, _, err := cache.SplitMetaNamespaceKey(key); err == nil && c.watchNamespaces.Len() != 0 && !c.watchNamespaces.Has(namespace) {
+		klog.V(4).Infof("ignoring virtualRouter '%s' outside the watched namespaces", key)
+		return
+	}
 	c.workqueue.Add(key)
 }
 
@@ -395,7 +846,13 @@ func (c *Controller) handleObject(obj interface{}) {
 			return
 		}
 
-		virtualRouter, err := c.virtualRoutersLister.VirtualRouters(object.GetNamespace()).Get(ownerRef.Name)
+		// Objects in the generated namespace carry the namespace of their
+		// VirtualRouter as a label
+		namespace := object.GetNamespace()
+		if crNamespace, ok := object.GetLabels()[VIRTUALROUTER_NAMESPACE_LABEL]; ok {
+			namespace = crNamespace
+		}
+		virtualRouter, err := c.virtualRoutersLister.VirtualRouters(namespace).Get(ownerRef.Name)
 		if err != nil {
 			klog.V(4).Infof("ignoring orphaned object '%s' of virtualRouter '%s'", object.GetSelfLink(), ownerRef.Name)
 			return
@@ -417,11 +874,21 @@ func newDeployment(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) *a
 	for _, nodeSelector := range virtualRouter.Spec.NodeSelector {
 		nodeSelectorMap[nodeSelector.Key] = nodeSelector.Value
 	}
+	spec := virtualRouter.Spec.DeepCopy()
+	imagePullPolicy := spec.ImagePullPolicy
+	if imagePullPolicy == "" {
+		imagePullPolicy = DEFAULT_IMAGE_PULL_POLICY
+	}
+	privileged := true
+	if virtualRouter.Spec.Privileged != nil {
+		privileged = *virtualRouter.Spec.Privileged
+	}
 	// var uuid = uuid.Must(uuid.NewRandom())
-	return &appsv1.Deployment{
+	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      virtualRouter.Spec.DeploymentName,
 			Namespace: newNS,
+			Labels:    ownerLabels(virtualRouter),
 
 			OwnerReferences: []metav1.OwnerReference{
 				*metav1.NewControllerRef(virtualRouter, samplev1alpha1.SchemeGroupVersion.WithKind("VirtualRouter")),
@@ -442,16 +909,20 @@ func newDeployment(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) *a
 					Finalizers: []string{VIRTUALROUTER_DAEMON_FINALIZER},
 				},
 				Spec: corev1.PodSpec{
-					Affinity:           &virtualRouter.Spec.Affinity,
+					Affinity:           virtualRouter.Spec.Affinity.DeepCopy(),
 					ServiceAccountName: "virtualrouter-sa",
 					NodeSelector:       nodeSelectorMap,
+					Tolerations:        spec.Tolerations,
+					PriorityClassName:  virtualRouter.Spec.PriorityClassName,
+					ImagePullSecrets:   spec.ImagePullSecrets,
 					Containers: []corev1.Container{
 						{
 							// Name:            "virtualrouter-" + uuid.String(),
 							Name:  virtualRouter.Name,
 							Image: virtualRouter.Spec.Image,
 							// Image:           "tmaxcloudck/virtualrouter:0.0.1",
-							ImagePullPolicy: "Always",
+							ImagePullPolicy: imagePullPolicy,
+							Resources:       spec.Resources,
 							Env: []corev1.EnvVar{
 								{
 									Name:  "POD_NAMESPACE",
@@ -466,9 +937,7 @@ func newDeployment(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) *a
 										corev1.Capability("SYS_ADMIN"),
 									},
 								},
-								Privileged: func(b bool) *bool {
-									return &b
-								}(true),
+								Privileged: &privileged,
 							},
 						},
 					},
@@ -476,123 +945,204 @@ func newDeployment(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) *a
 			},
 		},
 	}
+
+	// syncHandler refuses invalid overlays before building the Deployment.
+	deployment.Spec.Template, _ = applyPodTemplateOverlay(deployment.Spec.Template, deployment.Spec.Template.DeepCopy(), virtualRouter.Spec.PodTemplateOverlay)
+	deployment.Annotations = map[string]string{POD_TEMPLATE_HASH_ANNOTATION: podTemplateHash(&deployment.Spec.Template)}
+	return deployment
 }
 
-func (c *Controller) ensureVirtualRouterSA(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) error {
-	_, err := c.kubeclientset.CoreV1().ServiceAccounts(newNS).Get(context.TODO(), SERVICE_ACCOUNT_NAME, metav1.GetOptions{})
-	if err != nil {
-		if !errors.IsNotFound(err) {
-			klog.Error(err)
-			return err
-		}
-		_, err = c.kubeclientset.CoreV1().ServiceAccounts(newNS).Create(context.TODO(), &corev1.ServiceAccount{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      SERVICE_ACCOUNT_NAME,
-				Namespace: newNS,
-				OwnerReferences: []metav1.OwnerReference{
-					*metav1.NewControllerRef(virtualRouter, samplev1alpha1.SchemeGroupVersion.WithKind("VirtualRouter")),
-				},
-			},
-		}, metav1.CreateOptions{})
-		if err != nil {
-			klog.Error(err)
-			return err
-		}
+// mergeDeployment returns a copy of the live Deployment with every field set by
+// newDeployment overwritten by the desired value. Fields defaulted by the API
+// server are kept, so that the result only differs from the live Deployment
+// when the managed fields have drifted.
+func mergeDeployment(live *appsv1.Deployment, desired *appsv1.Deployment) *appsv1.Deployment {
+	merged := live.DeepCopy()
+	merged.Labels = mergeStringMap(merged.Labels, desired.Labels)
+	merged.Annotations = mergeStringMap(merged.Annotations, desired.Annotations)
+	merged.OwnerReferences = desired.OwnerReferences
+	if desired.Spec.Replicas != nil {
+		merged.Spec.Replicas = desired.Spec.Replicas
 	}
-	return nil
+	merged.Spec.Selector = desired.Spec.Selector
+	mergePodTemplate(&merged.Spec.Template, &desired.Spec.Template, live.Annotations[POD_TEMPLATE_HASH_ANNOTATION] != desired.Annotations[POD_TEMPLATE_HASH_ANNOTATION])
+	return merged
 }
 
-// ToDo: modify magic string
-func (c *Controller) ensureVirtualRouterRole(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) error {
-	_, err := c.kubeclientset.RbacV1().Roles(newNS).Get(context.TODO(), ROLE_NAME, metav1.GetOptions{})
-	if err != nil {
-		if !errors.IsNotFound(err) {
-			klog.Error(err)
-			return err
+// mergePodTemplate updates the live pod template to the desired one. When the
+// desired template changed since the workload was last updated, as recorded by
+// POD_TEMPLATE_HASH_ANNOTATION, the template is replaced, so that the fields
+// removed from the VirtualRouter or from its overlay are removed as well.
+// Otherwise only the fields newDeployment sets are overwritten, which reverts
+// edits by hand and leaves the values defaulted by the API server alone.
+func mergePodTemplate(template *corev1.PodTemplateSpec, desired *corev1.PodTemplateSpec, changed bool) {
+	if changed {
+		restartedAt, restarted := template.Annotations[RESTARTED_AT_ANNOTATION]
+		*template = *desired.DeepCopy()
+		if restarted {
+			template.Annotations = mergeStringMap(template.Annotations, map[string]string{RESTARTED_AT_ANNOTATION: restartedAt})
 		}
+		return
+	}
 
-		_, err = c.kubeclientset.RbacV1().Roles(newNS).Create(context.TODO(), &rbac_v1.Role{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      ROLE_NAME,
-				Namespace: newNS,
-				OwnerReferences: []metav1.OwnerReference{
-					*metav1.NewControllerRef(virtualRouter, samplev1alpha1.SchemeGroupVersion.WithKind("VirtualRouter")),
-				},
-			},
-			Rules: []rbac_v1.PolicyRule{
-				{
-					APIGroups: []string{
-						// samplev1alpha1.SchemeGroupVersion.Group,
-						virtualrouter.GroupName,
-					},
-					Resources: []string{
-						"natrules", "firewallrules", "loadbalancerrules",
-					},
-					Verbs: []string{
-						"get", "list", "watch", "create", "update", "patch", "delete",
-					},
-				},
-				{
-					APIGroups: []string{
-						networkGroupName,
-					},
-					Resources: []string{
-						"vpns",
-					},
-					Verbs: []string{
-						"get", "list", "watch",
-					},
-				},
-			},
-		}, metav1.CreateOptions{})
-		if err != nil {
-			klog.Error(err)
-			return err
+	template.Labels = mergeStringMap(template.Labels, desired.Labels)
+	template.Annotations = mergeStringMap(template.Annotations, desired.Annotations)
+	for _, finalizer := range desired.Finalizers {
+		if !containsString(template.Finalizers, finalizer) {
+			template.Finalizers = append(template.Finalizers, finalizer)
 		}
 	}
-	return nil
+
+	podSpec := &template.Spec
+	podSpec.Affinity = desired.Spec.Affinity
+	podSpec.ServiceAccountName = desired.Spec.ServiceAccountName
+	podSpec.NodeSelector = desired.Spec.NodeSelector
+	podSpec.Tolerations = desired.Spec.Tolerations
+	podSpec.PriorityClassName = desired.Spec.PriorityClassName
+	podSpec.ImagePullSecrets = desired.Spec.ImagePullSecrets
+
+	// Keep exactly the desired containers, reusing the live ones so the values
+	// defaulted by the API server do not show up as drift. The fields of the
+	// sidecars of the overlay are only compared through the hash, those of the
+	// router container, named after the VirtualRouter, are overwritten.
+	routerName := desired.Annotations["customresourceName"]
+	containers := make([]corev1.Container, 0, len(desired.Spec.Containers))
+	for _, desiredContainer := range desired.Spec.Containers {
+		container := desiredContainer
+		if liveContainer := findContainer(podSpec.Containers, desiredContainer.Name); liveContainer != nil {
+			container = *liveContainer
+			if container.Name == routerName {
+				container.Image = desiredContainer.Image
+				container.ImagePullPolicy = desiredContainer.ImagePullPolicy
+				container.Env = desiredContainer.Env
+				container.Resources = desiredContainer.Resources
+				container.SecurityContext = desiredContainer.SecurityContext
+			}
+		}
+		containers = append(containers, container)
+	}
+	podSpec.Containers = containers
 }
 
-// ToDo: modify magic string
-func (c *Controller) ensureVirtualRouterRoleBinding(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) error {
-	_, err := c.kubeclientset.RbacV1().RoleBindings(newNS).Get(context.TODO(), ROLE_BINDING_NAME, metav1.GetOptions{})
-	if err != nil {
-		if !errors.IsNotFound(err) {
-			klog.Error(err)
-			return err
+// mergeStringMap returns a copy of live where every key of desired is set to
+// its desired value.
+func mergeStringMap(live map[string]string, desired map[string]string) map[string]string {
+	merged := make(map[string]string, len(live)+len(desired))
+	for k, v := range live {
+		merged[k] = v
+	}
+	for k, v := range desired {
+		merged[k] = v
+	}
+	return merged
+}
+
+func containsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
 		}
+	}
+	return false
+}
 
-		_, err = c.kubeclientset.RbacV1().RoleBindings(newNS).Create(context.TODO(), &rbac_v1.RoleBinding{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      ROLE_BINDING_NAME,
-				Namespace: newNS,
-				OwnerReferences: []metav1.OwnerReference{
-					*metav1.NewControllerRef(virtualRouter, samplev1alpha1.SchemeGroupVersion.WithKind("VirtualRouter")),
-				},
-			},
-			RoleRef: rbac_v1.RoleRef{
-				APIGroup: rbac_v1.SchemeGroupVersion.Group,
-				Kind:     "Role",
-				Name:     ROLE_NAME,
-			},
-			Subjects: []rbac_v1.Subject{
-				{
-					Kind: "ServiceAccount",
-					Name: SERVICE_ACCOUNT_NAME,
-				},
-			},
-		}, metav1.CreateOptions{})
-		if err != nil {
-			klog.Error(err)
-			return err
+func removeString(slice []string, s string) (result []string) {
+	for _, item := range slice {
+		if item == s {
+			continue
 		}
+		result = append(result, item)
 	}
-	return nil
+	return
+}
+
+// virtualRouterNamespace returns the namespace generated for the
+// VirtualRouter. Once chosen, the namespace is recorded in the status and
+// reused. VirtualRouters created before the status was recorded keep the
+// namespace named after the VirtualRouter if they own it.
+func (c *Controller) virtualRouterNamespace(virtualRouter *samplev1alpha1.VirtualRouter) (string, error) {
+	if virtualRouter.Status.Namespace != "" {
+		return virtualRouter.Status.Namespace, nil
+	}
+
+	legacyNS, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), virtualRouter.Name, metav1.GetOptions{})
+	if err == nil && metav1.IsControlledBy(legacyNS, virtualRouter) {
+		return legacyNS.Name, nil
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return "", err
+	}
+
+	// <crNamespace>-<crName> is ambiguous, e.g. for a/b-c and a-b/c, so the
+	// hashed name is used when another VirtualRouter already got it
+	name := generatedNamespaceName(virtualRouter)
+	ns, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), name, metav1.GetOptions{})
+	if err == nil && !ownsNamespace(ns, virtualRouter) && generatedForVirtualRouter(ns) {
+		return hashedNamespaceName(virtualRouter), nil
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return "", err
+	}
+	return name, nil
+}
+
+// generatedNamespaceName returns <crNamespace>-<crName>. Names which are not
+// valid namespace names are shortened and suffixed with a hash of the
+// VirtualRouter namespace and name.
+func generatedNamespaceName(virtualRouter *samplev1alpha1.VirtualRouter) string {
+	name := strings.ReplaceAll(virtualRouter.Namespace+"-"+virtualRouter.Name, ".", "-")
+	if len(name) <= validation.DNS1123LabelMaxLength {
+		return name
+	}
+	return hashedNamespaceName(virtualRouter)
+}
+
+// hashedNamespaceName returns <crNamespace>-<crName>, shortened if needed,
+// suffixed with a hash of the VirtualRouter namespace and name.
+func hashedNamespaceName(virtualRouter *samplev1alpha1.VirtualRouter) string {
+	name := strings.ReplaceAll(virtualRouter.Namespace+"-"+virtualRouter.Name, ".", "-")
+	hash := sha256.Sum256([]byte(virtualRouter.Namespace + "/" + virtualRouter.Name))
+	suffix := hex.EncodeToString(hash[:])[:8]
+	if maxLength := validation.DNS1123LabelMaxLength - len(suffix) - 1; len(name) > maxLength {
+		name = name[:maxLength]
+	}
+	return strings.TrimRight(name, "-") + "-" + suffix
+}
+
+// ownerLabels returns the labels identifying the VirtualRouter which owns a
+// generated resource.
+func ownerLabels(virtualRouter *samplev1alpha1.VirtualRouter) map[string]string {
+	return map[string]string{
+		VIRTUALROUTER_NAMESPACE_LABEL: virtualRouter.Namespace,
+		VIRTUALROUTER_NAME_LABEL:      virtualRouter.Name,
+	}
+}
+
+// ownsNamespace reports whether ns was generated for the VirtualRouter, either
+// by its ownership labels or by its controller reference.
+func ownsNamespace(ns *corev1.Namespace, virtualRouter *samplev1alpha1.VirtualRouter) bool {
+	if metav1.IsControlledBy(ns, virtualRouter) {
+		return true
+	}
+	labels := ns.GetLabels()
+	return labels[VIRTUALROUTER_NAMESPACE_LABEL] == virtualRouter.Namespace && labels[VIRTUALROUTER_NAME_LABEL] == virtualRouter.Name
+}
+
+// generatedForVirtualRouter reports whether ns was generated for any
+// VirtualRouter.
+func generatedForVirtualRouter(ns *corev1.Namespace) bool {
+	if ownerRef := metav1.GetControllerOf(ns); ownerRef != nil && ownerRef.Kind == "VirtualRouter" {
+		return true
+	}
+	_, ok := ns.GetLabels()[VIRTUALROUTER_NAME_LABEL]
+	return ok
 }
 
+// ensureVirtualRouterNamespace creates the generated namespace, or adopts it
+// if it already exists and belongs to the VirtualRouter. A namespace which
+// belongs to someone else is never used.
 func (c *Controller) ensureVirtualRouterNamespace(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) error {
-	_, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), newNS, metav1.GetOptions{})
-	// RbacV1().ClusterRoles().Get(context.TODO(), clusterRoleName, metav1.GetOptions{})
+	ns, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), newNS, metav1.GetOptions{})
 	if err != nil {
 		if !errors.IsNotFound(err) {
 			klog.Error(err)
@@ -600,7 +1150,8 @@ func (c *Controller) ensureVirtualRouterNamespace(newNS string, virtualRouter *s
 		}
 		_, err := c.kubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: newNS,
+				Name:   newNS,
+				Labels: ownerLabels(virtualRouter),
 				OwnerReferences: []metav1.OwnerReference{
 					*metav1.NewControllerRef(virtualRouter, samplev1alpha1.SchemeGroupVersion.WithKind("VirtualRouter")),
 				},
@@ -610,6 +1161,30 @@ func (c *Controller) ensureVirtualRouterNamespace(newNS string, virtualRouter *s
 			klog.Error(err)
 			return err
 		}
+		return nil
+	}
+
+	if !ownsNamespace(ns, virtualRouter) {
+		msg := fmt.Sprintf(MessageResourceExists, newNS)
+		c.recorder.Event(virtualRouter, corev1.EventTypeWarning, ErrResourceExists, msg)
+		return fmt.Errorf(msg)
+	}
+
+	// Adopt the namespace: make sure it carries the ownership labels and a
+	// controller reference to this VirtualRouter.
+	nsCopy := ns.DeepCopy()
+	nsCopy.Labels = mergeStringMap(nsCopy.Labels, ownerLabels(virtualRouter))
+	if !metav1.IsControlledBy(nsCopy, virtualRouter) {
+		nsCopy.OwnerReferences = []metav1.OwnerReference{
+			*metav1.NewControllerRef(virtualRouter, samplev1alpha1.SchemeGroupVersion.WithKind("VirtualRouter")),
+		}
+	}
+	if equality.Semantic.DeepEqual(ns, nsCopy) {
+		return nil
+	}
+	if _, err := c.kubeclientset.CoreV1().Namespaces().Update(context.TODO(), nsCopy, metav1.UpdateOptions{}); err != nil {
+		klog.Error(err)
+		return err
 	}
 	return nil
 }
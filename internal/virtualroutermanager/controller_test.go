@@ -19,14 +19,21 @@ package virtualroutermanager
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	kubeinformers "k8s.io/client-go/informers"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
@@ -51,6 +58,10 @@ type fixture struct {
 	// Objects to put in the store.
 	virtualRouterLister []*networkcontroller.VirtualRouter
 	deploymentLister    []*apps.Deployment
+	ipPoolLister        []*networkcontroller.IPPool
+	vlanPoolLister      []*networkcontroller.VlanPool
+	// Namespaces whose VirtualRouters are reconciled, all of them when empty.
+	watchNamespaces []string
 	// Actions expected to happen on the client.
 	kubeactions []core.Action
 	actions     []core.Action
@@ -67,18 +78,23 @@ func newFixture(t *testing.T) *fixture {
 	return f
 }
 
+// newVirtualRouter returns a VirtualRouter whose generated namespace has
+// already been recorded in its status.
 func newVirtualRouter(name string, replicas *int32) *networkcontroller.VirtualRouter {
-	return &networkcontroller.VirtualRouter{
+	virtualRouter := &networkcontroller.VirtualRouter{
 		TypeMeta: metav1.TypeMeta{APIVersion: networkcontroller.SchemeGroupVersion.String()},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: metav1.NamespaceDefault,
+			Name:       name,
+			Namespace:  metav1.NamespaceDefault,
+			Finalizers: []string{VIRTUALROUTER_FINALIZER},
 		},
 		Spec: networkcontroller.VirtualRouterSpec{
 			DeploymentName: fmt.Sprintf("%s-deployment", name),
 			Replicas:       replicas,
 		},
 	}
+	virtualRouter.Status.Namespace = generatedNamespaceName(virtualRouter)
+	return virtualRouter
 }
 
 func (f *fixture) newController() (*Controller, informers.SharedInformerFactory, kubeinformers.SharedInformerFactory) {
@@ -89,20 +105,54 @@ func (f *fixture) newController() (*Controller, informers.SharedInformerFactory,
 	k8sI := kubeinformers.NewSharedInformerFactory(f.kubeclient, noResyncPeriodFunc())
 
 	c := NewController(f.kubeclient, f.client,
-		k8sI.Apps().V1().Deployments(), i.Tmax().V1().VirtualRouters())
+		k8sI.Apps().V1().Deployments(), k8sI.Apps().V1().StatefulSets(), k8sI.Policy().V1beta1().PodDisruptionBudgets(),
+		k8sI.Core().V1().ServiceAccounts(), k8sI.Rbac().V1().Roles(), k8sI.Rbac().V1().RoleBindings(), i.Tmax().V1().VirtualRouters(), i.Tmax().V1().IPPools(), i.Tmax().V1().VlanPools())
 
 	c.virtualRoutersSynced = alwaysReady
 	c.deploymentsSynced = alwaysReady
+	c.statefulSetsSynced = alwaysReady
+	c.pdbSynced = alwaysReady
+	c.saSynced = alwaysReady
+	c.rolesSynced = alwaysReady
+	c.roleBindingsSynced = alwaysReady
+	c.ipPoolsSynced = alwaysReady
+	c.vlanPoolsSynced = alwaysReady
 	c.recorder = &record.FakeRecorder{}
+	c.SetWatchNamespaces(f.watchNamespaces)
 
 	for _, f := range f.virtualRouterLister {
 		i.Tmax().V1().VirtualRouters().Informer().GetIndexer().Add(f)
 	}
 
+	for _, p := range f.ipPoolLister {
+		i.Tmax().V1().IPPools().Informer().GetIndexer().Add(p)
+	}
+
+	for _, p := range f.vlanPoolLister {
+		i.Tmax().V1().VlanPools().Informer().GetIndexer().Add(p)
+	}
+
 	for _, d := range f.deploymentLister {
 		k8sI.Apps().V1().Deployments().Informer().GetIndexer().Add(d)
 	}
 
+	// The generated StatefulSets, PodDisruptionBudgets and RBAC objects are
+	// looked up in the listers
+	for _, obj := range f.kubeobjects {
+		switch obj := obj.(type) {
+		case *apps.StatefulSet:
+			k8sI.Apps().V1().StatefulSets().Informer().GetIndexer().Add(obj)
+		case *policyv1beta1.PodDisruptionBudget:
+			k8sI.Policy().V1beta1().PodDisruptionBudgets().Informer().GetIndexer().Add(obj)
+		case *corev1.ServiceAccount:
+			k8sI.Core().V1().ServiceAccounts().Informer().GetIndexer().Add(obj)
+		case *rbacv1.Role:
+			k8sI.Rbac().V1().Roles().Informer().GetIndexer().Add(obj)
+		case *rbacv1.RoleBinding:
+			k8sI.Rbac().V1().RoleBindings().Informer().GetIndexer().Add(obj)
+		}
+	}
+
 	return c, i, k8sI
 }
 
@@ -175,6 +225,18 @@ func checkAction(expected, actual core.Action, t *testing.T) {
 	}
 
 	switch a := actual.(type) {
+	case core.GetActionImpl:
+		e, _ := expected.(core.GetActionImpl)
+		if e.GetName() != a.GetName() {
+			t.Errorf("Action %s %s has wrong name. Expected: %s. Got: %s",
+				a.GetVerb(), a.GetResource().Resource, e.GetName(), a.GetName())
+		}
+	case core.DeleteActionImpl:
+		e, _ := expected.(core.DeleteActionImpl)
+		if e.GetName() != a.GetName() {
+			t.Errorf("Action %s %s has wrong name. Expected: %s. Got: %s",
+				a.GetVerb(), a.GetResource().Resource, e.GetName(), a.GetName())
+		}
 	case core.CreateActionImpl:
 		e, _ := expected.(core.CreateActionImpl)
 		expObject := e.GetObject()
@@ -186,8 +248,8 @@ func checkAction(expected, actual core.Action, t *testing.T) {
 		}
 	case core.UpdateActionImpl:
 		e, _ := expected.(core.UpdateActionImpl)
-		expObject := e.GetObject()
-		object := a.GetObject()
+		expObject := clearTransitionTimes(e.GetObject())
+		object := clearTransitionTimes(a.GetObject())
 
 		if !reflect.DeepEqual(expObject, object) {
 			t.Errorf("Action %s %s has wrong object\nDiff:\n %s",
@@ -208,6 +270,20 @@ func checkAction(expected, actual core.Action, t *testing.T) {
 	}
 }
 
+// clearTransitionTimes returns a copy of a VirtualRouter without the condition
+// transition times, which depend on the time the test ran.
+func clearTransitionTimes(obj runtime.Object) runtime.Object {
+	virtualRouter, ok := obj.(*networkcontroller.VirtualRouter)
+	if !ok {
+		return obj
+	}
+	virtualRouter = virtualRouter.DeepCopy()
+	for i := range virtualRouter.Status.Conditions {
+		virtualRouter.Status.Conditions[i].LastTransitionTime = metav1.Time{}
+	}
+	return virtualRouter
+}
+
 // filterInformerActions filters list and watch actions for testing resources.
 // Since list and watch don't change resource state we can filter it to lower
 // nose level in our tests.
@@ -215,10 +291,18 @@ func filterInformerActions(actions []core.Action) []core.Action {
 	ret := []core.Action{}
 	for _, action := range actions {
 		if len(action.GetNamespace()) == 0 &&
-			(action.Matches("list", "virtualRouters") ||
-				action.Matches("watch", "virtualRouters") ||
+			(action.Matches("list", "virtualrouters") ||
+				action.Matches("watch", "virtualrouters") ||
+				action.Matches("list", "ippools") ||
+				action.Matches("watch", "ippools") ||
+				action.Matches("list", "vlanpools") ||
+				action.Matches("watch", "vlanpools") ||
 				action.Matches("list", "deployments") ||
-				action.Matches("watch", "deployments")) {
+				action.Matches("watch", "deployments") ||
+				action.Matches("list", "statefulsets") ||
+				action.Matches("watch", "statefulsets") ||
+				action.Matches("list", "poddisruptionbudgets") ||
+				action.Matches("watch", "poddisruptionbudgets")) {
 			continue
 		}
 		ret = append(ret, action)
@@ -235,13 +319,69 @@ func (f *fixture) expectUpdateDeploymentAction(d *apps.Deployment) {
 	f.kubeactions = append(f.kubeactions, core.NewUpdateAction(schema.GroupVersionResource{Resource: "deployments"}, d.Namespace, d))
 }
 
+func (f *fixture) expectCreateStatefulSetAction(s *apps.StatefulSet) {
+	f.kubeactions = append(f.kubeactions, core.NewCreateAction(schema.GroupVersionResource{Resource: "statefulsets"}, s.Namespace, s))
+}
+
+func (f *fixture) expectUpdateStatefulSetAction(s *apps.StatefulSet) {
+	f.kubeactions = append(f.kubeactions, core.NewUpdateAction(schema.GroupVersionResource{Resource: "statefulsets"}, s.Namespace, s))
+}
+
+// expectGetGeneratedResourcesActions expects the lookups done by the ensure
+// functions for resources which already exist in the generated namespace.
+// The other generated resources are looked up in the listers.
+func (f *fixture) expectGetGeneratedResourcesActions(newNS string) {
+	f.kubeactions = append(f.kubeactions,
+		core.NewRootGetAction(schema.GroupVersionResource{Resource: "namespaces"}, newNS),
+	)
+}
+
+func (f *fixture) expectUpdateVirtualRouterAction(virtualRouter *networkcontroller.VirtualRouter) {
+	f.actions = append(f.actions, core.NewUpdateAction(schema.GroupVersionResource{Resource: "virtualrouters"}, virtualRouter.Namespace, virtualRouter))
+}
+
 func (f *fixture) expectUpdateVirtualRouterStatusAction(virtualRouter *networkcontroller.VirtualRouter) {
-	action := core.NewUpdateAction(schema.GroupVersionResource{Resource: "virtualRouters"}, virtualRouter.Namespace, virtualRouter)
-	// TODO: Until #38113 is merged, we can't use Subresource
-	//action.Subresource = "status"
+	action := core.NewUpdateSubresourceAction(schema.GroupVersionResource{Resource: "virtualrouters"}, "status", virtualRouter.Namespace, virtualRouter)
 	f.actions = append(f.actions, action)
 }
 
+// newGeneratedResources returns the namespace and RBAC objects which the
+// manager creates before the router Deployment.
+func newGeneratedResources(virtualRouter *networkcontroller.VirtualRouter) []runtime.Object {
+	newNS := virtualRouter.Status.Namespace
+	return []runtime.Object{
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   newNS,
+				Labels: ownerLabels(virtualRouter),
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(virtualRouter, networkcontroller.SchemeGroupVersion.WithKind("VirtualRouter")),
+				},
+			},
+		},
+		newServiceAccount(newNS, virtualRouter),
+		newRole(newNS, virtualRouter, DefaultRouterRoleConfig().Rules),
+		newRoleBinding(newNS, virtualRouter),
+		newPodDisruptionBudget(newNS, virtualRouter),
+	}
+}
+
+// withStatus returns a copy of virtualRouter with the status the manager is
+// expected to write once the given deployment is in place.
+func withStatus(virtualRouter *networkcontroller.VirtualRouter, d *apps.Deployment) *networkcontroller.VirtualRouter {
+	virtualRouter = virtualRouter.DeepCopy()
+	virtualRouter.Status.AvailableReplicas = d.Status.AvailableReplicas
+	virtualRouter.Status.Conditions = []metav1.Condition{
+		newCondition(networkcontroller.ConditionNamespaceReady, metav1.ConditionTrue, ReasonNamespaceCreated, ""),
+		newCondition(networkcontroller.ConditionRBACReady, metav1.ConditionTrue, ReasonRBACCreated, ""),
+		deploymentAvailableCondition(d),
+		newCondition(networkcontroller.ConditionDisruptionBudgetReady, metav1.ConditionTrue, ReasonDisruptionBudgetCreated, ""),
+		newCondition(networkcontroller.ConditionNetworkAttached, metav1.ConditionUnknown, ReasonWaitingForDaemon, MessageWaitingForDaemon),
+	}
+	virtualRouter.Status.Conditions = append(virtualRouter.Status.Conditions, degradedCondition(virtualRouter.Status.Conditions))
+	return virtualRouter
+}
+
 func getKey(virtualRouter *networkcontroller.VirtualRouter, t *testing.T) string {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(virtualRouter)
 	if err != nil {
@@ -258,10 +398,12 @@ func TestCreatesDeployment(t *testing.T) {
 	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
 	f.objects = append(f.objects, virtualRouter)
 
-	newNS := virtualRouter.Name
+	newNS := virtualRouter.Status.Namespace
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
 	expDeployment := newDeployment(newNS, virtualRouter)
+	f.expectGetGeneratedResourcesActions(newNS)
 	f.expectCreateDeploymentAction(expDeployment)
-	f.expectUpdateVirtualRouterStatusAction(virtualRouter)
+	f.expectUpdateVirtualRouterStatusAction(withStatus(virtualRouter, expDeployment))
 
 	f.run(getKey(virtualRouter, t))
 }
@@ -269,22 +411,42 @@ func TestCreatesDeployment(t *testing.T) {
 func TestDoNothing(t *testing.T) {
 	f := newFixture(t)
 	virtualRouter := newVirtualRouter("test", int32Ptr(1))
-	newNS := virtualRouter.Name
+	newNS := virtualRouter.Status.Namespace
 	d := newDeployment(newNS, virtualRouter)
 
 	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
 	f.objects = append(f.objects, virtualRouter)
 	f.deploymentLister = append(f.deploymentLister, d)
 	f.kubeobjects = append(f.kubeobjects, d)
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
 
-	f.expectUpdateVirtualRouterStatusAction(virtualRouter)
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.expectUpdateVirtualRouterStatusAction(withStatus(virtualRouter, d))
+	f.run(getKey(virtualRouter, t))
+}
+
+func TestDoNothingWhenStatusUpToDate(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	newNS := virtualRouter.Status.Namespace
+	d := newDeployment(newNS, virtualRouter)
+	d.Status.AvailableReplicas = 1
+	virtualRouter = withStatus(virtualRouter, d)
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	f.deploymentLister = append(f.deploymentLister, d)
+	f.kubeobjects = append(f.kubeobjects, d)
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
+
+	f.expectGetGeneratedResourcesActions(newNS)
 	f.run(getKey(virtualRouter, t))
 }
 
 func TestUpdateDeployment(t *testing.T) {
 	f := newFixture(t)
 	virtualRouter := newVirtualRouter("test", int32Ptr(1))
-	newNS := virtualRouter.Name
+	newNS := virtualRouter.Status.Namespace
 	d := newDeployment(newNS, virtualRouter)
 
 	// Update replicas
@@ -295,16 +457,439 @@ func TestUpdateDeployment(t *testing.T) {
 	f.objects = append(f.objects, virtualRouter)
 	f.deploymentLister = append(f.deploymentLister, d)
 	f.kubeobjects = append(f.kubeobjects, d)
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
 
-	f.expectUpdateVirtualRouterStatusAction(virtualRouter)
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.expectUpdateVirtualRouterStatusAction(withStatus(virtualRouter, expDeployment))
 	f.expectUpdateDeploymentAction(expDeployment)
 	f.run(getKey(virtualRouter, t))
 }
 
+// runDeploymentDrift syncs a VirtualRouter whose live Deployment was built
+// from base and expects the Deployment to be updated to the one generated
+// from virtualRouter.
+func runDeploymentDrift(t *testing.T, virtualRouter *networkcontroller.VirtualRouter, live *apps.Deployment) {
+	f := newFixture(t)
+	newNS := virtualRouter.Status.Namespace
+	desired := newDeployment(newNS, virtualRouter)
+	expDeployment := mergeDeployment(live, desired)
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	f.deploymentLister = append(f.deploymentLister, live)
+	f.kubeobjects = append(f.kubeobjects, live)
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
+
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.expectUpdateVirtualRouterStatusAction(withStatus(virtualRouter, expDeployment))
+	f.expectUpdateDeploymentAction(expDeployment)
+	f.run(getKey(virtualRouter, t))
+}
+
+func TestUpdateDeploymentImage(t *testing.T) {
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Spec.Image = "tmaxcloudck/virtualrouter:v0.1.0"
+	d := newDeployment(virtualRouter.Status.Namespace, virtualRouter)
+
+	virtualRouter.Spec.Image = "tmaxcloudck/virtualrouter:v0.2.0"
+	expDeployment := mergeDeployment(d, newDeployment(virtualRouter.Status.Namespace, virtualRouter))
+	if image := expDeployment.Spec.Template.Spec.Containers[0].Image; image != virtualRouter.Spec.Image {
+		t.Errorf("expected image %q, got %q", virtualRouter.Spec.Image, image)
+	}
+	runDeploymentDrift(t, virtualRouter, d)
+}
+
+func TestUpdateDeploymentNodeSelector(t *testing.T) {
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	d := newDeployment(virtualRouter.Status.Namespace, virtualRouter)
+
+	virtualRouter.Spec.NodeSelector = []networkcontroller.NodeSelector{{Key: "virtualrouter/node", Value: "true"}}
+	expDeployment := mergeDeployment(d, newDeployment(virtualRouter.Status.Namespace, virtualRouter))
+	if !reflect.DeepEqual(expDeployment.Spec.Template.Spec.NodeSelector, map[string]string{"virtualrouter/node": "true"}) {
+		t.Errorf("unexpected nodeSelector %v", expDeployment.Spec.Template.Spec.NodeSelector)
+	}
+	runDeploymentDrift(t, virtualRouter, d)
+}
+
+func TestUpdateDeploymentAffinity(t *testing.T) {
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	d := newDeployment(virtualRouter.Status.Namespace, virtualRouter)
+
+	virtualRouter.Spec.Affinity = corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{{
+						Key:      "virtualrouter/daemon",
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   []string{"deploy"},
+					}},
+				}},
+			},
+		},
+	}
+	runDeploymentDrift(t, virtualRouter, d)
+}
+
+func TestUpdateDeploymentPodSettings(t *testing.T) {
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	d := newDeployment(virtualRouter.Status.Namespace, virtualRouter)
+	if container := d.Spec.Template.Spec.Containers[0]; container.ImagePullPolicy != corev1.PullAlways || !*container.SecurityContext.Privileged {
+		t.Errorf("expected a privileged container pulled Always by default, got %v %v", container.ImagePullPolicy, *container.SecurityContext.Privileged)
+	}
+
+	virtualRouter.Spec.Resources = corev1.ResourceRequirements{
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+	}
+	virtualRouter.Spec.Tolerations = []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "router", Effect: corev1.TaintEffectNoSchedule}}
+	virtualRouter.Spec.PriorityClassName = "system-cluster-critical"
+	virtualRouter.Spec.ImagePullPolicy = corev1.PullIfNotPresent
+	virtualRouter.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "registry"}}
+	virtualRouter.Spec.Privileged = boolPtr(false)
+
+	expDeployment := mergeDeployment(d, newDeployment(virtualRouter.Status.Namespace, virtualRouter))
+	podSpec := expDeployment.Spec.Template.Spec
+	if !reflect.DeepEqual(podSpec.Tolerations, virtualRouter.Spec.Tolerations) || podSpec.PriorityClassName != "system-cluster-critical" ||
+		!reflect.DeepEqual(podSpec.ImagePullSecrets, virtualRouter.Spec.ImagePullSecrets) {
+		t.Errorf("pod settings were not propagated: %#v", podSpec)
+	}
+	container := podSpec.Containers[0]
+	if !reflect.DeepEqual(container.Resources, virtualRouter.Spec.Resources) || container.ImagePullPolicy != corev1.PullIfNotPresent || *container.SecurityContext.Privileged {
+		t.Errorf("container settings were not propagated: %#v", container)
+	}
+	runDeploymentDrift(t, virtualRouter, d)
+}
+
+const testPodTemplateOverlay = `{
+	"metadata": {"labels": {"app": "other", "team": "network"}},
+	"spec": {
+		"serviceAccountName": "other",
+		"dnsPolicy": "None",
+		"dnsConfig": {"nameservers": ["10.10.10.1"]},
+		"containers": [
+			{"name": "test", "image": "busybox", "env": [{"name": "LOG_LEVEL", "value": "debug"}]},
+			{"name": "exporter", "image": "tmaxcloudck/exporter:v0.1.0", "livenessProbe": {"httpGet": {"path": "/metrics", "port": 9100}}}
+		],
+		"volumes": [{"name": "config", "configMap": {"name": "router-config"}}]
+	}
+}`
+
+func TestPodTemplateOverlay(t *testing.T) {
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Spec.Image = "tmaxcloudck/virtualrouter:v0.1.0"
+	virtualRouter.Spec.PodTemplateOverlay = &runtime.RawExtension{Raw: []byte(testPodTemplateOverlay)}
+	template := newDeployment(virtualRouter.Status.Namespace, virtualRouter).Spec.Template
+
+	if template.Labels["app"] != VIRTUALROUTER_LABEL || template.Labels["team"] != "network" {
+		t.Errorf("unexpected labels %v", template.Labels)
+	}
+	if template.Spec.ServiceAccountName != SERVICE_ACCOUNT_NAME || template.Spec.DNSPolicy != corev1.DNSNone || len(template.Spec.Volumes) != 1 {
+		t.Errorf("unexpected pod spec %#v", template.Spec)
+	}
+	if len(template.Spec.Containers) != 2 || template.Spec.Containers[1].Name != "exporter" {
+		t.Fatalf("expected the exporter sidecar, got %#v", template.Spec.Containers)
+	}
+	router := template.Spec.Containers[0]
+	if router.Image != virtualRouter.Spec.Image || len(router.Env) != 2 || !containsString(template.Finalizers, VIRTUALROUTER_DAEMON_FINALIZER) {
+		t.Errorf("guarded fields were changed: %#v", router)
+	}
+}
+
+func TestPodTemplateOverlayIgnoresDefaultedFields(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Spec.PodTemplateOverlay = &runtime.RawExtension{Raw: []byte(testPodTemplateOverlay)}
+	newNS := virtualRouter.Status.Namespace
+	d := newDeployment(newNS, virtualRouter)
+
+	// Defaulted by the API server
+	defaultMode := corev1.ConfigMapVolumeSourceDefaultMode
+	d.Spec.Template.Spec.Volumes[0].ConfigMap.DefaultMode = &defaultMode
+	exporter := findContainer(d.Spec.Template.Spec.Containers, "exporter")
+	exporter.TerminationMessagePath = corev1.TerminationMessagePathDefault
+	exporter.ImagePullPolicy = corev1.PullIfNotPresent
+	exporter.LivenessProbe.HTTPGet.Scheme = corev1.URISchemeHTTP
+	exporter.LivenessProbe.TimeoutSeconds = 1
+	exporter.LivenessProbe.PeriodSeconds = 10
+	exporter.LivenessProbe.SuccessThreshold = 1
+	exporter.LivenessProbe.FailureThreshold = 3
+	d.Spec.Template.Spec.DNSConfig.Options = []corev1.PodDNSConfigOption{}
+	d.Spec.Template.Spec.SchedulerName = corev1.DefaultSchedulerName
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	f.deploymentLister = append(f.deploymentLister, d)
+	f.kubeobjects = append(f.kubeobjects, d)
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
+
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.expectUpdateVirtualRouterStatusAction(withStatus(virtualRouter, d))
+	f.run(getKey(virtualRouter, t))
+}
+
+func TestUpdatePodTemplateOverlay(t *testing.T) {
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Spec.PodTemplateOverlay = &runtime.RawExtension{Raw: []byte(testPodTemplateOverlay)}
+	d := newDeployment(virtualRouter.Status.Namespace, virtualRouter)
+
+	virtualRouter.Spec.PodTemplateOverlay = &runtime.RawExtension{Raw: []byte(`{"spec":{"containers":[{"name":"exporter","image":"tmaxcloudck/exporter:v0.2.0"}]}}`)}
+	runDeploymentDrift(t, virtualRouter, d)
+}
+
+func TestRemovePodTemplateOverlayFields(t *testing.T) {
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Spec.PodTemplateOverlay = &runtime.RawExtension{Raw: []byte(testPodTemplateOverlay)}
+	d := newDeployment(virtualRouter.Status.Namespace, virtualRouter)
+
+	// Only the exporter sidecar is left in the overlay
+	virtualRouter.Spec.PodTemplateOverlay = &runtime.RawExtension{Raw: []byte(`{"spec":{"containers":[{"name":"exporter","image":"tmaxcloudck/exporter:v0.1.0"}]}}`)}
+	expDeployment := mergeDeployment(d, newDeployment(virtualRouter.Status.Namespace, virtualRouter))
+	template := expDeployment.Spec.Template
+	if len(template.Spec.Volumes) != 0 || template.Spec.DNSPolicy != "" || template.Spec.DNSConfig != nil || template.Labels["team"] != "" {
+		t.Errorf("expected the fields removed from the overlay to be removed, got %#v", template)
+	}
+	router, exporter := findContainer(template.Spec.Containers, "test"), findContainer(template.Spec.Containers, "exporter")
+	if len(template.Spec.Containers) != 2 || router == nil || exporter == nil || len(router.Env) != 1 {
+		t.Fatalf("expected the router container without the overlay env and the exporter sidecar, got %#v", template.Spec.Containers)
+	}
+	if hash := expDeployment.Annotations[POD_TEMPLATE_HASH_ANNOTATION]; hash == d.Annotations[POD_TEMPLATE_HASH_ANNOTATION] {
+		t.Errorf("expected the hash of the pod template to change, got %s", hash)
+	}
+	runDeploymentDrift(t, virtualRouter, d)
+}
+
+// runPodDisruptionBudgetSync syncs a VirtualRouter whose Deployment is up to
+// date and expects its PodDisruptionBudget to be created, or updated from live.
+func runPodDisruptionBudgetSync(t *testing.T, virtualRouter *networkcontroller.VirtualRouter, live *policyv1beta1.PodDisruptionBudget) {
+	f := newFixture(t)
+	newNS := virtualRouter.Status.Namespace
+	d := newDeployment(newNS, virtualRouter)
+	expPDB := newPodDisruptionBudget(newNS, virtualRouter)
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	f.deploymentLister = append(f.deploymentLister, d)
+	f.kubeobjects = append(f.kubeobjects, d)
+	// Every generated resource but the PodDisruptionBudget, which comes last
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)[:4]...)
+
+	f.expectGetGeneratedResourcesActions(newNS)
+	if live == nil {
+		f.kubeactions = append(f.kubeactions, core.NewCreateAction(schema.GroupVersionResource{Resource: "poddisruptionbudgets"}, newNS, expPDB))
+	} else {
+		f.kubeobjects = append(f.kubeobjects, live)
+		f.kubeactions = append(f.kubeactions, core.NewUpdateAction(schema.GroupVersionResource{Resource: "poddisruptionbudgets"}, newNS, expPDB))
+	}
+	f.expectUpdateVirtualRouterStatusAction(withStatus(virtualRouter, d))
+	f.run(getKey(virtualRouter, t))
+}
+
+func TestCreatesPodDisruptionBudget(t *testing.T) {
+	virtualRouter := newVirtualRouter("test", int32Ptr(2))
+	pdb := newPodDisruptionBudget(virtualRouter.Status.Namespace, virtualRouter)
+	if pdb.Spec.MaxUnavailable == nil || *pdb.Spec.MaxUnavailable != intstr.FromInt(1) || pdb.Spec.MinAvailable != nil {
+		t.Errorf("expected maxUnavailable 1 by default, got %#v", pdb.Spec)
+	}
+	runPodDisruptionBudgetSync(t, virtualRouter, nil)
+}
+
+func TestUpdatePodDisruptionBudgetWithReplicas(t *testing.T) {
+	minAvailable := intstr.FromInt(3)
+	virtualRouter := newVirtualRouter("test", int32Ptr(3))
+	virtualRouter.Spec.DisruptionBudget = &networkcontroller.DisruptionBudgetSpec{MinAvailable: &minAvailable}
+	live := newPodDisruptionBudget(virtualRouter.Status.Namespace, virtualRouter)
+	if *live.Spec.MinAvailable != intstr.FromInt(2) {
+		t.Errorf("expected minAvailable to be lowered to 2, got %s", live.Spec.MinAvailable.String())
+	}
+
+	// Scaling down lowers minAvailable again
+	virtualRouter.Spec.Replicas = int32Ptr(2)
+	if pdb := newPodDisruptionBudget(virtualRouter.Status.Namespace, virtualRouter); *pdb.Spec.MinAvailable != intstr.FromInt(1) {
+		t.Errorf("expected minAvailable to be lowered to 1, got %s", pdb.Spec.MinAvailable.String())
+	}
+	runPodDisruptionBudgetSync(t, virtualRouter, live)
+}
+
+func newStatefulSetVirtualRouter(replicas *int32) *networkcontroller.VirtualRouter {
+	virtualRouter := newVirtualRouter("test", replicas)
+	virtualRouter.Spec.WorkloadKind = networkcontroller.WorkloadKindStatefulSet
+	return virtualRouter
+}
+
+func TestCreatesStatefulSet(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newStatefulSetVirtualRouter(int32Ptr(2))
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+
+	newNS := virtualRouter.Status.Namespace
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
+	expStatefulSet := newStatefulSet(newNS, virtualRouter)
+	if expStatefulSet.Spec.PodManagementPolicy != apps.ParallelPodManagement || !reflect.DeepEqual(expStatefulSet.Spec.Template, newDeployment(newNS, virtualRouter).Spec.Template) {
+		t.Errorf("expected the pod template of the router Deployment started in parallel, got %#v", expStatefulSet.Spec)
+	}
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.expectCreateStatefulSetAction(expStatefulSet)
+	// No replica is ready yet, as for a new Deployment
+	f.expectUpdateVirtualRouterStatusAction(withStatus(virtualRouter, newDeployment(newNS, virtualRouter)))
+
+	f.run(getKey(virtualRouter, t))
+}
+
+func TestDeletesWorkloadOfPreviousKind(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(2))
+	newNS := virtualRouter.Status.Namespace
+	d := newDeployment(newNS, virtualRouter)
+
+	// workloadKind was changed without the webhooks
+	virtualRouter.Spec.WorkloadKind = networkcontroller.WorkloadKindStatefulSet
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	f.deploymentLister = append(f.deploymentLister, d)
+	f.kubeobjects = append(f.kubeobjects, d)
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
+	expStatefulSet := newStatefulSet(newNS, virtualRouter)
+
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.kubeactions = append(f.kubeactions, core.NewDeleteAction(schema.GroupVersionResource{Resource: "deployments"}, newNS, d.Name))
+	f.expectCreateStatefulSetAction(expStatefulSet)
+	f.expectUpdateVirtualRouterStatusAction(withStatus(virtualRouter, newDeployment(newNS, virtualRouter)))
+
+	f.run(getKey(virtualRouter, t))
+}
+
+func TestDeletesStatefulSetOfPreviousKind(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newStatefulSetVirtualRouter(int32Ptr(2))
+	newNS := virtualRouter.Status.Namespace
+	live := newStatefulSet(newNS, virtualRouter)
+
+	virtualRouter.Spec.WorkloadKind = networkcontroller.WorkloadKindDeployment
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	f.kubeobjects = append(f.kubeobjects, live)
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
+	expDeployment := newDeployment(newNS, virtualRouter)
+
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.kubeactions = append(f.kubeactions, core.NewDeleteAction(schema.GroupVersionResource{Resource: "statefulsets"}, newNS, live.Name))
+	f.expectCreateDeploymentAction(expDeployment)
+	f.expectUpdateVirtualRouterStatusAction(withStatus(virtualRouter, expDeployment))
+
+	f.run(getKey(virtualRouter, t))
+}
+
+func TestUpdateStatefulSet(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newStatefulSetVirtualRouter(int32Ptr(2))
+	virtualRouter.Spec.Image = "tmaxcloudck/virtualrouter:v0.2.0"
+	newNS := virtualRouter.Status.Namespace
+
+	live := newStatefulSet(newNS, virtualRouter)
+	live.Spec.Replicas = int32Ptr(1)
+	live.Spec.Template.Spec.Containers[0].Image = "tmaxcloudck/virtualrouter:v0.1.0"
+	live.Status.ReadyReplicas = 1
+	expStatefulSet := live.DeepCopy()
+	expStatefulSet.Spec.Replicas = int32Ptr(2)
+	expStatefulSet.Spec.Template.Spec.Containers[0].Image = virtualRouter.Spec.Image
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	f.kubeobjects = append(f.kubeobjects, live)
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
+
+	expStatus := virtualRouter.DeepCopy()
+	expStatus.Status.AvailableReplicas = 1
+	expStatus.Status.Conditions = []metav1.Condition{
+		newCondition(networkcontroller.ConditionNamespaceReady, metav1.ConditionTrue, ReasonNamespaceCreated, ""),
+		newCondition(networkcontroller.ConditionRBACReady, metav1.ConditionTrue, ReasonRBACCreated, ""),
+		statefulSetAvailableCondition(expStatefulSet),
+		newCondition(networkcontroller.ConditionDisruptionBudgetReady, metav1.ConditionTrue, ReasonDisruptionBudgetCreated, ""),
+		newCondition(networkcontroller.ConditionNetworkAttached, metav1.ConditionUnknown, ReasonWaitingForDaemon, MessageWaitingForDaemon),
+	}
+	expStatus.Status.Conditions = append(expStatus.Status.Conditions, degradedCondition(expStatus.Status.Conditions))
+
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.expectUpdateStatefulSetAction(expStatefulSet)
+	f.expectUpdateVirtualRouterStatusAction(expStatus)
+	f.run(getKey(virtualRouter, t))
+}
+
+func TestRevertManualDeploymentEdit(t *testing.T) {
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Spec.Image = "tmaxcloudck/virtualrouter:v0.1.0"
+	d := newDeployment(virtualRouter.Status.Namespace, virtualRouter)
+
+	// Someone edited the Deployment by hand
+	d.Spec.Replicas = int32Ptr(3)
+	d.Spec.Template.Spec.Containers[0].Image = "busybox"
+	d.Spec.Template.Spec.Containers = append(d.Spec.Template.Spec.Containers, corev1.Container{Name: "sidecar", Image: "busybox"})
+	d.Spec.Template.Finalizers = nil
+
+	expDeployment := mergeDeployment(d, newDeployment(virtualRouter.Status.Namespace, virtualRouter))
+	if !reflect.DeepEqual(expDeployment.Spec, newDeployment(virtualRouter.Status.Namespace, virtualRouter).Spec) {
+		t.Errorf("manual edits were not reverted\nDiff:\n %s", diff.ObjectGoPrintSideBySide(newDeployment(virtualRouter.Status.Namespace, virtualRouter).Spec, expDeployment.Spec))
+	}
+	runDeploymentDrift(t, virtualRouter, d)
+}
+
+func TestIgnoreDefaultedDeploymentFields(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	newNS := virtualRouter.Status.Namespace
+	d := newDeployment(newNS, virtualRouter)
+
+	// Fields defaulted by the API server or set by other actors must not be
+	// reported as drift.
+	d.Spec.Strategy.Type = apps.RollingUpdateDeploymentStrategyType
+	d.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyAlways
+	d.Spec.Template.Spec.Containers[0].TerminationMessagePath = corev1.TerminationMessagePathDefault
+	d.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] = "2021-11-01T00:00:00Z"
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	f.deploymentLister = append(f.deploymentLister, d)
+	f.kubeobjects = append(f.kubeobjects, d)
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
+
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.expectUpdateVirtualRouterStatusAction(withStatus(virtualRouter, d))
+	f.run(getKey(virtualRouter, t))
+}
+
+func TestLabelsDeploymentMissingFromInformer(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	newNS := virtualRouter.Status.Namespace
+	expDeployment := newDeployment(newNS, virtualRouter)
+
+	// Created before the generated objects were labelled, the Deployment is
+	// not cached by the informer filtered on the owner labels
+	d := expDeployment.DeepCopy()
+	d.Labels = nil
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	f.kubeobjects = append(f.kubeobjects, d)
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
+
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.expectCreateDeploymentAction(expDeployment)
+	f.kubeactions = append(f.kubeactions, core.NewGetAction(schema.GroupVersionResource{Resource: "deployments"}, newNS, d.Name))
+	f.expectUpdateDeploymentAction(expDeployment)
+	f.expectUpdateVirtualRouterStatusAction(withStatus(virtualRouter, expDeployment))
+	f.run(getKey(virtualRouter, t))
+}
+
 func TestNotControlledByUs(t *testing.T) {
 	f := newFixture(t)
 	virtualRouter := newVirtualRouter("test", int32Ptr(1))
-	newNS := virtualRouter.Name
+	newNS := virtualRouter.Status.Namespace
 	d := newDeployment(newNS, virtualRouter)
 
 	d.ObjectMeta.OwnerReferences = []metav1.OwnerReference{}
@@ -313,8 +898,244 @@ func TestNotControlledByUs(t *testing.T) {
 	f.objects = append(f.objects, virtualRouter)
 	f.deploymentLister = append(f.deploymentLister, d)
 	f.kubeobjects = append(f.kubeobjects, d)
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
+
+	expVirtualRouter := virtualRouter.DeepCopy()
+	expVirtualRouter.Status.Conditions = []metav1.Condition{
+		newCondition(networkcontroller.ConditionNamespaceReady, metav1.ConditionTrue, ReasonNamespaceCreated, ""),
+		newCondition(networkcontroller.ConditionRBACReady, metav1.ConditionTrue, ReasonRBACCreated, ""),
+		newCondition(networkcontroller.ConditionDeploymentAvailable, metav1.ConditionFalse, ReasonDeploymentNotOwned, fmt.Sprintf(MessageResourceExists, d.Name)),
+		newCondition(networkcontroller.ConditionNetworkAttached, metav1.ConditionUnknown, ReasonWaitingForDaemon, MessageWaitingForDaemon),
+		newCondition(networkcontroller.ConditionDegraded, metav1.ConditionTrue, ReasonDeploymentNotOwned, fmt.Sprintf("%s: %s", networkcontroller.ConditionDeploymentAvailable, fmt.Sprintf(MessageResourceExists, d.Name))),
+	}
+
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.expectUpdateVirtualRouterStatusAction(expVirtualRouter)
+	f.runExpectError(getKey(virtualRouter, t))
+}
+
+func TestAddsFinalizer(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Finalizers = nil
+	newNS := virtualRouter.Status.Namespace
+	d := newDeployment(newNS, virtualRouter)
+	d.Status.AvailableReplicas = 1
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	f.deploymentLister = append(f.deploymentLister, d)
+	f.kubeobjects = append(f.kubeobjects, d)
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
+
+	expVirtualRouter := virtualRouter.DeepCopy()
+	expVirtualRouter.Finalizers = []string{VIRTUALROUTER_FINALIZER}
+	f.expectUpdateVirtualRouterAction(expVirtualRouter)
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.expectUpdateVirtualRouterStatusAction(withStatus(expVirtualRouter, d))
+	f.run(getKey(virtualRouter, t))
+}
 
+func TestGeneratedNamespaceName(t *testing.T) {
+	a := newVirtualRouter("router", nil)
+	b := newVirtualRouter("router", nil)
+	b.Namespace = "team-b"
+	if generatedNamespaceName(a) == generatedNamespaceName(b) {
+		t.Errorf("VirtualRouters in different namespaces share namespace %q", generatedNamespaceName(a))
+	}
+
+	long := newVirtualRouter(strings.Repeat("r", 60), nil)
+	long.Namespace = strings.Repeat("n", 60)
+	other := long.DeepCopy()
+	other.Name = strings.Repeat("r", 59) + "s"
+	name := generatedNamespaceName(long)
+	if errs := validation.IsDNS1123Label(name); len(errs) != 0 {
+		t.Errorf("invalid namespace name %q: %v", name, errs)
+	}
+	if name != generatedNamespaceName(long.DeepCopy()) {
+		t.Errorf("namespace name is not stable")
+	}
+	if name == generatedNamespaceName(other) {
+		t.Errorf("truncated namespace names collide: %q", name)
+	}
+}
+
+func TestRefusesForeignNamespace(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	newNS := virtualRouter.Status.Namespace
+	virtualRouter.Status.Namespace = ""
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	f.kubeobjects = append(f.kubeobjects, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: newNS}})
+
+	msg := fmt.Sprintf(MessageResourceExists, newNS)
+	expVirtualRouter := virtualRouter.DeepCopy()
+	expVirtualRouter.Status.Namespace = newNS
+	expVirtualRouter.Status.Conditions = []metav1.Condition{
+		newCondition(networkcontroller.ConditionNamespaceReady, metav1.ConditionFalse, ReasonNamespaceFailed, msg),
+		newCondition(networkcontroller.ConditionNetworkAttached, metav1.ConditionUnknown, ReasonWaitingForDaemon, MessageWaitingForDaemon),
+		newCondition(networkcontroller.ConditionDegraded, metav1.ConditionTrue, ReasonNamespaceFailed, fmt.Sprintf("%s: %s", networkcontroller.ConditionNamespaceReady, msg)),
+	}
+
+	f.kubeactions = append(f.kubeactions,
+		core.NewRootGetAction(schema.GroupVersionResource{Resource: "namespaces"}, virtualRouter.Name),
+		core.NewRootGetAction(schema.GroupVersionResource{Resource: "namespaces"}, newNS),
+		core.NewRootGetAction(schema.GroupVersionResource{Resource: "namespaces"}, newNS),
+	)
+	f.expectUpdateVirtualRouterStatusAction(expVirtualRouter)
 	f.runExpectError(getKey(virtualRouter, t))
 }
 
+func TestHashesAmbiguousNamespaceName(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("b-c", int32Ptr(1))
+	virtualRouter.Namespace = "a"
+	virtualRouter.Status.Namespace = ""
+	other := newVirtualRouter("c", int32Ptr(1))
+	other.Namespace = "a-b"
+	other.UID = "other"
+	other.Status.Namespace = generatedNamespaceName(other)
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(other)[0])
+	c, _, _ := f.newController()
+
+	newNS, err := c.virtualRouterNamespace(virtualRouter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newNS == other.Status.Namespace || newNS != hashedNamespaceName(virtualRouter) {
+		t.Errorf("expected the hashed namespace name, got %q", newNS)
+	}
+
+	// The generated name is kept for the VirtualRouter it was generated for
+	if newNS, err := c.virtualRouterNamespace(other); err != nil || newNS != other.Status.Namespace {
+		t.Errorf("expected %q, got %q (%v)", other.Status.Namespace, newNS, err)
+	}
+}
+
+func TestAdoptsLegacyNamespace(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Status.Namespace = virtualRouter.Name
+	resources := newGeneratedResources(virtualRouter)
+	virtualRouter.Status.Namespace = ""
+	legacyNS := resources[0].(*corev1.Namespace)
+	legacyNS.Labels = nil
+	d := newDeployment(legacyNS.Name, virtualRouter)
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	f.deploymentLister = append(f.deploymentLister, d)
+	f.kubeobjects = append(f.kubeobjects, d)
+	f.kubeobjects = append(f.kubeobjects, resources...)
+
+	expNS := legacyNS.DeepCopy()
+	expNS.Labels = ownerLabels(virtualRouter)
+	expVirtualRouter := withStatus(virtualRouter, d)
+	expVirtualRouter.Status.Namespace = legacyNS.Name
+
+	f.kubeactions = append(f.kubeactions,
+		core.NewRootGetAction(schema.GroupVersionResource{Resource: "namespaces"}, legacyNS.Name),
+		core.NewRootGetAction(schema.GroupVersionResource{Resource: "namespaces"}, legacyNS.Name),
+		core.NewRootUpdateAction(schema.GroupVersionResource{Resource: "namespaces"}, expNS),
+	)
+	f.expectUpdateVirtualRouterStatusAction(expVirtualRouter)
+	f.run(getKey(virtualRouter, t))
+}
+
+func TestDeletesGeneratedNamespace(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	now := metav1.Now()
+	virtualRouter.DeletionTimestamp = &now
+	newNS := virtualRouter.Status.Namespace
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: newNS,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(virtualRouter, networkcontroller.SchemeGroupVersion.WithKind("VirtualRouter")),
+			},
+		},
+	}
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	f.kubeobjects = append(f.kubeobjects, ns)
+
+	expVirtualRouter := virtualRouter.DeepCopy()
+	expVirtualRouter.Status.Conditions = []metav1.Condition{
+		newCondition(networkcontroller.ConditionTerminating, metav1.ConditionTrue, ReasonDeletingNamespace, fmt.Sprintf(MessageDeletingNamespace, newNS)),
+		newCondition(networkcontroller.ConditionNetworkAttached, metav1.ConditionUnknown, ReasonWaitingForDaemon, MessageWaitingForDaemon),
+		newCondition(networkcontroller.ConditionDegraded, metav1.ConditionFalse, ReasonAsExpected, ""),
+	}
+
+	f.kubeactions = append(f.kubeactions,
+		core.NewRootGetAction(schema.GroupVersionResource{Resource: "namespaces"}, newNS),
+		core.NewRootDeleteAction(schema.GroupVersionResource{Resource: "namespaces"}, newNS),
+	)
+	f.expectUpdateVirtualRouterStatusAction(expVirtualRouter)
+	f.run(getKey(virtualRouter, t))
+}
+
+func TestKeepsForeignNamespaceOnDeletion(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	now := metav1.Now()
+	virtualRouter.DeletionTimestamp = &now
+	newNS := virtualRouter.Status.Namespace
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	f.kubeobjects = append(f.kubeobjects, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: newNS}})
+
+	expVirtualRouter := virtualRouter.DeepCopy()
+	expVirtualRouter.Finalizers = nil
+	f.kubeactions = append(f.kubeactions, core.NewRootGetAction(schema.GroupVersionResource{Resource: "namespaces"}, newNS))
+	f.expectUpdateVirtualRouterAction(expVirtualRouter)
+	f.run(getKey(virtualRouter, t))
+}
+
+func TestRemovesFinalizerOnceNamespaceIsGone(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	now := metav1.Now()
+	virtualRouter.DeletionTimestamp = &now
+	newNS := virtualRouter.Status.Namespace
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+
+	expVirtualRouter := virtualRouter.DeepCopy()
+	expVirtualRouter.Finalizers = nil
+	f.kubeactions = append(f.kubeactions, core.NewRootGetAction(schema.GroupVersionResource{Resource: "namespaces"}, newNS))
+	f.expectUpdateVirtualRouterAction(expVirtualRouter)
+	f.run(getKey(virtualRouter, t))
+}
+
 func int32Ptr(i int32) *int32 { return &i }
+func boolPtr(b bool) *bool    { return &b }
+
+func TestEnqueueWatchedNamespaces(t *testing.T) {
+	f := newFixture(t)
+	c, _, _ := f.newController()
+	c.SetWatchNamespaces([]string{"team-a", "team-b"})
+
+	ignored := newVirtualRouter("router", nil)
+	c.enqueueVirtualRouter(ignored)
+	if c.workqueue.Len() != 0 {
+		t.Errorf("expected VirtualRouters of %s to be ignored", ignored.Namespace)
+	}
+	watched := ignored.DeepCopy()
+	watched.Namespace = "team-b"
+	c.enqueueVirtualRouter(watched)
+	if c.workqueue.Len() != 1 {
+		t.Errorf("expected VirtualRouters of %s to be queued", watched.Namespace)
+	}
+
+	c.SetWatchNamespaces(nil)
+	c.enqueueVirtualRouter(ignored)
+	if c.workqueue.Len() != 2 {
+		t.Errorf("expected VirtualRouters of every namespace to be queued")
+	}
+}
@@ -0,0 +1,165 @@
+package virtualroutermanager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	samplev2 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v2"
+	clientset "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/generated/clientset/versioned"
+)
+
+const (
+	VIRTUALROUTER_CRD_NAME = "virtualrouters.tmax.hypercloud.com"
+
+	CRD_ESTABLISH_TIMEOUT      = 30 * time.Second
+	CRD_MIGRATION_RETRY_PERIOD = 30 * time.Second
+)
+
+// InstallCustomResourceDefinition creates the VirtualRouter, IPPool and
+// VlanPool CRDs generated into VIRTUALROUTER_CRD, IPPOOL_CRD and VLANPOOL_CRD,
+// or replaces the spec of the ones already installed, and waits until the API
+// server serves them.
+func InstallCustomResourceDefinition(client apiextensionsclientset.Interface) error {
+	for _, manifest := range []string{VIRTUALROUTER_CRD, IPPOOL_CRD, VLANPOOL_CRD} {
+		if err := installCustomResourceDefinition(client, manifest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func installCustomResourceDefinition(client apiextensionsclientset.Interface, manifest string) error {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := yaml.UnmarshalStrict([]byte(manifest), crd); err != nil {
+		return fmt.Errorf("failed to decode CustomResourceDefinition: %v", err)
+	}
+
+	crds := client.ApiextensionsV1().CustomResourceDefinitions()
+	_, err := crds.Create(context.TODO(), crd, metav1.CreateOptions{})
+	switch {
+	case err == nil:
+		klog.Infof("Created CustomResourceDefinition %s", crd.Name)
+	case errors.IsAlreadyExists(err):
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			current, err := crds.Get(context.TODO(), crd.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			updated := current.DeepCopy()
+			updated.Labels = crd.Labels
+			updated.Annotations = crd.Annotations
+			updated.Spec = crd.Spec
+			keepCABundle(updated, current)
+			_, err = crds.Update(context.TODO(), updated, metav1.UpdateOptions{})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update CustomResourceDefinition %s: %v", crd.Name, err)
+		}
+		klog.Infof("Updated CustomResourceDefinition %s", crd.Name)
+	default:
+		return fmt.Errorf("failed to create CustomResourceDefinition %s: %v", crd.Name, err)
+	}
+
+	return wait.PollImmediate(time.Second, CRD_ESTABLISH_TIMEOUT, func() (bool, error) {
+		current, err := crds.Get(context.TODO(), crd.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, cond := range current.Status.Conditions {
+			if cond.Type == apiextensionsv1.Established && cond.Status == apiextensionsv1.ConditionTrue {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+// keepCABundle carries the conversion webhook CA bundle injected into current
+// over to updated, which is generated without one.
+func keepCABundle(updated *apiextensionsv1.CustomResourceDefinition, current *apiextensionsv1.CustomResourceDefinition) {
+	if updated.Spec.Conversion == nil || updated.Spec.Conversion.Webhook == nil || updated.Spec.Conversion.Webhook.ClientConfig == nil {
+		return
+	}
+	if current.Spec.Conversion == nil || current.Spec.Conversion.Webhook == nil || current.Spec.Conversion.Webhook.ClientConfig == nil {
+		return
+	}
+	if len(updated.Spec.Conversion.Webhook.ClientConfig.CABundle) == 0 {
+		updated.Spec.Conversion.Webhook.ClientConfig.CABundle = current.Spec.Conversion.Webhook.ClientConfig.CABundle
+	}
+}
+
+// MigrateStorageVersion rewrites every VirtualRouter stored in an older
+// version in the storage version of the CRD, then drops the older versions
+// from status.storedVersions. It retries until it succeeds or stopCh is
+// closed, since the objects are converted by the manager's own webhook.
+func MigrateStorageVersion(client apiextensionsclientset.Interface, sampleclientset clientset.Interface, stopCh <-chan struct{}) {
+	err := wait.PollImmediateUntil(CRD_MIGRATION_RETRY_PERIOD, func() (bool, error) {
+		if err := migrateStorageVersion(client, sampleclientset); err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to migrate VirtualRouter storage version: %v", err))
+			return false, nil
+		}
+		return true, nil
+	}, stopCh)
+	if err != nil {
+		klog.Infof("Stopped migrating VirtualRouter storage version: %s", err.Error())
+	}
+}
+
+func migrateStorageVersion(client apiextensionsclientset.Interface, sampleclientset clientset.Interface) error {
+	crds := client.ApiextensionsV1().CustomResourceDefinitions()
+	crd, err := crds.Get(context.TODO(), VIRTUALROUTER_CRD_NAME, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	storageVersion := ""
+	for _, version := range crd.Spec.Versions {
+		if version.Storage {
+			storageVersion = version.Name
+		}
+	}
+	if storageVersion != samplev2.SchemeGroupVersion.Version {
+		return fmt.Errorf("unexpected storage version %q", storageVersion)
+	}
+	if len(crd.Status.StoredVersions) == 1 && crd.Status.StoredVersions[0] == storageVersion {
+		return nil
+	}
+
+	virtualRouters, err := sampleclientset.TmaxV2().VirtualRouters(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range virtualRouters.Items {
+		virtualRouter := &virtualRouters.Items[i]
+		// An unchanged update makes the API server store the object again
+		// in the storage version.
+		_, err := sampleclientset.TmaxV2().VirtualRouters(virtualRouter.Namespace).Update(context.TODO(), virtualRouter, metav1.UpdateOptions{})
+		if err != nil && !errors.IsNotFound(err) && !errors.IsConflict(err) {
+			return fmt.Errorf("failed to migrate VirtualRouter %s/%s: %v", virtualRouter.Namespace, virtualRouter.Name, err)
+		}
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		crd, err := crds.Get(context.TODO(), VIRTUALROUTER_CRD_NAME, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		crd.Status.StoredVersions = []string{storageVersion}
+		if _, err := crds.UpdateStatus(context.TODO(), crd, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		klog.Infof("Migrated VirtualRouters to storage version %s", storageVersion)
+		return nil
+	})
+}
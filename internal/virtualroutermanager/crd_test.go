@@ -0,0 +1,58 @@
+package virtualroutermanager
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+func TestInstallCustomResourceDefinitionUpgrades(t *testing.T) {
+	var expected []*apiextensionsv1.CustomResourceDefinition
+	var objects []runtime.Object
+	for _, manifest := range []string{VIRTUALROUTER_CRD, IPPOOL_CRD, VLANPOOL_CRD} {
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := yaml.UnmarshalStrict([]byte(manifest), crd); err != nil {
+			t.Fatalf("error decoding CustomResourceDefinition: %v", err)
+		}
+		if len(crd.Spec.Versions) == 0 || crd.Spec.Versions[0].Schema == nil {
+			t.Fatalf("expected a structural schema in %s", crd.Name)
+		}
+		expected = append(expected, crd)
+
+		// An older CRD that the API server already serves.
+		objects = append(objects, &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: crd.Name},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Group: crd.Spec.Group,
+				Names: crd.Spec.Names,
+				Scope: crd.Spec.Scope,
+			},
+			Status: apiextensionsv1.CustomResourceDefinitionStatus{
+				Conditions: []apiextensionsv1.CustomResourceDefinitionCondition{
+					{Type: apiextensionsv1.Established, Status: apiextensionsv1.ConditionTrue},
+				},
+			},
+		})
+	}
+	client := apiextensionsfake.NewSimpleClientset(objects...)
+
+	if err := InstallCustomResourceDefinition(client); err != nil {
+		t.Fatalf("error installing CustomResourceDefinition: %v", err)
+	}
+
+	for _, expected := range expected {
+		crd, err := client.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), expected.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("error getting CustomResourceDefinition: %v", err)
+		}
+		if !reflect.DeepEqual(crd.Spec, expected.Spec) {
+			t.Errorf("CustomResourceDefinition %s spec was not upgraded", expected.Name)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package virtualroutermanager
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	samplev1alpha1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+// DEFAULT_MAX_UNAVAILABLE is used when the VirtualRouter does not configure
+// its disruption budget.
+var DEFAULT_MAX_UNAVAILABLE = intstr.FromInt(1)
+
+// ensureVirtualRouterPDB creates the PodDisruptionBudget of the router pods,
+// named after the router Deployment, or updates it when it drifted.
+func (c *Controller) ensureVirtualRouterPDB(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) error {
+	desired := newPodDisruptionBudget(newNS, virtualRouter)
+	pdb, err := c.pdbLister.PodDisruptionBudgets(newNS).Get(desired.Name)
+	if errors.IsNotFound(err) {
+		_, err = c.kubeclientset.PolicyV1beta1().PodDisruptionBudgets(newNS).Create(context.TODO(), desired, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if !metav1.IsControlledBy(pdb, virtualRouter) {
+		msg := fmt.Sprintf(MessageResourceExists, pdb.Name)
+		c.recorder.Event(virtualRouter, corev1.EventTypeWarning, ErrResourceExists, msg)
+		return fmt.Errorf(msg)
+	}
+
+	if equality.Semantic.DeepEqual(pdb.Spec, desired.Spec) && equality.Semantic.DeepEqual(pdb.OwnerReferences, desired.OwnerReferences) {
+		return nil
+	}
+	pdbCopy := pdb.DeepCopy()
+	pdbCopy.Labels = mergeStringMap(pdbCopy.Labels, desired.Labels)
+	pdbCopy.OwnerReferences = desired.OwnerReferences
+	pdbCopy.Spec = desired.Spec
+	_, err = c.kubeclientset.PolicyV1beta1().PodDisruptionBudgets(newNS).Update(context.TODO(), pdbCopy, metav1.UpdateOptions{})
+	return err
+}
+
+// newPodDisruptionBudget creates the PodDisruptionBudget selecting the pods of
+// the router Deployment.
+func newPodDisruptionBudget(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) *policyv1beta1.PodDisruptionBudget {
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      virtualRouter.Spec.DeploymentName,
+			Namespace: newNS,
+			Labels:    ownerLabels(virtualRouter),
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(virtualRouter, samplev1alpha1.SchemeGroupVersion.WithKind("VirtualRouter")),
+			},
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": VIRTUALROUTER_LABEL,
+				},
+			},
+		},
+	}
+
+	budget := virtualRouter.Spec.DisruptionBudget
+	switch {
+	case budget != nil && budget.MinAvailable != nil:
+		minAvailable := *budget.MinAvailable
+		// An integer minAvailable reaching the replicas would block every
+		// drain, so it follows the replicas down.
+		if minAvailable.Type == intstr.Int {
+			var replicas int32 = 1
+			if virtualRouter.Spec.Replicas != nil {
+				replicas = *virtualRouter.Spec.Replicas
+			}
+			if minAvailable.IntVal >= replicas {
+				minAvailable = intstr.FromInt(int(replicas) - 1)
+			}
+			if minAvailable.IntVal < 0 {
+				minAvailable = intstr.FromInt(0)
+			}
+		}
+		pdb.Spec.MinAvailable = &minAvailable
+	case budget != nil && budget.MaxUnavailable != nil:
+		maxUnavailable := *budget.MaxUnavailable
+		pdb.Spec.MaxUnavailable = &maxUnavailable
+	default:
+		maxUnavailable := DEFAULT_MAX_UNAVAILABLE
+		pdb.Spec.MaxUnavailable = &maxUnavailable
+	}
+	return pdb
+}
@@ -0,0 +1,319 @@
+package virtualroutermanager
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	samplev1alpha1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+// ensureVirtualRouterIPAllocations allocates an address to every interface of
+// a VirtualRouter which references an IPPool, releases the addresses of the
+// interfaces which no longer do, and records the allocations in the status of
+// the VirtualRouter. It returns the updated VirtualRouter.
+func (c *Controller) ensureVirtualRouterIPAllocations(virtualRouter *samplev1alpha1.VirtualRouter) (*samplev1alpha1.VirtualRouter, error) {
+	var allocations []samplev1alpha1.IPAllocation
+	for _, iface := range []string{samplev1alpha1.IPAllocationInternal, samplev1alpha1.IPAllocationExternal} {
+		poolName := referencedIPPool(&virtualRouter.Spec, iface)
+		if current := findIPAllocation(virtualRouter.Status.IPAllocations, iface); current != nil && current.Pool != poolName {
+			if err := c.releaseIPAddresses(virtualRouter, current.Pool, iface); err != nil {
+				return nil, fmt.Errorf("failed to release the %s address from IPPool %q: %v", iface, current.Pool, err)
+			}
+		}
+		if poolName == "" {
+			continue
+		}
+		allocation, err := c.allocateIPAddress(virtualRouter, poolName, iface)
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate the %s address from IPPool %q: %v", iface, poolName, err)
+		}
+		allocations = append(allocations, allocation)
+	}
+	return c.updateIPAllocations(virtualRouter, allocations)
+}
+
+// releaseVirtualRouterIPAddresses releases every address allocated to a
+// deleted VirtualRouter.
+func (c *Controller) releaseVirtualRouterIPAddresses(virtualRouter *samplev1alpha1.VirtualRouter) error {
+	pools := map[string]bool{}
+	for _, allocation := range virtualRouter.Status.IPAllocations {
+		pools[allocation.Pool] = true
+	}
+	// An address may have been allocated before it was recorded in the
+	// status of the VirtualRouter
+	for _, iface := range []string{samplev1alpha1.IPAllocationInternal, samplev1alpha1.IPAllocationExternal} {
+		if poolName := referencedIPPool(&virtualRouter.Spec, iface); poolName != "" {
+			pools[poolName] = true
+		}
+	}
+	for poolName := range pools {
+		if err := c.releaseIPAddresses(virtualRouter, poolName, ""); err != nil {
+			return fmt.Errorf("failed to release addresses from IPPool %q: %v", poolName, err)
+		}
+	}
+	return nil
+}
+
+// allocateIPAddress returns the address of IPPool poolName allocated to the
+// interface iface of a VirtualRouter, allocating the first free one when it
+// has none. The allocation is recorded in the status of the pool, whose
+// resource version makes sure an address is never handed out twice.
+func (c *Controller) allocateIPAddress(virtualRouter *samplev1alpha1.VirtualRouter, poolName string, iface string) (samplev1alpha1.IPAllocation, error) {
+	var allocation samplev1alpha1.IPAllocation
+	firstTry := true
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var pool *samplev1alpha1.IPPool
+		var err error
+		if firstTry {
+			pool, err = c.ipPoolsLister.Get(poolName)
+		} else {
+			pool, err = c.sampleclientset.TmaxV1().IPPools().Get(context.TODO(), poolName, metav1.GetOptions{})
+		}
+		firstTry = false
+		if err != nil {
+			return err
+		}
+		subnet, err := pool.Spec.Subnet()
+		if err != nil {
+			return err
+		}
+
+		address := ""
+		for _, allocated := range pool.Status.Allocations {
+			if allocated.Namespace == virtualRouter.Namespace && allocated.Name == virtualRouter.Name && allocated.Interface == iface {
+				address = allocated.Address
+				break
+			}
+		}
+		if address == "" {
+			if address, err = nextFreeAddress(pool, subnet, c.addressesInUse(pool.Name)); err != nil {
+				return err
+			}
+			poolCopy := pool.DeepCopy()
+			poolCopy.Status.Allocations = append(poolCopy.Status.Allocations, samplev1alpha1.IPPoolAllocation{
+				Address:   address,
+				Namespace: virtualRouter.Namespace,
+				Name:      virtualRouter.Name,
+				Interface: iface,
+			})
+			poolCopy.Status.Allocated = int32(len(poolCopy.Status.Allocations))
+			if _, err := c.sampleclientset.TmaxV1().IPPools().UpdateStatus(context.TODO(), poolCopy, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+			klog.Infof("Allocated %s from IPPool %s to the %s interface of VirtualRouter %s/%s", address, pool.Name, iface, virtualRouter.Namespace, virtualRouter.Name)
+		}
+
+		allocation = samplev1alpha1.IPAllocation{
+			Interface: iface,
+			Pool:      pool.Name,
+			Address:   address,
+			Netmask:   net.IP(subnet.Mask).String(),
+			Gateway:   pool.Spec.Gateway,
+		}
+		return nil
+	})
+	return allocation, err
+}
+
+// releaseIPAddresses removes the addresses allocated to the interface iface
+// of a VirtualRouter, or to all of its interfaces if iface is empty, from the
+// status of IPPool poolName.
+func (c *Controller) releaseIPAddresses(virtualRouter *samplev1alpha1.VirtualRouter, poolName string, iface string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pool, err := c.sampleclientset.TmaxV1().IPPools().Get(context.TODO(), poolName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var remaining []samplev1alpha1.IPPoolAllocation
+		for _, allocated := range pool.Status.Allocations {
+			if allocated.Namespace == virtualRouter.Namespace && allocated.Name == virtualRouter.Name && (iface == "" || allocated.Interface == iface) {
+				klog.Infof("Releasing %s of VirtualRouter %s/%s to IPPool %s", allocated.Address, virtualRouter.Namespace, virtualRouter.Name, pool.Name)
+				continue
+			}
+			remaining = append(remaining, allocated)
+		}
+		if len(remaining) == len(pool.Status.Allocations) {
+			return nil
+		}
+		poolCopy := pool.DeepCopy()
+		poolCopy.Status.Allocations = remaining
+		poolCopy.Status.Allocated = int32(len(remaining))
+		_, err = c.sampleclientset.TmaxV1().IPPools().UpdateStatus(context.TODO(), poolCopy, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// updateIPAllocations records allocations in the status of a VirtualRouter,
+// together with the IPAllocated condition, which is only reported for
+// VirtualRouters referencing an IPPool.
+func (c *Controller) updateIPAllocations(virtualRouter *samplev1alpha1.VirtualRouter, allocations []samplev1alpha1.IPAllocation) (*samplev1alpha1.VirtualRouter, error) {
+	latest := virtualRouter
+	firstTry := true
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if !firstTry {
+			var err error
+			latest, err = c.sampleclientset.TmaxV1().VirtualRouters(virtualRouter.Namespace).Get(context.TODO(), virtualRouter.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+		}
+		firstTry = false
+
+		virtualRouterCopy := latest.DeepCopy()
+		virtualRouterCopy.Status.IPAllocations = allocations
+		if len(allocations) == 0 {
+			// RemoveStatusCondition of apimachinery 0.19 panics when the
+			// condition is missing
+			if meta.FindStatusCondition(virtualRouterCopy.Status.Conditions, samplev1alpha1.ConditionIPAllocated) != nil {
+				meta.RemoveStatusCondition(&virtualRouterCopy.Status.Conditions, samplev1alpha1.ConditionIPAllocated)
+			}
+		} else {
+			condition := newCondition(samplev1alpha1.ConditionIPAllocated, metav1.ConditionTrue, ReasonIPAllocated, describeIPAllocations(allocations))
+			condition.ObservedGeneration = virtualRouter.Generation
+			meta.SetStatusCondition(&virtualRouterCopy.Status.Conditions, condition)
+		}
+		if equality.Semantic.DeepEqual(latest.Status, virtualRouterCopy.Status) {
+			return nil
+		}
+		updated, err := c.sampleclientset.TmaxV1().VirtualRouters(virtualRouter.Namespace).UpdateStatus(context.TODO(), virtualRouterCopy, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		latest = updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return latest, nil
+}
+
+// addressesInUse returns the addresses which IPPool poolName must not hand
+// out: those allocated from the other pools, which may overlap with it, and
+// those set on VirtualRouters in any namespace, directly or through the
+// address pools of their replicas. The VirtualRouter informer is cluster-wide
+// whatever the watched namespaces.
+func (c *Controller) addressesInUse(poolName string) map[string]bool {
+	inUse := map[string]bool{}
+	pools, err := c.ipPoolsLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+	for _, pool := range pools {
+		if pool.Name == poolName {
+			continue
+		}
+		for _, allocated := range pool.Status.Allocations {
+			inUse[allocated.Address] = true
+		}
+	}
+	virtualRouters, err := c.virtualRoutersLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+	for _, virtualRouter := range virtualRouters {
+		inUse[virtualRouter.Spec.InternalIP] = true
+		inUse[virtualRouter.Spec.ExternalIP] = true
+		if replicaAddressing := virtualRouter.Spec.ReplicaAddressing; replicaAddressing != nil {
+			for _, addressPool := range []*samplev1alpha1.AddressPool{replicaAddressing.Internal, replicaAddressing.External} {
+				if addressPool == nil {
+					continue
+				}
+				addresses, _ := addressPool.List()
+				for _, address := range addresses {
+					inUse[address] = true
+				}
+			}
+		}
+	}
+	return inUse
+}
+
+// nextFreeAddress returns the first host address of subnet which is neither
+// excluded from pool, allocated from it, nor in inUse.
+func nextFreeAddress(pool *samplev1alpha1.IPPool, subnet *net.IPNet, inUse map[string]bool) (string, error) {
+	allocated := map[string]bool{}
+	for _, allocation := range pool.Status.Allocations {
+		allocated[allocation.Address] = true
+	}
+
+	ones, bits := subnet.Mask.Size()
+	first := binary.BigEndian.Uint32(subnet.IP.To4())
+	last := first | ^binary.BigEndian.Uint32(net.IP(subnet.Mask).To4())
+	// The network and broadcast addresses of subnets with host addresses
+	if bits-ones >= 2 {
+		first++
+		last--
+	}
+	for i := uint64(first); i <= uint64(last); i++ {
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, uint32(i))
+		address := ip.String()
+		if allocated[address] || inUse[address] || pool.Spec.Excludes(ip) {
+			continue
+		}
+		return address, nil
+	}
+	return "", fmt.Errorf("no free address left in IPPool %q", pool.Name)
+}
+
+// handleIPPool enqueues the VirtualRouters referencing an IPPool, so that
+// those waiting for it to be created or to free an address are synced.
+func (c *Controller) handleIPPool(obj interface{}) {
+	pool, ok := obj.(*samplev1alpha1.IPPool)
+	if !ok {
+		return
+	}
+	virtualRouters, err := c.virtualRoutersLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, virtualRouter := range virtualRouters {
+		if virtualRouter.Spec.InternalIPPool == pool.Name || virtualRouter.Spec.ExternalIPPool == pool.Name {
+			c.enqueueVirtualRouter(virtualRouter)
+		}
+	}
+}
+
+// referencedIPPool returns the IPPool the address of the interface iface is
+// allocated from, or "".
+func referencedIPPool(spec *samplev1alpha1.VirtualRouterSpec, iface string) string {
+	if iface == samplev1alpha1.IPAllocationInternal {
+		return spec.InternalIPPool
+	}
+	return spec.ExternalIPPool
+}
+
+func findIPAllocation(allocations []samplev1alpha1.IPAllocation, iface string) *samplev1alpha1.IPAllocation {
+	for i := range allocations {
+		if allocations[i].Interface == iface {
+			return &allocations[i]
+		}
+	}
+	return nil
+}
+
+func describeIPAllocations(allocations []samplev1alpha1.IPAllocation) string {
+	var descriptions []string
+	for _, allocation := range allocations {
+		descriptions = append(descriptions, fmt.Sprintf("%s %s from %s", allocation.Interface, allocation.Address, allocation.Pool))
+	}
+	return strings.Join(descriptions, ", ")
+}
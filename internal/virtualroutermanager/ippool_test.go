@@ -0,0 +1,153 @@
+package virtualroutermanager
+
+import (
+	"net"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	core "k8s.io/client-go/testing"
+
+	networkcontroller "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+func newIPPool(name string, cidr string, allocations ...networkcontroller.IPPoolAllocation) *networkcontroller.IPPool {
+	return &networkcontroller.IPPool{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       networkcontroller.IPPoolSpec{CIDR: cidr},
+		Status: networkcontroller.IPPoolStatus{
+			Allocated:   int32(len(allocations)),
+			Allocations: allocations,
+		},
+	}
+}
+
+func (f *fixture) expectUpdateIPPoolStatusAction(pool *networkcontroller.IPPool) {
+	f.actions = append(f.actions, core.NewRootUpdateSubresourceAction(schema.GroupVersionResource{Resource: "ippools"}, "status", pool))
+}
+
+func TestNextFreeAddress(t *testing.T) {
+	pool := newIPPool("pool", "10.0.0.0/29", networkcontroller.IPPoolAllocation{Address: "10.0.0.4"})
+	pool.Spec.Gateway = "10.0.0.1"
+	pool.Spec.Exclusions = []string{"10.0.0.2-10.0.0.3"}
+	_, subnet, _ := net.ParseCIDR(pool.Spec.CIDR)
+
+	address, err := nextFreeAddress(pool, subnet, map[string]bool{"10.0.0.5": true})
+	if err != nil || address != "10.0.0.6" {
+		t.Errorf("expected 10.0.0.6, got %q (%v)", address, err)
+	}
+
+	// The broadcast address is never allocated
+	if address, err := nextFreeAddress(pool, subnet, map[string]bool{"10.0.0.5": true, "10.0.0.6": true}); err == nil {
+		t.Errorf("expected the pool to be exhausted, got %s", address)
+	}
+
+	// Every address of a /32 is a host address
+	_, subnet, _ = net.ParseCIDR("10.0.0.9/32")
+	if address, err := nextFreeAddress(newIPPool("host", "10.0.0.9/32"), subnet, nil); err != nil || address != "10.0.0.9" {
+		t.Errorf("expected 10.0.0.9, got %q (%v)", address, err)
+	}
+}
+
+func TestAllocatesIPFromPool(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Spec.InternalIPPool = "internal"
+	// An address of the pool is already allocated to a VirtualRouter of
+	// another namespace, another one is set on a VirtualRouter of another
+	// namespace directly and two more are given to the replicas of a third
+	pool := newIPPool("internal", "10.0.0.0/24", networkcontroller.IPPoolAllocation{
+		Address: "10.0.0.1", Namespace: "other", Name: "test", Interface: networkcontroller.IPAllocationInternal,
+	})
+	other := newVirtualRouter("literal", int32Ptr(1))
+	other.Namespace = "other"
+	other.Spec.InternalIP = "10.0.0.2"
+	replicated := newVirtualRouter("replicated", int32Ptr(2))
+	replicated.Spec.ReplicaAddressing = &networkcontroller.ReplicaAddressing{
+		Internal: &networkcontroller.AddressPool{Range: "10.0.0.3-10.0.0.4"},
+	}
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter, other, replicated)
+	f.ipPoolLister = append(f.ipPoolLister, pool)
+	f.objects = append(f.objects, virtualRouter, pool)
+
+	newNS := virtualRouter.Status.Namespace
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
+	expDeployment := newDeployment(newNS, virtualRouter)
+
+	expPool := pool.DeepCopy()
+	expPool.Status.Allocations = append(expPool.Status.Allocations, networkcontroller.IPPoolAllocation{
+		Address: "10.0.0.5", Namespace: virtualRouter.Namespace, Name: virtualRouter.Name, Interface: networkcontroller.IPAllocationInternal,
+	})
+	expPool.Status.Allocated = 2
+	allocated := virtualRouter.DeepCopy()
+	allocated.Status.IPAllocations = []networkcontroller.IPAllocation{
+		{Interface: networkcontroller.IPAllocationInternal, Pool: "internal", Address: "10.0.0.5", Netmask: "255.255.255.0"},
+	}
+	ipAllocated := newCondition(networkcontroller.ConditionIPAllocated, metav1.ConditionTrue, ReasonIPAllocated, "internal 10.0.0.5 from internal")
+	allocated.Status.Conditions = []metav1.Condition{ipAllocated}
+	expVirtualRouter := withStatus(allocated, expDeployment)
+	expVirtualRouter.Status.Conditions = append([]metav1.Condition{ipAllocated}, expVirtualRouter.Status.Conditions...)
+
+	f.expectUpdateIPPoolStatusAction(expPool)
+	f.expectUpdateVirtualRouterStatusAction(allocated)
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.expectCreateDeploymentAction(expDeployment)
+	f.expectUpdateVirtualRouterStatusAction(expVirtualRouter)
+
+	f.run(getKey(virtualRouter, t))
+}
+
+func TestIPPoolExhausted(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Spec.ExternalIPPool = "external"
+	pool := newIPPool("external", "192.168.8.0/30", networkcontroller.IPPoolAllocation{
+		Address: "192.168.8.1", Namespace: "other", Name: "test", Interface: networkcontroller.IPAllocationExternal,
+	})
+	pool.Spec.Gateway = "192.168.8.2"
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.ipPoolLister = append(f.ipPoolLister, pool)
+	f.objects = append(f.objects, virtualRouter, pool)
+
+	expVirtualRouter := virtualRouter.DeepCopy()
+	expVirtualRouter.Status.Conditions = []metav1.Condition{
+		newCondition(networkcontroller.ConditionIPAllocated, metav1.ConditionFalse, ReasonIPAllocationFailed,
+			`failed to allocate the external address from IPPool "external": no free address left in IPPool "external"`),
+		newCondition(networkcontroller.ConditionNetworkAttached, metav1.ConditionUnknown, ReasonWaitingForDaemon, MessageWaitingForDaemon),
+	}
+	expVirtualRouter.Status.Conditions = append(expVirtualRouter.Status.Conditions, degradedCondition(expVirtualRouter.Status.Conditions))
+
+	f.expectUpdateVirtualRouterStatusAction(expVirtualRouter)
+	f.runExpectError(getKey(virtualRouter, t))
+}
+
+func TestReleasesIPOnceNamespaceIsGone(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	now := metav1.Now()
+	virtualRouter.DeletionTimestamp = &now
+	virtualRouter.Spec.InternalIPPool = "internal"
+	virtualRouter.Status.IPAllocations = []networkcontroller.IPAllocation{
+		{Interface: networkcontroller.IPAllocationInternal, Pool: "internal", Address: "10.0.0.5", Netmask: "255.255.255.0"},
+	}
+	kept := networkcontroller.IPPoolAllocation{Address: "10.0.0.1", Namespace: "other", Name: "test", Interface: networkcontroller.IPAllocationInternal}
+	pool := newIPPool("internal", "10.0.0.0/24", kept, networkcontroller.IPPoolAllocation{
+		Address: "10.0.0.5", Namespace: virtualRouter.Namespace, Name: virtualRouter.Name, Interface: networkcontroller.IPAllocationInternal,
+	})
+	newNS := virtualRouter.Status.Namespace
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.ipPoolLister = append(f.ipPoolLister, pool)
+	f.objects = append(f.objects, virtualRouter, pool)
+
+	expPool := newIPPool("internal", "10.0.0.0/24", kept)
+	expVirtualRouter := virtualRouter.DeepCopy()
+	expVirtualRouter.Finalizers = nil
+	f.kubeactions = append(f.kubeactions, core.NewRootGetAction(schema.GroupVersionResource{Resource: "namespaces"}, newNS))
+	f.actions = append(f.actions, core.NewRootGetAction(schema.GroupVersionResource{Resource: "ippools"}, pool.Name))
+	f.expectUpdateIPPoolStatusAction(expPool)
+	f.expectUpdateVirtualRouterAction(expVirtualRouter)
+	f.run(getKey(virtualRouter, t))
+}
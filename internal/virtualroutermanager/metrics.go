@@ -0,0 +1,60 @@
+package virtualroutermanager
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/metrics"
+)
+
+const METRICS_SUBSYSTEM = "virtualrouter_manager"
+
+var (
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: METRICS_SUBSYSTEM,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Time taken to reconcile a VirtualRouter.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"namespace", "name"})
+
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: METRICS_SUBSYSTEM,
+		Name:      "reconcile_errors_total",
+		Help:      "Number of failed VirtualRouter reconciles.",
+	}, []string{"namespace", "name"})
+)
+
+// RegisterMetrics registers the metrics of the manager in metrics.Registry.
+// It is called by the manager binary only, so that the daemon, which shares
+// some of this package, does not expose them.
+func RegisterMetrics() {
+	metrics.Registry.MustRegister(reconcileDuration, reconcileErrors)
+}
+
+// observeReconcile records the duration and result of syncing key. The
+// series of a VirtualRouter which is gone are deleted instead, so that the
+// sync following its deletion does not create them again.
+func (c *Controller) observeReconcile(key string, start time.Time, err error) {
+	namespace, name, splitErr := cache.SplitMetaNamespaceKey(key)
+	if splitErr != nil {
+		return
+	}
+	if _, getErr := c.virtualRoutersLister.VirtualRouters(namespace).Get(name); errors.IsNotFound(getErr) {
+		forgetReconcileMetrics(namespace, name)
+		return
+	}
+	reconcileDuration.WithLabelValues(namespace, name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		reconcileErrors.WithLabelValues(namespace, name).Inc()
+	}
+}
+
+// forgetReconcileMetrics deletes the series of a VirtualRouter once it is
+// gone.
+func forgetReconcileMetrics(namespace string, name string) {
+	reconcileDuration.DeleteLabelValues(namespace, name)
+	reconcileErrors.DeleteLabelValues(namespace, name)
+}
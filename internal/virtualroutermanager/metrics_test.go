@@ -0,0 +1,44 @@
+package virtualroutermanager
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestReconcileMetricsAreForgotten(t *testing.T) {
+	f := newFixture(t)
+	teamA := newVirtualRouter("router", int32Ptr(1))
+	teamA.Namespace = "team-a"
+	teamB := teamA.DeepCopy()
+	teamB.Namespace = "team-b"
+	f.virtualRouterLister = append(f.virtualRouterLister, teamA, teamB)
+	c, i, _ := f.newController()
+
+	c.observeReconcile("team-a/router", time.Now(), nil)
+	c.observeReconcile("team-a/router", time.Now(), fmt.Errorf("failed"))
+	c.observeReconcile("team-b/router", time.Now(), nil)
+	if count := testutil.CollectAndCount(reconcileErrors); count != 1 {
+		t.Errorf("expected 1 error series, got %d", count)
+	}
+	if count := testutil.CollectAndCount(reconcileDuration); count != 2 {
+		t.Errorf("expected a duration series per VirtualRouter, got %d", count)
+	}
+
+	forgetReconcileMetrics("team-a", "router")
+	if count := testutil.CollectAndCount(reconcileErrors); count != 0 {
+		t.Errorf("expected the error series to be deleted, got %d", count)
+	}
+	if count := testutil.CollectAndCount(reconcileDuration); count != 1 {
+		t.Errorf("expected only the duration series of team-b/router to be left, got %d", count)
+	}
+
+	// The sync following the deletion does not bring them back
+	i.Tmax().V1().VirtualRouters().Informer().GetIndexer().Delete(teamA)
+	c.observeReconcile("team-a/router", time.Now(), nil)
+	if count := testutil.CollectAndCount(reconcileDuration); count != 1 {
+		t.Errorf("expected the duration series of team-a/router not to be recreated, got %d", count)
+	}
+}
@@ -0,0 +1,95 @@
+package virtualroutermanager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// applyPodTemplateOverlay strategic-merge-patches overlay onto template and
+// restores the fields of base the manager and the daemon rely on: the
+// labels selecting the pods, the annotations and finalizer used by the daemon,
+// the service account bound to the generated Role and the router container.
+func applyPodTemplateOverlay(template corev1.PodTemplateSpec, base *corev1.PodTemplateSpec, overlay *runtime.RawExtension) (corev1.PodTemplateSpec, error) {
+	if overlay == nil || len(overlay.Raw) == 0 {
+		return template, nil
+	}
+
+	original, err := json.Marshal(template)
+	if err != nil {
+		return template, err
+	}
+	patched, err := strategicpatch.StrategicMergePatch(original, overlay.Raw, corev1.PodTemplateSpec{})
+	if err != nil {
+		return template, fmt.Errorf("invalid podTemplateOverlay: %v", err)
+	}
+	result := corev1.PodTemplateSpec{}
+	if err := json.Unmarshal(patched, &result); err != nil {
+		return template, fmt.Errorf("invalid podTemplateOverlay: %v", err)
+	}
+
+	result.Labels = mergeStringMap(result.Labels, base.Labels)
+	result.Annotations = mergeStringMap(result.Annotations, base.Annotations)
+	for _, finalizer := range base.Finalizers {
+		if !containsString(result.Finalizers, finalizer) {
+			result.Finalizers = append(result.Finalizers, finalizer)
+		}
+	}
+	result.Spec.ServiceAccountName = base.Spec.ServiceAccountName
+
+	for _, baseContainer := range base.Spec.Containers {
+		container := findContainer(result.Spec.Containers, baseContainer.Name)
+		if container == nil {
+			result.Spec.Containers = append(result.Spec.Containers, baseContainer)
+			continue
+		}
+		container.Image = baseContainer.Image
+		container.SecurityContext = baseContainer.SecurityContext
+		container.Env = mergeEnv(container.Env, baseContainer.Env)
+	}
+	return result, nil
+}
+
+// mergeEnv returns env with every variable of base set to its base value.
+func mergeEnv(env []corev1.EnvVar, base []corev1.EnvVar) []corev1.EnvVar {
+	merged := append([]corev1.EnvVar(nil), env...)
+	for _, baseVar := range base {
+		found := false
+		for i := range merged {
+			if merged[i].Name == baseVar.Name {
+				merged[i] = baseVar
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, baseVar)
+		}
+	}
+	return merged
+}
+
+func findContainer(containers []corev1.Container, name string) *corev1.Container {
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i]
+		}
+	}
+	return nil
+}
+
+// podTemplateHash returns a hash of the desired pod template, spec and overlay
+// included, recorded in POD_TEMPLATE_HASH_ANNOTATION of the router workload.
+func podTemplateHash(template *corev1.PodTemplateSpec) string {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])[:16]
+}
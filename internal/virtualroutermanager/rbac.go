@@ -0,0 +1,200 @@
+package virtualroutermanager
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	rbac_v1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	samplev1alpha1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+// ensureVirtualRouterSA creates the ServiceAccount of the router pods, or
+// restores its ownership when it drifted.
+func (c *Controller) ensureVirtualRouterSA(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) error {
+	desired := newServiceAccount(newNS, virtualRouter)
+	sa, err := c.saLister.ServiceAccounts(newNS).Get(desired.Name)
+	if errors.IsNotFound(err) {
+		_, err = c.kubeclientset.CoreV1().ServiceAccounts(newNS).Create(context.TODO(), desired, metav1.CreateOptions{})
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+		// Created before the generated objects were labelled, so it is not
+		// cached by the informer
+		sa, err = c.kubeclientset.CoreV1().ServiceAccounts(newNS).Get(context.TODO(), desired.Name, metav1.GetOptions{})
+	}
+	if err != nil {
+		return err
+	}
+	if err := c.checkGeneratedOwner(sa, virtualRouter); err != nil {
+		return err
+	}
+
+	if ownedAsDesired(sa, desired) {
+		return nil
+	}
+	saCopy := sa.DeepCopy()
+	saCopy.Labels = mergeStringMap(saCopy.Labels, desired.Labels)
+	saCopy.OwnerReferences = desired.OwnerReferences
+	_, err = c.kubeclientset.CoreV1().ServiceAccounts(newNS).Update(context.TODO(), saCopy, metav1.UpdateOptions{})
+	return err
+}
+
+// ensureVirtualRouterRole creates the Role of the router pods granting rules,
+// or restores its rules when they drifted.
+func (c *Controller) ensureVirtualRouterRole(newNS string, virtualRouter *samplev1alpha1.VirtualRouter, rules []rbac_v1.PolicyRule) error {
+	desired := newRole(newNS, virtualRouter, rules)
+	role, err := c.rolesLister.Roles(newNS).Get(desired.Name)
+	if errors.IsNotFound(err) {
+		_, err = c.kubeclientset.RbacV1().Roles(newNS).Create(context.TODO(), desired, metav1.CreateOptions{})
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+		// Created before the generated objects were labelled, so it is not
+		// cached by the informer
+		role, err = c.kubeclientset.RbacV1().Roles(newNS).Get(context.TODO(), desired.Name, metav1.GetOptions{})
+	}
+	if err != nil {
+		return err
+	}
+	if err := c.checkGeneratedOwner(role, virtualRouter); err != nil {
+		return err
+	}
+
+	if ownedAsDesired(role, desired) && equality.Semantic.DeepEqual(role.Rules, desired.Rules) {
+		return nil
+	}
+	roleCopy := role.DeepCopy()
+	roleCopy.Labels = mergeStringMap(roleCopy.Labels, desired.Labels)
+	roleCopy.OwnerReferences = desired.OwnerReferences
+	roleCopy.Rules = desired.Rules
+	_, err = c.kubeclientset.RbacV1().Roles(newNS).Update(context.TODO(), roleCopy, metav1.UpdateOptions{})
+	return err
+}
+
+// ensureVirtualRouterRoleBinding creates the RoleBinding of the router pods,
+// or restores its subjects when they drifted. The role of a RoleBinding
+// cannot be changed, so a RoleBinding bound to another role is recreated.
+func (c *Controller) ensureVirtualRouterRoleBinding(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) error {
+	desired := newRoleBinding(newNS, virtualRouter)
+	roleBinding, err := c.roleBindingsLister.RoleBindings(newNS).Get(desired.Name)
+	if errors.IsNotFound(err) {
+		_, err = c.kubeclientset.RbacV1().RoleBindings(newNS).Create(context.TODO(), desired, metav1.CreateOptions{})
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+		// Created before the generated objects were labelled, so it is not
+		// cached by the informer
+		roleBinding, err = c.kubeclientset.RbacV1().RoleBindings(newNS).Get(context.TODO(), desired.Name, metav1.GetOptions{})
+	}
+	if err != nil {
+		return err
+	}
+	if err := c.checkGeneratedOwner(roleBinding, virtualRouter); err != nil {
+		return err
+	}
+
+	if roleBinding.RoleRef != desired.RoleRef {
+		err = c.kubeclientset.RbacV1().RoleBindings(newNS).Delete(context.TODO(), roleBinding.Name, metav1.DeleteOptions{
+			Preconditions: metav1.NewUIDPreconditions(string(roleBinding.UID)),
+		})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		_, err = c.kubeclientset.RbacV1().RoleBindings(newNS).Create(context.TODO(), desired, metav1.CreateOptions{})
+		return err
+	}
+	if ownedAsDesired(roleBinding, desired) && equality.Semantic.DeepEqual(roleBinding.Subjects, desired.Subjects) {
+		return nil
+	}
+	roleBindingCopy := roleBinding.DeepCopy()
+	roleBindingCopy.Labels = mergeStringMap(roleBindingCopy.Labels, desired.Labels)
+	roleBindingCopy.OwnerReferences = desired.OwnerReferences
+	roleBindingCopy.Subjects = desired.Subjects
+	_, err = c.kubeclientset.RbacV1().RoleBindings(newNS).Update(context.TODO(), roleBindingCopy, metav1.UpdateOptions{})
+	return err
+}
+
+// checkGeneratedOwner returns an error if an object of the generated
+// namespace is controlled by something else than the VirtualRouter. Objects
+// without a controller are adopted, since the namespace belongs to the
+// VirtualRouter.
+func (c *Controller) checkGeneratedOwner(object metav1.Object, virtualRouter *samplev1alpha1.VirtualRouter) error {
+	if metav1.GetControllerOf(object) == nil || metav1.IsControlledBy(object, virtualRouter) {
+		return nil
+	}
+	msg := fmt.Sprintf(MessageResourceExists, object.GetName())
+	c.recorder.Event(virtualRouter, corev1.EventTypeWarning, ErrResourceExists, msg)
+	return fmt.Errorf(msg)
+}
+
+// ownedAsDesired reports whether object carries the ownership labels and
+// references of desired.
+func ownedAsDesired(object metav1.Object, desired metav1.Object) bool {
+	for k, v := range desired.GetLabels() {
+		if object.GetLabels()[k] != v {
+			return false
+		}
+	}
+	return equality.Semantic.DeepEqual(object.GetOwnerReferences(), desired.GetOwnerReferences())
+}
+
+// newServiceAccount creates the ServiceAccount the router pods run as.
+func newServiceAccount(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SERVICE_ACCOUNT_NAME,
+			Namespace: newNS,
+			Labels:    ownerLabels(virtualRouter),
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(virtualRouter, samplev1alpha1.SchemeGroupVersion.WithKind("VirtualRouter")),
+			},
+		},
+	}
+}
+
+// newRole creates the Role of the router pods granting rules, see
+// RouterRoleConfig.
+func newRole(newNS string, virtualRouter *samplev1alpha1.VirtualRouter, rules []rbac_v1.PolicyRule) *rbac_v1.Role {
+	return &rbac_v1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ROLE_NAME,
+			Namespace: newNS,
+			Labels:    ownerLabels(virtualRouter),
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(virtualRouter, samplev1alpha1.SchemeGroupVersion.WithKind("VirtualRouter")),
+			},
+		},
+		Rules: rules,
+	}
+}
+
+// newRoleBinding creates the RoleBinding granting the Role to the
+// ServiceAccount of the router pods.
+func newRoleBinding(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) *rbac_v1.RoleBinding {
+	return &rbac_v1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ROLE_BINDING_NAME,
+			Namespace: newNS,
+			Labels:    ownerLabels(virtualRouter),
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(virtualRouter, samplev1alpha1.SchemeGroupVersion.WithKind("VirtualRouter")),
+			},
+		},
+		RoleRef: rbac_v1.RoleRef{
+			APIGroup: rbac_v1.SchemeGroupVersion.Group,
+			Kind:     "Role",
+			Name:     ROLE_NAME,
+		},
+		Subjects: []rbac_v1.Subject{
+			{
+				Kind: "ServiceAccount",
+				Name: SERVICE_ACCOUNT_NAME,
+			},
+		},
+	}
+}
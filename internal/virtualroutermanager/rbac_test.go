@@ -0,0 +1,113 @@
+package virtualroutermanager
+
+import (
+	"reflect"
+	"testing"
+
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	core "k8s.io/client-go/testing"
+
+	networkcontroller "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+// runRBACDrift syncs a VirtualRouter whose generated resources are up to
+// date but for the RBAC object live, which replaces the generated object of
+// its type, and expects the actions repairing it.
+func runRBACDrift(t *testing.T, virtualRouter *networkcontroller.VirtualRouter, live runtime.Object, repair ...core.Action) {
+	f := newFixture(t)
+	newNS := virtualRouter.Status.Namespace
+	d := newDeployment(newNS, virtualRouter)
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	f.deploymentLister = append(f.deploymentLister, d)
+	f.kubeobjects = append(f.kubeobjects, d)
+	for _, obj := range newGeneratedResources(virtualRouter) {
+		if reflect.TypeOf(obj) == reflect.TypeOf(live) {
+			obj = live
+		}
+		f.kubeobjects = append(f.kubeobjects, obj)
+	}
+
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.kubeactions = append(f.kubeactions, repair...)
+	f.expectUpdateVirtualRouterStatusAction(withStatus(virtualRouter, d))
+	f.run(getKey(virtualRouter, t))
+}
+
+func TestRepairsRoleRules(t *testing.T) {
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	newNS := virtualRouter.Status.Namespace
+	live := newRole(newNS, virtualRouter, DefaultRouterRoleConfig().Rules)
+	live.Rules = live.Rules[:1]
+	live.Rules[0].Verbs = []string{"get"}
+
+	expRole := newRole(newNS, virtualRouter, DefaultRouterRoleConfig().Rules)
+	runRBACDrift(t, virtualRouter, live, core.NewUpdateAction(schema.GroupVersionResource{Resource: "roles"}, newNS, expRole))
+}
+
+func TestRepairsRoleBindingSubjects(t *testing.T) {
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	newNS := virtualRouter.Status.Namespace
+	live := newRoleBinding(newNS, virtualRouter)
+	live.Subjects = append(live.Subjects, rbacv1.Subject{Kind: "User", Name: "someone"})
+
+	expRoleBinding := newRoleBinding(newNS, virtualRouter)
+	runRBACDrift(t, virtualRouter, live, core.NewUpdateAction(schema.GroupVersionResource{Resource: "rolebindings"}, newNS, expRoleBinding))
+}
+
+func TestRecreatesRoleBindingOfAnotherRole(t *testing.T) {
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	newNS := virtualRouter.Status.Namespace
+	live := newRoleBinding(newNS, virtualRouter)
+	live.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "admin"}
+
+	expRoleBinding := newRoleBinding(newNS, virtualRouter)
+	runRBACDrift(t, virtualRouter, live,
+		core.NewDeleteAction(schema.GroupVersionResource{Resource: "rolebindings"}, newNS, ROLE_BINDING_NAME),
+		core.NewCreateAction(schema.GroupVersionResource{Resource: "rolebindings"}, newNS, expRoleBinding),
+	)
+}
+
+func TestAdoptsServiceAccount(t *testing.T) {
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	newNS := virtualRouter.Status.Namespace
+	live := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: SERVICE_ACCOUNT_NAME, Namespace: newNS}}
+
+	expServiceAccount := newServiceAccount(newNS, virtualRouter)
+	runRBACDrift(t, virtualRouter, live, core.NewUpdateAction(schema.GroupVersionResource{Resource: "serviceaccounts"}, newNS, expServiceAccount))
+}
+
+func TestRoleOwnedByAnotherController(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	newNS := virtualRouter.Status.Namespace
+	live := newRole(newNS, virtualRouter, DefaultRouterRoleConfig().Rules)
+	owner := newDeployment(newNS, virtualRouter)
+	owner.UID = "other"
+	live.OwnerReferences = []metav1.OwnerReference{
+		*metav1.NewControllerRef(owner, apps.SchemeGroupVersion.WithKind("Deployment")),
+	}
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)[:2]...)
+	f.kubeobjects = append(f.kubeobjects, live)
+
+	expVirtualRouter := virtualRouter.DeepCopy()
+	expVirtualRouter.Status.Conditions = []metav1.Condition{
+		newCondition(networkcontroller.ConditionNamespaceReady, metav1.ConditionTrue, ReasonNamespaceCreated, ""),
+		newCondition(networkcontroller.ConditionRBACReady, metav1.ConditionFalse, ReasonRBACFailed, `Resource "virtualrouter-role" already exists and is not managed by VirtualRouter`),
+		newCondition(networkcontroller.ConditionNetworkAttached, metav1.ConditionUnknown, ReasonWaitingForDaemon, MessageWaitingForDaemon),
+	}
+	expVirtualRouter.Status.Conditions = append(expVirtualRouter.Status.Conditions, degradedCondition(expVirtualRouter.Status.Conditions))
+
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.expectUpdateVirtualRouterStatusAction(expVirtualRouter)
+	f.runExpectError(getKey(virtualRouter, t))
+}
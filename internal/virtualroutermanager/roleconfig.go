@@ -0,0 +1,148 @@
+package virtualroutermanager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	rbac_v1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+
+	samplev1alpha1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+	virtualrouter "github.com/tmax-cloud/virtualrouter/pkg/apis/networkcontroller"
+)
+
+// RouterRoleConfig describes the rules of the Role granted to the router
+// pods.
+type RouterRoleConfig struct {
+	// Rules are granted to the router pods of every VirtualRouter
+	Rules []rbac_v1.PolicyRule `json:"rules"`
+	// NamespaceRules replace Rules for the VirtualRouters of a namespace,
+	// e.g. to restrict a tenant to a subset of them
+	NamespaceRules map[string][]rbac_v1.PolicyRule `json:"namespaceRules,omitempty"`
+	// AllowedRules limit the spec.additionalRoleRules of the VirtualRouters.
+	// Without AllowedRules, no additional rule is allowed.
+	AllowedRules []rbac_v1.PolicyRule `json:"allowedRules,omitempty"`
+}
+
+// DefaultRouterRoleConfig returns the rules the router image needs: full
+// access to the rules it serves and read access to the VPNs.
+func DefaultRouterRoleConfig() *RouterRoleConfig {
+	return &RouterRoleConfig{
+		Rules: []rbac_v1.PolicyRule{
+			{
+				APIGroups: []string{
+					virtualrouter.GroupName,
+				},
+				Resources: []string{
+					"natrules", "firewallrules", "loadbalancerrules",
+				},
+				Verbs: []string{
+					"get", "list", "watch", "create", "update", "patch", "delete",
+				},
+			},
+			{
+				APIGroups: []string{
+					networkGroupName,
+				},
+				Resources: []string{
+					"vpns",
+				},
+				Verbs: []string{
+					"get", "list", "watch",
+				},
+			},
+		},
+	}
+}
+
+// LoadRouterRoleConfig reads a RouterRoleConfig from a YAML file. Fields the
+// file does not set keep their default, so that an empty path or file
+// results in DefaultRouterRoleConfig.
+func LoadRouterRoleConfig(path string) (*RouterRoleConfig, error) {
+	config := DefaultRouterRoleConfig()
+	if path == "" {
+		return config, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf("invalid router role config %s: %v", path, err)
+	}
+	return config, nil
+}
+
+// rulesFor returns the rules of the Role of the VirtualRouter, or an error if
+// one of its additional rules is not allowed.
+func (r *RouterRoleConfig) rulesFor(virtualRouter *samplev1alpha1.VirtualRouter) ([]rbac_v1.PolicyRule, error) {
+	rules, ok := r.NamespaceRules[virtualRouter.Namespace]
+	if !ok {
+		rules = r.Rules
+	}
+	var notAllowed []string
+	for _, rule := range virtualRouter.Spec.AdditionalRoleRules {
+		if !r.allows(rule) {
+			notAllowed = append(notAllowed, rule.String())
+		}
+	}
+	if len(notAllowed) != 0 {
+		return nil, fmt.Errorf("additional role rules are not allowed: %s", strings.Join(notAllowed, ", "))
+	}
+
+	result := make([]rbac_v1.PolicyRule, 0, len(rules)+len(virtualRouter.Spec.AdditionalRoleRules))
+	result = append(result, rules...)
+	return append(result, virtualRouter.Spec.AdditionalRoleRules...), nil
+}
+
+// allows reports whether every verb on every resource of rule is granted by
+// a single allowed rule. Rules of non-resource URLs, which a Role cannot
+// grant, are never allowed.
+func (r *RouterRoleConfig) allows(rule rbac_v1.PolicyRule) bool {
+	if len(rule.NonResourceURLs) != 0 || len(rule.APIGroups) == 0 || len(rule.Resources) == 0 || len(rule.Verbs) == 0 {
+		return false
+	}
+	for _, group := range rule.APIGroups {
+		for _, resource := range rule.Resources {
+			for _, verb := range rule.Verbs {
+				if !r.allowsOne(group, resource, verb, rule.ResourceNames) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func (r *RouterRoleConfig) allowsOne(group string, resource string, verb string, resourceNames []string) bool {
+	for _, allowed := range r.AllowedRules {
+		if !matches(allowed.APIGroups, group) || !matches(allowed.Resources, resource) || !matches(allowed.Verbs, verb) {
+			continue
+		}
+		// An allowed rule limited to some objects only allows rules limited
+		// to those objects
+		if len(allowed.ResourceNames) == 0 {
+			return true
+		}
+		if len(resourceNames) == 0 {
+			continue
+		}
+		covered := true
+		for _, name := range resourceNames {
+			if !containsString(allowed.ResourceNames, name) {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether values contain value or the wildcard.
+func matches(values []string, value string) bool {
+	return containsString(values, rbac_v1.ResourceAll) || containsString(values, value)
+}
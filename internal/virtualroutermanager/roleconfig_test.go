@@ -0,0 +1,129 @@
+package virtualroutermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkcontroller "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+func TestLoadRouterRoleConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "roleconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yaml")
+	data := `
+allowedRules:
+- apiGroups: [""]
+  resources: [configmaps]
+  verbs: [get, list, watch]
+`
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadRouterRoleConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The rules which are not set keep their default
+	if !reflect.DeepEqual(config.Rules, DefaultRouterRoleConfig().Rules) {
+		t.Errorf("expected the default rules, got %v", config.Rules)
+	}
+	if len(config.AllowedRules) != 1 || config.AllowedRules[0].Resources[0] != "configmaps" {
+		t.Errorf("expected configmaps to be allowed, got %v", config.AllowedRules)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("rule: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRouterRoleConfig(path); err == nil {
+		t.Error("expected unknown fields to be rejected")
+	}
+}
+
+func TestRulesFor(t *testing.T) {
+	readVpns := rbacv1.PolicyRule{APIGroups: []string{networkGroupName}, Resources: []string{"vpns"}, Verbs: []string{"get"}}
+	config := &RouterRoleConfig{
+		Rules: DefaultRouterRoleConfig().Rules,
+		NamespaceRules: map[string][]rbacv1.PolicyRule{
+			"restricted": {readVpns},
+		},
+		AllowedRules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get", "list", "watch"}},
+			{APIGroups: []string{"dhcp.tmax.io"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}, ResourceNames: []string{"router-cert"}},
+		},
+	}
+
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	if rules, err := config.rulesFor(virtualRouter); err != nil || !reflect.DeepEqual(rules, config.Rules) {
+		t.Errorf("expected the configured rules, got %v (%v)", rules, err)
+	}
+	virtualRouter.Namespace = "restricted"
+	if rules, err := config.rulesFor(virtualRouter); err != nil || !reflect.DeepEqual(rules, []rbacv1.PolicyRule{readVpns}) {
+		t.Errorf("expected the rules of the namespace, got %v (%v)", rules, err)
+	}
+
+	testCases := []struct {
+		name    string
+		rule    rbacv1.PolicyRule
+		allowed bool
+	}{
+		{"subset of verbs", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get", "watch"}}, true},
+		{"wildcards", rbacv1.PolicyRule{APIGroups: []string{"dhcp.tmax.io"}, Resources: []string{"leases", "pools"}, Verbs: []string{"delete"}}, true},
+		{"allowed names", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}, ResourceNames: []string{"router-cert"}}, true},
+		{"verb not allowed", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get", "delete"}}, false},
+		{"wildcard not allowed", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"*"}, Verbs: []string{"get"}}, false},
+		{"every name", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}}, false},
+		{"other name", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}, ResourceNames: []string{"router-cert", "admin"}}, false},
+	}
+	for _, tc := range testCases {
+		virtualRouter.Spec.AdditionalRoleRules = []rbacv1.PolicyRule{tc.rule}
+		rules, err := config.rulesFor(virtualRouter)
+		if !tc.allowed {
+			if err == nil {
+				t.Errorf("%s: expected the rule to be rejected, got %v", tc.name, rules)
+			}
+			continue
+		}
+		if expected := []rbacv1.PolicyRule{readVpns, tc.rule}; err != nil || !reflect.DeepEqual(rules, expected) {
+			t.Errorf("%s: expected %v, got %v (%v)", tc.name, expected, rules, err)
+		}
+	}
+}
+
+func TestAdditionalRoleRuleNotAllowed(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Spec.AdditionalRoleRules = []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}}}
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.objects = append(f.objects, virtualRouter)
+	newNS := virtualRouter.Status.Namespace
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
+
+	_, err := DefaultRouterRoleConfig().rulesFor(virtualRouter)
+	if err == nil {
+		t.Fatal("expected additional rules not to be allowed by default")
+	}
+	expVirtualRouter := virtualRouter.DeepCopy()
+	expVirtualRouter.Status.Conditions = []metav1.Condition{
+		newCondition(networkcontroller.ConditionNamespaceReady, metav1.ConditionTrue, ReasonNamespaceCreated, ""),
+		newCondition(networkcontroller.ConditionRBACReady, metav1.ConditionFalse, ReasonRoleRulesNotAllowed, err.Error()),
+		newCondition(networkcontroller.ConditionNetworkAttached, metav1.ConditionUnknown, ReasonWaitingForDaemon, MessageWaitingForDaemon),
+	}
+	expVirtualRouter.Status.Conditions = append(expVirtualRouter.Status.Conditions, degradedCondition(expVirtualRouter.Status.Conditions))
+
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.expectUpdateVirtualRouterStatusAction(expVirtualRouter)
+	f.run(getKey(virtualRouter, t))
+}
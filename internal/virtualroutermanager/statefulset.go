@@ -0,0 +1,129 @@
+package virtualroutermanager
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	samplev1alpha1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+// ensureVirtualRouterStatefulSet creates the router StatefulSet of a
+// VirtualRouter with the StatefulSet workload kind, or updates it when it
+// drifted. On failure it also returns the reason reported in the
+// DeploymentAvailable condition.
+func (c *Controller) ensureVirtualRouterStatefulSet(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) (*appsv1.StatefulSet, string, error) {
+	desired := newStatefulSet(newNS, virtualRouter)
+	statefulSet, err := c.statefulSetsLister.StatefulSets(newNS).Get(desired.Name)
+	if errors.IsNotFound(err) {
+		statefulSet, err = c.kubeclientset.AppsV1().StatefulSets(newNS).Create(context.TODO(), desired, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return nil, ReasonDeploymentFailed, err
+	}
+
+	if !metav1.IsControlledBy(statefulSet, virtualRouter) {
+		msg := fmt.Sprintf(MessageResourceExists, statefulSet.Name)
+		c.recorder.Event(virtualRouter, corev1.EventTypeWarning, ErrResourceExists, msg)
+		return nil, ReasonDeploymentNotOwned, fmt.Errorf(msg)
+	}
+
+	// Drift is handled as for the router Deployment
+	updated := mergeStatefulSet(statefulSet, desired)
+	if equality.Semantic.DeepEqual(statefulSet, updated) {
+		return statefulSet, "", nil
+	}
+	klog.V(4).Infof("VirtualRouter %s: statefulset %s/%s drifted from the desired state", virtualRouter.Name, newNS, desired.Name)
+	statefulSet, err = c.kubeclientset.AppsV1().StatefulSets(newNS).Update(context.TODO(), updated, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, ReasonDeploymentFailed, err
+	}
+	return statefulSet, "", nil
+}
+
+// newStatefulSet creates the router StatefulSet, named after
+// spec.deploymentName, with the pod template of the router Deployment.
+func newStatefulSet(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) *appsv1.StatefulSet {
+	deployment := newDeployment(newNS, virtualRouter)
+	return &appsv1.StatefulSet{
+		ObjectMeta: deployment.ObjectMeta,
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: deployment.Spec.Replicas,
+			Selector: deployment.Spec.Selector,
+			Template: deployment.Spec.Template,
+			// The router pods only need stable names, not DNS records, so the
+			// governing Service is not created. The StatefulSet controller
+			// creates the pods whether the Service exists or not.
+			ServiceName: virtualRouter.Spec.DeploymentName,
+			// A router replica does not depend on the lower ordinals
+			PodManagementPolicy: appsv1.ParallelPodManagement,
+		},
+	}
+}
+
+// mergeStatefulSet is mergeDeployment for the router StatefulSet. The
+// selector, service name and pod management policy of a StatefulSet cannot be
+// updated and are left as they are.
+func mergeStatefulSet(live *appsv1.StatefulSet, desired *appsv1.StatefulSet) *appsv1.StatefulSet {
+	merged := live.DeepCopy()
+	merged.Labels = mergeStringMap(merged.Labels, desired.Labels)
+	merged.Annotations = mergeStringMap(merged.Annotations, desired.Annotations)
+	merged.OwnerReferences = desired.OwnerReferences
+	if desired.Spec.Replicas != nil {
+		merged.Spec.Replicas = desired.Spec.Replicas
+	}
+	mergePodTemplate(&merged.Spec.Template, &desired.Spec.Template, live.Annotations[POD_TEMPLATE_HASH_ANNOTATION] != desired.Annotations[POD_TEMPLATE_HASH_ANNOTATION])
+	return merged
+}
+
+// deleteOtherWorkloads deletes the router Deployments of a VirtualRouter with
+// the StatefulSet workload kind, or its router StatefulSets otherwise, which
+// are left behind when workloadKind changed. The pods of both would carry the
+// same labels and addresses, so the old ones are deleted before the new
+// workload is created.
+func (c *Controller) deleteOtherWorkloads(newNS string, virtualRouter *samplev1alpha1.VirtualRouter) error {
+	if virtualRouter.Spec.WorkloadKind == samplev1alpha1.WorkloadKindStatefulSet {
+		deployments, err := c.deploymentsLister.Deployments(newNS).List(labels.Everything())
+		if err != nil {
+			return err
+		}
+		for _, deployment := range deployments {
+			if !metav1.IsControlledBy(deployment, virtualRouter) {
+				continue
+			}
+			klog.Infof("VirtualRouter %s/%s: deleting deployment %s/%s of the previous workload kind", virtualRouter.Namespace, virtualRouter.Name, newNS, deployment.Name)
+			err := c.kubeclientset.AppsV1().Deployments(newNS).Delete(context.TODO(), deployment.Name, metav1.DeleteOptions{
+				Preconditions: metav1.NewUIDPreconditions(string(deployment.UID)),
+			})
+			if err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	statefulSets, err := c.statefulSetsLister.StatefulSets(newNS).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, statefulSet := range statefulSets {
+		if !metav1.IsControlledBy(statefulSet, virtualRouter) {
+			continue
+		}
+		klog.Infof("VirtualRouter %s/%s: deleting statefulset %s/%s of the previous workload kind", virtualRouter.Namespace, virtualRouter.Name, newNS, statefulSet.Name)
+		err := c.kubeclientset.AppsV1().StatefulSets(newNS).Delete(context.TODO(), statefulSet.Name, metav1.DeleteOptions{
+			Preconditions: metav1.NewUIDPreconditions(string(statefulSet.UID)),
+		})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
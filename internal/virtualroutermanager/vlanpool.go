@@ -0,0 +1,385 @@
+package virtualroutermanager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	samplev1alpha1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+// ensureVirtualRouterVlan claims the VLAN of a VirtualRouter from the
+// VlanPool it names or, without one, from the first pool containing the VLAN,
+// and allocates a VLAN when the VirtualRouter names a pool but no VLAN. The
+// VLANs the VirtualRouter no longer uses are released and the claim is
+// recorded in its status. It returns the updated VirtualRouter or, on failure,
+// the reason reported in the VlanAllocated condition.
+func (c *Controller) ensureVirtualRouterVlan(virtualRouter *samplev1alpha1.VirtualRouter) (*samplev1alpha1.VirtualRouter, string, error) {
+	vlan := virtualRouter.Spec.VlanNumber
+	poolName := virtualRouter.Spec.VlanPool
+	if poolName == "" && vlan != 0 {
+		poolName = c.vlanPoolContaining(vlan)
+	}
+
+	var allocation *samplev1alpha1.VlanAllocation
+	if poolName != "" {
+		claimed, reason, err := c.claimVlan(virtualRouter, poolName, vlan)
+		if err != nil {
+			return nil, reason, err
+		}
+		allocation = &samplev1alpha1.VlanAllocation{Pool: poolName, VlanNumber: claimed}
+	} else if vlan != 0 {
+		// A VLAN outside of every pool belongs to the VirtualRouter which
+		// used it first
+		if owner := c.vlanOwner(virtualRouter, vlan); owner != nil {
+			return nil, ReasonVlanConflict, fmt.Errorf("VLAN %d is already used by VirtualRouter %s/%s", vlan, owner.Namespace, owner.Name)
+		}
+	}
+
+	if err := c.releaseVlans(virtualRouter, poolName); err != nil {
+		return nil, ReasonVlanAllocationFailed, err
+	}
+	updated, err := c.updateVlanAllocation(virtualRouter, allocation)
+	if err != nil {
+		return nil, ReasonVlanAllocationFailed, err
+	}
+	return updated, "", nil
+}
+
+// claimVlan claims vlan of VlanPool poolName for the namespace of a
+// VirtualRouter, or the VLAN the VirtualRouter already holds in the pool, or
+// else the first free one, if vlan is 0. A VLAN claimed by or reserved for
+// another namespace is a conflict. The claim is recorded in the status of the
+// pool, whose resource version makes sure two namespaces never claim the same
+// VLAN.
+func (c *Controller) claimVlan(virtualRouter *samplev1alpha1.VirtualRouter, poolName string, vlan int32) (int32, string, error) {
+	var claimed int32
+	var reason string
+	firstTry := true
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		reason = ReasonVlanAllocationFailed
+		var pool *samplev1alpha1.VlanPool
+		var err error
+		if firstTry {
+			pool, err = c.vlanPoolsLister.Get(poolName)
+		} else {
+			pool, err = c.sampleclientset.TmaxV1().VlanPools().Get(context.TODO(), poolName, metav1.GetOptions{})
+		}
+		firstTry = false
+		if err != nil {
+			return err
+		}
+
+		current := findVlanPoolAllocation(pool.Status.Allocations, virtualRouter)
+		switch {
+		case vlan == 0 && current != nil:
+			claimed = current.VlanNumber
+			return nil
+		case vlan == 0:
+			if claimed, err = nextFreeVlan(pool, virtualRouter.Namespace, c.vlansInUse(pool.Name)); err != nil {
+				return err
+			}
+		default:
+			if !pool.Spec.Contains(vlan) {
+				return fmt.Errorf("VLAN %d is not in VlanPool %q", vlan, pool.Name)
+			}
+			for _, allocated := range pool.Status.Allocations {
+				if allocated.VlanNumber == vlan && allocated.Namespace != virtualRouter.Namespace {
+					reason = ReasonVlanConflict
+					return fmt.Errorf("VLAN %d of VlanPool %q is owned by namespace %q", vlan, pool.Name, allocated.Namespace)
+				}
+			}
+			if namespace := pool.Spec.ReservedFor(vlan); namespace != "" && namespace != virtualRouter.Namespace {
+				reason = ReasonVlanConflict
+				return fmt.Errorf("VLAN %d of VlanPool %q is reserved for namespace %q", vlan, pool.Name, namespace)
+			}
+			claimed = vlan
+			if current != nil && current.VlanNumber == vlan {
+				return nil
+			}
+		}
+
+		poolCopy := pool.DeepCopy()
+		poolCopy.Status.Allocations = withVlanClaim(poolCopy.Status.Allocations, virtualRouter, claimed)
+		poolCopy.Status.Allocated = int32(len(poolCopy.Status.Allocations))
+		if _, err := c.sampleclientset.TmaxV1().VlanPools().UpdateStatus(context.TODO(), poolCopy, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		klog.Infof("Claimed VLAN %d of VlanPool %s for VirtualRouter %s/%s", claimed, pool.Name, virtualRouter.Namespace, virtualRouter.Name)
+		return nil
+	})
+	return claimed, reason, err
+}
+
+// releaseVlans removes a VirtualRouter from the VLANs it claimed in every
+// VlanPool except keep, which is "" once the VirtualRouter is deleted. The
+// pools are looked up in the informer cache, which sees a claim long before
+// the VirtualRouter moves to another pool or is deleted.
+func (c *Controller) releaseVlans(virtualRouter *samplev1alpha1.VirtualRouter, keep string) error {
+	pools, err := c.vlanPoolsLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, pool := range pools {
+		if pool.Name == keep || findVlanPoolAllocation(pool.Status.Allocations, virtualRouter) == nil {
+			continue
+		}
+		if err := c.releaseVlan(virtualRouter, pool.Name); err != nil {
+			return fmt.Errorf("failed to release VLAN from VlanPool %q: %v", pool.Name, err)
+		}
+	}
+	return nil
+}
+
+// releaseVlan removes a VirtualRouter from the status of VlanPool poolName.
+// A VLAN no VirtualRouter uses anymore no longer belongs to their namespace.
+func (c *Controller) releaseVlan(virtualRouter *samplev1alpha1.VirtualRouter, poolName string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pool, err := c.sampleclientset.TmaxV1().VlanPools().Get(context.TODO(), poolName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		current := findVlanPoolAllocation(pool.Status.Allocations, virtualRouter)
+		if current == nil {
+			return nil
+		}
+		klog.Infof("Releasing VLAN %d of VirtualRouter %s/%s to VlanPool %s", current.VlanNumber, virtualRouter.Namespace, virtualRouter.Name, pool.Name)
+		poolCopy := pool.DeepCopy()
+		poolCopy.Status.Allocations = withoutVirtualRouter(poolCopy.Status.Allocations, virtualRouter)
+		poolCopy.Status.Allocated = int32(len(poolCopy.Status.Allocations))
+		_, err = c.sampleclientset.TmaxV1().VlanPools().UpdateStatus(context.TODO(), poolCopy, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// updateVlanAllocation records allocation in the status of a VirtualRouter,
+// together with the VlanAllocated condition, which is only reported for
+// VirtualRouters claiming a VLAN from a VlanPool.
+func (c *Controller) updateVlanAllocation(virtualRouter *samplev1alpha1.VirtualRouter, allocation *samplev1alpha1.VlanAllocation) (*samplev1alpha1.VirtualRouter, error) {
+	latest := virtualRouter
+	firstTry := true
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if !firstTry {
+			var err error
+			latest, err = c.sampleclientset.TmaxV1().VirtualRouters(virtualRouter.Namespace).Get(context.TODO(), virtualRouter.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+		}
+		firstTry = false
+
+		virtualRouterCopy := latest.DeepCopy()
+		virtualRouterCopy.Status.VlanAllocation = allocation
+		if allocation == nil {
+			// RemoveStatusCondition of apimachinery 0.19 panics when the
+			// condition is missing
+			if meta.FindStatusCondition(virtualRouterCopy.Status.Conditions, samplev1alpha1.ConditionVlanAllocated) != nil {
+				meta.RemoveStatusCondition(&virtualRouterCopy.Status.Conditions, samplev1alpha1.ConditionVlanAllocated)
+			}
+		} else {
+			condition := newCondition(samplev1alpha1.ConditionVlanAllocated, metav1.ConditionTrue, ReasonVlanAllocated,
+				fmt.Sprintf("VLAN %d from %s", allocation.VlanNumber, allocation.Pool))
+			condition.ObservedGeneration = virtualRouter.Generation
+			meta.SetStatusCondition(&virtualRouterCopy.Status.Conditions, condition)
+		}
+		if equality.Semantic.DeepEqual(latest.Status, virtualRouterCopy.Status) {
+			return nil
+		}
+		updated, err := c.sampleclientset.TmaxV1().VirtualRouters(virtualRouter.Namespace).UpdateStatus(context.TODO(), virtualRouterCopy, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		latest = updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return latest, nil
+}
+
+// vlanPoolContaining returns the first VlanPool, by name, whose ranges
+// contain vlan, or "".
+func (c *Controller) vlanPoolContaining(vlan int32) string {
+	pools, err := c.vlanPoolsLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return ""
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Name < pools[j].Name })
+	for _, pool := range pools {
+		if pool.Spec.Contains(vlan) {
+			return pool.Name
+		}
+	}
+	return ""
+}
+
+// vlanOwner returns the oldest VirtualRouter of another namespace using vlan,
+// if it was created before virtualRouter. The VirtualRouter informer is
+// cluster-wide, so the namespaces which are not watched are included.
+func (c *Controller) vlanOwner(virtualRouter *samplev1alpha1.VirtualRouter, vlan int32) *samplev1alpha1.VirtualRouter {
+	virtualRouters, err := c.virtualRoutersLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return nil
+	}
+	owner := virtualRouter
+	for _, other := range virtualRouters {
+		if other.Namespace == virtualRouter.Namespace || vlanOf(other) != vlan {
+			continue
+		}
+		if createdBefore(other, owner) {
+			owner = other
+		}
+	}
+	if owner == virtualRouter {
+		return nil
+	}
+	return owner
+}
+
+// vlansInUse returns the VLANs which VlanPool poolName must not hand out:
+// those claimed from the other pools, which may overlap with it, and those
+// used by VirtualRouters in any namespace.
+func (c *Controller) vlansInUse(poolName string) map[int32]bool {
+	inUse := map[int32]bool{}
+	pools, err := c.vlanPoolsLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+	for _, pool := range pools {
+		if pool.Name == poolName {
+			continue
+		}
+		for _, allocated := range pool.Status.Allocations {
+			inUse[allocated.VlanNumber] = true
+		}
+	}
+	virtualRouters, err := c.virtualRoutersLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+	for _, virtualRouter := range virtualRouters {
+		inUse[vlanOf(virtualRouter)] = true
+	}
+	return inUse
+}
+
+// nextFreeVlan returns the first VLAN of pool which is neither claimed from
+// it nor in inUse. The VLANs reserved for namespace are handed out first, and
+// those reserved for other namespaces never.
+func nextFreeVlan(pool *samplev1alpha1.VlanPool, namespace string, inUse map[int32]bool) (int32, error) {
+	claimed := map[int32]bool{}
+	for _, allocation := range pool.Status.Allocations {
+		claimed[allocation.VlanNumber] = true
+	}
+	vlans := pool.Spec.List()
+	for _, reserved := range []string{namespace, ""} {
+		for _, vlan := range vlans {
+			if claimed[vlan] || inUse[vlan] || pool.Spec.ReservedFor(vlan) != reserved {
+				continue
+			}
+			return vlan, nil
+		}
+	}
+	return 0, fmt.Errorf("no free VLAN left in VlanPool %q", pool.Name)
+}
+
+// handleVlanPool enqueues the VirtualRouters which use a VlanPool or one of
+// its VLANs, so that those waiting for it to be created or to free a VLAN are
+// synced.
+func (c *Controller) handleVlanPool(obj interface{}) {
+	pool, ok := obj.(*samplev1alpha1.VlanPool)
+	if !ok {
+		return
+	}
+	virtualRouters, err := c.virtualRoutersLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, virtualRouter := range virtualRouters {
+		if virtualRouter.Spec.VlanPool == pool.Name || (virtualRouter.Spec.VlanNumber != 0 && pool.Spec.Contains(virtualRouter.Spec.VlanNumber)) {
+			c.enqueueVirtualRouter(virtualRouter)
+		}
+	}
+}
+
+// vlanOf returns the VLAN a VirtualRouter uses, or 0.
+func vlanOf(virtualRouter *samplev1alpha1.VirtualRouter) int32 {
+	if virtualRouter.Spec.VlanNumber == 0 && virtualRouter.Status.VlanAllocation != nil {
+		return virtualRouter.Status.VlanAllocation.VlanNumber
+	}
+	return virtualRouter.Spec.VlanNumber
+}
+
+// createdBefore orders VirtualRouters by creation, and by namespace and name
+// when they were created within the same second.
+func createdBefore(a, b *samplev1alpha1.VirtualRouter) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+func findVlanPoolAllocation(allocations []samplev1alpha1.VlanPoolAllocation, virtualRouter *samplev1alpha1.VirtualRouter) *samplev1alpha1.VlanPoolAllocation {
+	for i := range allocations {
+		if allocations[i].Namespace == virtualRouter.Namespace && containsString(allocations[i].VirtualRouters, virtualRouter.Name) {
+			return &allocations[i]
+		}
+	}
+	return nil
+}
+
+// withoutVirtualRouter returns allocations without a VirtualRouter, dropping
+// the VLANs no other VirtualRouter uses.
+func withoutVirtualRouter(allocations []samplev1alpha1.VlanPoolAllocation, virtualRouter *samplev1alpha1.VirtualRouter) []samplev1alpha1.VlanPoolAllocation {
+	var remaining []samplev1alpha1.VlanPoolAllocation
+	for _, allocation := range allocations {
+		if allocation.Namespace == virtualRouter.Namespace {
+			allocation.VirtualRouters = removeString(allocation.VirtualRouters, virtualRouter.Name)
+			if len(allocation.VirtualRouters) == 0 {
+				continue
+			}
+		}
+		remaining = append(remaining, allocation)
+	}
+	return remaining
+}
+
+// withVlanClaim returns allocations with a VirtualRouter moved to vlan,
+// ordered by VLAN.
+func withVlanClaim(allocations []samplev1alpha1.VlanPoolAllocation, virtualRouter *samplev1alpha1.VirtualRouter, vlan int32) []samplev1alpha1.VlanPoolAllocation {
+	allocations = withoutVirtualRouter(allocations, virtualRouter)
+	for i := range allocations {
+		if allocations[i].VlanNumber == vlan {
+			allocations[i].VirtualRouters = append(allocations[i].VirtualRouters, virtualRouter.Name)
+			sort.Strings(allocations[i].VirtualRouters)
+			return allocations
+		}
+	}
+	allocations = append(allocations, samplev1alpha1.VlanPoolAllocation{
+		VlanNumber:     vlan,
+		Namespace:      virtualRouter.Namespace,
+		VirtualRouters: []string{virtualRouter.Name},
+	})
+	sort.Slice(allocations, func(i, j int) bool { return allocations[i].VlanNumber < allocations[j].VlanNumber })
+	return allocations
+}
@@ -0,0 +1,180 @@
+package virtualroutermanager
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	core "k8s.io/client-go/testing"
+
+	networkcontroller "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+func newVlanPool(name string, ranges []string, allocations ...networkcontroller.VlanPoolAllocation) *networkcontroller.VlanPool {
+	return &networkcontroller.VlanPool{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       networkcontroller.VlanPoolSpec{Ranges: ranges},
+		Status: networkcontroller.VlanPoolStatus{
+			Allocated:   int32(len(allocations)),
+			Allocations: allocations,
+		},
+	}
+}
+
+func (f *fixture) expectUpdateVlanPoolStatusAction(pool *networkcontroller.VlanPool) {
+	f.actions = append(f.actions, core.NewRootUpdateSubresourceAction(schema.GroupVersionResource{Resource: "vlanpools"}, "status", pool))
+}
+
+func TestNextFreeVlan(t *testing.T) {
+	pool := newVlanPool("pool", []string{"100-103"}, networkcontroller.VlanPoolAllocation{VlanNumber: 100, Namespace: "a", VirtualRouters: []string{"vr"}})
+	pool.Spec.Reservations = []networkcontroller.VlanReservation{
+		{Namespace: "b", Ranges: []string{"101"}},
+		{Namespace: "c", Ranges: []string{"103"}},
+	}
+
+	// Reserved VLANs are handed out first
+	if vlan, err := nextFreeVlan(pool, "c", nil); err != nil || vlan != 103 {
+		t.Errorf("expected 103, got %d (%v)", vlan, err)
+	}
+	// and never to another namespace
+	if vlan, err := nextFreeVlan(pool, "a", nil); err != nil || vlan != 102 {
+		t.Errorf("expected 102, got %d (%v)", vlan, err)
+	}
+	if vlan, err := nextFreeVlan(pool, "a", map[int32]bool{102: true}); err == nil {
+		t.Errorf("expected the pool to be exhausted, got %d", vlan)
+	}
+}
+
+func TestAllocatesVlanFromPool(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Spec.VlanPool = "tenants"
+	// A VLAN of the pool is claimed by another namespace, another one is
+	// reserved for it
+	claimed := networkcontroller.VlanPoolAllocation{VlanNumber: 100, Namespace: "other", VirtualRouters: []string{"test"}}
+	pool := newVlanPool("tenants", []string{"100-102"}, claimed)
+	pool.Spec.Reservations = []networkcontroller.VlanReservation{{Namespace: "other", Ranges: []string{"101"}}}
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.vlanPoolLister = append(f.vlanPoolLister, pool)
+	f.objects = append(f.objects, virtualRouter, pool)
+
+	newNS := virtualRouter.Status.Namespace
+	f.kubeobjects = append(f.kubeobjects, newGeneratedResources(virtualRouter)...)
+	expDeployment := newDeployment(newNS, virtualRouter)
+
+	expPool := pool.DeepCopy()
+	expPool.Status.Allocations = append(expPool.Status.Allocations, networkcontroller.VlanPoolAllocation{
+		VlanNumber: 102, Namespace: virtualRouter.Namespace, VirtualRouters: []string{virtualRouter.Name},
+	})
+	expPool.Status.Allocated = 2
+	allocated := virtualRouter.DeepCopy()
+	allocated.Status.VlanAllocation = &networkcontroller.VlanAllocation{Pool: "tenants", VlanNumber: 102}
+	vlanAllocated := newCondition(networkcontroller.ConditionVlanAllocated, metav1.ConditionTrue, ReasonVlanAllocated, "VLAN 102 from tenants")
+	allocated.Status.Conditions = []metav1.Condition{vlanAllocated}
+	expVirtualRouter := withStatus(allocated, expDeployment)
+	expVirtualRouter.Status.Conditions = append([]metav1.Condition{vlanAllocated}, expVirtualRouter.Status.Conditions...)
+
+	f.expectUpdateVlanPoolStatusAction(expPool)
+	f.expectUpdateVirtualRouterStatusAction(allocated)
+	f.expectGetGeneratedResourcesActions(newNS)
+	f.expectCreateDeploymentAction(expDeployment)
+	f.expectUpdateVirtualRouterStatusAction(expVirtualRouter)
+
+	f.run(getKey(virtualRouter, t))
+}
+
+func TestVlanOwnedByAnotherTenant(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Spec.VlanNumber = 100
+	pool := newVlanPool("tenants", []string{"100-102"}, networkcontroller.VlanPoolAllocation{
+		VlanNumber: 100, Namespace: "other", VirtualRouters: []string{"test"},
+	})
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.vlanPoolLister = append(f.vlanPoolLister, pool)
+	f.objects = append(f.objects, virtualRouter, pool)
+
+	expVirtualRouter := virtualRouter.DeepCopy()
+	expVirtualRouter.Status.Conditions = []metav1.Condition{
+		newCondition(networkcontroller.ConditionVlanAllocated, metav1.ConditionFalse, ReasonVlanConflict,
+			`VLAN 100 of VlanPool "tenants" is owned by namespace "other"`),
+		newCondition(networkcontroller.ConditionNetworkAttached, metav1.ConditionUnknown, ReasonWaitingForDaemon, MessageWaitingForDaemon),
+	}
+	expVirtualRouter.Status.Conditions = append(expVirtualRouter.Status.Conditions, degradedCondition(expVirtualRouter.Status.Conditions))
+
+	f.expectUpdateVirtualRouterStatusAction(expVirtualRouter)
+	f.runExpectError(getKey(virtualRouter, t))
+}
+
+func TestVlanConflictOutsideOfPools(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	virtualRouter.Spec.VlanNumber = 300
+	virtualRouter.CreationTimestamp = metav1.Now()
+	older := newVirtualRouter("older", int32Ptr(1))
+	older.Namespace = "other"
+	older.Spec.VlanNumber = 300
+	older.CreationTimestamp = metav1.NewTime(virtualRouter.CreationTimestamp.Add(-time.Hour))
+
+	// Only the namespace of the manager is reconciled, as by default, but the
+	// VirtualRouters of the other namespaces are still known
+	f.watchNamespaces = []string{virtualRouter.Namespace}
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter, older)
+	f.objects = append(f.objects, virtualRouter)
+
+	expVirtualRouter := virtualRouter.DeepCopy()
+	expVirtualRouter.Status.Conditions = []metav1.Condition{
+		newCondition(networkcontroller.ConditionVlanAllocated, metav1.ConditionFalse, ReasonVlanConflict,
+			"VLAN 300 is already used by VirtualRouter other/older"),
+		newCondition(networkcontroller.ConditionNetworkAttached, metav1.ConditionUnknown, ReasonWaitingForDaemon, MessageWaitingForDaemon),
+	}
+	expVirtualRouter.Status.Conditions = append(expVirtualRouter.Status.Conditions, degradedCondition(expVirtualRouter.Status.Conditions))
+
+	f.expectUpdateVirtualRouterStatusAction(expVirtualRouter)
+	f.runExpectError(getKey(virtualRouter, t))
+}
+
+func TestVlansInUseInEveryNamespace(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	other := newVirtualRouter("other", int32Ptr(1))
+	other.Namespace = "other"
+	other.Spec.VlanNumber = 101
+
+	f.watchNamespaces = []string{virtualRouter.Namespace}
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter, other)
+	c, _, _ := f.newController()
+	if inUse := c.vlansInUse("tenants"); !inUse[101] {
+		t.Errorf("expected the VLAN of %s to be in use, got %v", getKey(other, t), inUse)
+	}
+}
+
+func TestReleasesVlanOnceNamespaceIsGone(t *testing.T) {
+	f := newFixture(t)
+	virtualRouter := newVirtualRouter("test", int32Ptr(1))
+	now := metav1.Now()
+	virtualRouter.DeletionTimestamp = &now
+	virtualRouter.Spec.VlanPool = "tenants"
+	virtualRouter.Status.VlanAllocation = &networkcontroller.VlanAllocation{Pool: "tenants", VlanNumber: 100}
+	kept := networkcontroller.VlanPoolAllocation{VlanNumber: 101, Namespace: "other", VirtualRouters: []string{"test"}}
+	pool := newVlanPool("tenants", []string{"100-102"}, networkcontroller.VlanPoolAllocation{
+		VlanNumber: 100, Namespace: virtualRouter.Namespace, VirtualRouters: []string{virtualRouter.Name},
+	}, kept)
+	newNS := virtualRouter.Status.Namespace
+
+	f.virtualRouterLister = append(f.virtualRouterLister, virtualRouter)
+	f.vlanPoolLister = append(f.vlanPoolLister, pool)
+	f.objects = append(f.objects, virtualRouter, pool)
+
+	expPool := newVlanPool("tenants", []string{"100-102"}, kept)
+	expVirtualRouter := virtualRouter.DeepCopy()
+	expVirtualRouter.Finalizers = nil
+	f.kubeactions = append(f.kubeactions, core.NewRootGetAction(schema.GroupVersionResource{Resource: "namespaces"}, newNS))
+	f.actions = append(f.actions, core.NewRootGetAction(schema.GroupVersionResource{Resource: "vlanpools"}, pool.Name))
+	f.expectUpdateVlanPoolStatusAction(expPool)
+	f.expectUpdateVirtualRouterAction(expVirtualRouter)
+	f.run(getKey(virtualRouter, t))
+}
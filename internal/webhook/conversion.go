@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	samplev1alpha1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+	samplev2 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v2"
+)
+
+const CONVERT_VIRTUALROUTER_PATH = "/convert"
+
+// convertVirtualRouters serves the ConversionReviews of the VirtualRouter CRD.
+func convertVirtualRouters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "application/json" {
+		http.Error(w, fmt.Sprintf("unsupported content type %q", contentType), http.StatusUnsupportedMediaType)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	review := apiextensionsv1.ConversionReview{}
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, fmt.Sprintf("failed to decode ConversionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	response := &apiextensionsv1.ConversionResponse{
+		UID:    review.Request.UID,
+		Result: metav1.Status{Status: metav1.StatusSuccess},
+	}
+	for _, object := range review.Request.Objects {
+		converted, err := convertVirtualRouter(object.Raw, review.Request.DesiredAPIVersion)
+		if err != nil {
+			response.ConvertedObjects = nil
+			response.Result = metav1.Status{Status: metav1.StatusFailure, Message: err.Error()}
+			break
+		}
+		response.ConvertedObjects = append(response.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+	review.Response = response
+	review.Request = nil
+
+	if err := json.NewEncoder(w).Encode(&review); err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to write ConversionReview: %v", err))
+	}
+}
+
+// convertVirtualRouter converts a serialized VirtualRouter to desiredAPIVersion.
+func convertVirtualRouter(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, err
+	}
+	if typeMeta.Kind != "VirtualRouter" {
+		return nil, fmt.Errorf("unexpected kind %q", typeMeta.Kind)
+	}
+	if typeMeta.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+
+	v2 := &samplev2.VirtualRouter{}
+	switch typeMeta.APIVersion {
+	case samplev1alpha1.SchemeGroupVersion.String():
+		v1 := &samplev1alpha1.VirtualRouter{}
+		if err := json.Unmarshal(raw, v1); err != nil {
+			return nil, err
+		}
+		if err := samplev2.ConvertFromV1(v1, v2); err != nil {
+			return nil, err
+		}
+	case samplev2.SchemeGroupVersion.String():
+		if err := json.Unmarshal(raw, v2); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported apiVersion %q", typeMeta.APIVersion)
+	}
+
+	switch desiredAPIVersion {
+	case samplev1alpha1.SchemeGroupVersion.String():
+		v1 := &samplev1alpha1.VirtualRouter{}
+		if err := samplev2.ConvertToV1(v2, v1); err != nil {
+			return nil, err
+		}
+		return json.Marshal(v1)
+	case samplev2.SchemeGroupVersion.String():
+		return json.Marshal(v2)
+	default:
+		return nil, fmt.Errorf("unsupported apiVersion %q", desiredAPIVersion)
+	}
+}
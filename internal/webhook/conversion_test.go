@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	samplev2 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v2"
+)
+
+func TestConvertVirtualRoutersToV2(t *testing.T) {
+	review := apiextensionsv1.ConversionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "ConversionReview"},
+		Request: &apiextensionsv1.ConversionRequest{
+			UID:               "uid",
+			DesiredAPIVersion: samplev2.SchemeGroupVersion.String(),
+			Objects: []runtime.RawExtension{{Raw: []byte(`{
+				"apiVersion": "tmax.hypercloud.com/v1",
+				"kind": "VirtualRouter",
+				"metadata": {"name": "vr1", "namespace": "default"},
+				"spec": {
+					"deploymentName": "vr1-deployment",
+					"vlanNumber": 210,
+					"internalIP": "10.10.10.11",
+					"internalNetmask": "255.255.255.0",
+					"externalIP": "192.168.8.153",
+					"externalNetmask": "255.255.254.0",
+					"gatewayIP": "192.168.8.1",
+					"image": "tmaxcloudck/virtualrouter:v0.1.0"
+				}
+			}`)}},
+		},
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := httptest.NewRequest(http.MethodPost, CONVERT_VIRTUALROUTER_PATH, bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	NewHandler(Defaults{}).ServeHTTP(recorder, request)
+
+	response := apiextensionsv1.ConversionReview{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response %q: %v", recorder.Body.String(), err)
+	}
+	if response.Response == nil || response.Response.UID != "uid" || response.Response.Result.Status != metav1.StatusSuccess {
+		t.Fatalf("unexpected response: %s", recorder.Body.String())
+	}
+	if len(response.Response.ConvertedObjects) != 1 {
+		t.Fatalf("expected 1 converted object, got %d", len(response.Response.ConvertedObjects))
+	}
+
+	virtualRouter := &samplev2.VirtualRouter{}
+	if err := json.Unmarshal(response.Response.ConvertedObjects[0].Raw, virtualRouter); err != nil {
+		t.Fatal(err)
+	}
+	if virtualRouter.APIVersion != samplev2.SchemeGroupVersion.String() || len(virtualRouter.Spec.Attachments) != 2 {
+		t.Errorf("unexpected converted object: %s", response.Response.ConvertedObjects[0].Raw)
+	}
+}
@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+
+	samplev1alpha1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+const (
+	DEFAULT_VIRTUALROUTER_PATH = "/default-virtualrouter"
+	DEFAULT_REPLICAS           = int32(1)
+)
+
+// Defaults holds the cluster-configured defaults applied to VirtualRouters.
+type Defaults struct {
+	// Image is the router image used when spec.image is empty. Empty leaves
+	// spec.image untouched.
+	Image string
+}
+
+// patchOperation is a single JSON patch operation.
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// defaultVirtualRouter fills the fields of a VirtualRouter which are the same
+// for nearly every router.
+func (d Defaults) defaultVirtualRouter(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req.Kind.Kind != "VirtualRouter" {
+		return denied(fmt.Errorf("unexpected kind %q", req.Kind.Kind))
+	}
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return allowed()
+	}
+
+	virtualRouter := &samplev1alpha1.VirtualRouter{}
+	if err := json.Unmarshal(req.Object.Raw, virtualRouter); err != nil {
+		return denied(err)
+	}
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(req.Object.Raw, &raw); err != nil {
+		return denied(err)
+	}
+
+	patch := d.specDefaults(virtualRouter)
+	if len(patch) == 0 {
+		return allowed()
+	}
+	if _, ok := raw["spec"]; !ok {
+		patch = append([]patchOperation{{Op: "add", Path: "/spec", Value: map[string]interface{}{}}}, patch...)
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return denied(err)
+	}
+	patchType := admissionv1.PatchTypeJSONPatch
+	return &admissionv1.AdmissionResponse{Allowed: true, Patch: patchBytes, PatchType: &patchType}
+}
+
+// specDefaults returns the patch operations defaulting the spec of
+// virtualRouter.
+func (d Defaults) specDefaults(virtualRouter *samplev1alpha1.VirtualRouter) []patchOperation {
+	spec := &virtualRouter.Spec
+	patch := []patchOperation{}
+	set := func(field string, value interface{}) {
+		patch = append(patch, patchOperation{Op: "add", Path: "/spec/" + field, Value: value})
+	}
+
+	if spec.DeploymentName == "" && virtualRouter.Name != "" {
+		set("deploymentName", fmt.Sprintf("%s-deployment", virtualRouter.Name))
+	}
+	if spec.Replicas == nil {
+		set("replicas", DEFAULT_REPLICAS)
+	}
+	if spec.Image == "" && d.Image != "" {
+		set("image", d.Image)
+	}
+
+	// internalIP and externalIP may be given in CIDR notation, in which case
+	// the prefix length becomes the netmask
+	if ip, netmask, ok := splitCIDR(spec.InternalIP); ok {
+		set("internalIP", ip)
+		if spec.InternalNetmask == "" {
+			set("internalNetmask", netmask)
+		}
+	}
+	if ip, netmask, ok := splitCIDR(spec.ExternalIP); ok {
+		set("externalIP", ip)
+		if spec.ExternalNetmask == "" {
+			set("externalNetmask", netmask)
+		}
+	}
+	return patch
+}
+
+// splitCIDR splits an IPv4 address in CIDR notation into the address and the
+// dotted netmask.
+func splitCIDR(value string) (string, string, bool) {
+	if !strings.Contains(value, "/") {
+		return "", "", false
+	}
+	ip, ipNet, err := net.ParseCIDR(value)
+	if err != nil || ip.To4() == nil {
+		return "", "", false
+	}
+	return ip.String(), net.IP(ipNet.Mask).String(), true
+}
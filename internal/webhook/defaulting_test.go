@@ -0,0 +1,108 @@
+package webhook
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	samplev1alpha1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+)
+
+// applyDefaults runs the defaulting webhook on raw and returns the patched
+// VirtualRouter.
+func applyDefaults(t *testing.T, d Defaults, raw string) *samplev1alpha1.VirtualRouter {
+	response := d.defaultVirtualRouter(&admissionv1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Group: samplev1alpha1.SchemeGroupVersion.Group, Version: "v1", Kind: "VirtualRouter"},
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: []byte(raw)},
+	})
+	if !response.Allowed {
+		t.Fatalf("request denied: %v", response.Result)
+	}
+
+	patched := []byte(raw)
+	if response.Patch != nil {
+		patch, err := jsonpatch.DecodePatch(response.Patch)
+		if err != nil {
+			t.Fatalf("invalid patch %s: %v", response.Patch, err)
+		}
+		if patched, err = patch.Apply(patched); err != nil {
+			t.Fatalf("failed to apply patch %s: %v", response.Patch, err)
+		}
+	}
+
+	virtualRouter := &samplev1alpha1.VirtualRouter{}
+	if err := json.Unmarshal(patched, virtualRouter); err != nil {
+		t.Fatal(err)
+	}
+	return virtualRouter
+}
+
+func TestDefaultMinimalVirtualRouter(t *testing.T) {
+	virtualRouter := applyDefaults(t, Defaults{Image: "tmaxcloudck/virtualrouter:v0.1.0"}, `{
+		"apiVersion": "tmax.hypercloud.com/v1",
+		"kind": "VirtualRouter",
+		"metadata": {"name": "vr1", "namespace": "default"},
+		"spec": {
+			"vlanNumber": 210,
+			"internalIP": "10.10.10.11/24",
+			"externalIP": "192.168.8.153/23",
+			"gatewayIP": "192.168.8.1"
+		}
+	}`)
+
+	replicas := DEFAULT_REPLICAS
+	expected := samplev1alpha1.VirtualRouterSpec{
+		DeploymentName:  "vr1-deployment",
+		Replicas:        &replicas,
+		VlanNumber:      210,
+		InternalIP:      "10.10.10.11",
+		InternalNetmask: "255.255.255.0",
+		ExternalIP:      "192.168.8.153",
+		ExternalNetmask: "255.255.254.0",
+		GatewayIP:       "192.168.8.1",
+		Image:           "tmaxcloudck/virtualrouter:v0.1.0",
+	}
+	if !reflect.DeepEqual(virtualRouter.Spec, expected) {
+		t.Errorf("unexpected spec\n\texpected %+v\n\tgot      %+v", expected, virtualRouter.Spec)
+	}
+}
+
+func TestDefaultKeepsUserValues(t *testing.T) {
+	raw := `{
+		"apiVersion": "tmax.hypercloud.com/v1",
+		"kind": "VirtualRouter",
+		"metadata": {"name": "vr1", "namespace": "default"},
+		"spec": {
+			"deploymentName": "router",
+			"replicas": 2,
+			"image": "busybox",
+			"internalIP": "10.10.10.11",
+			"internalNetmask": "255.255.0.0"
+		}
+	}`
+	response := Defaults{Image: "tmaxcloudck/virtualrouter:v0.1.0"}.defaultVirtualRouter(&admissionv1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Group: samplev1alpha1.SchemeGroupVersion.Group, Version: "v1", Kind: "VirtualRouter"},
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: []byte(raw)},
+	})
+	if !response.Allowed || response.Patch != nil {
+		t.Errorf("expected no patch, got %s", response.Patch)
+	}
+}
+
+func TestDefaultMissingSpec(t *testing.T) {
+	virtualRouter := applyDefaults(t, Defaults{}, `{
+		"apiVersion": "tmax.hypercloud.com/v1",
+		"kind": "VirtualRouter",
+		"metadata": {"name": "vr1", "namespace": "default"}
+	}`)
+	if virtualRouter.Spec.DeploymentName != "vr1-deployment" || virtualRouter.Spec.Replicas == nil || virtualRouter.Spec.Image != "" {
+		t.Errorf("unexpected spec %+v", virtualRouter.Spec)
+	}
+}
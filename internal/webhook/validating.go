@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	samplev1alpha1 "github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1"
+	"github.com/tmax-cloud/virtualrouter-controller/internal/utils/pkg/apis/networkcontroller/v1/validation"
+)
+
+const (
+	VALIDATE_VIRTUALROUTER_PATH = "/validate-virtualrouter"
+	VALIDATE_IPPOOL_PATH        = "/validate-ippool"
+	VALIDATE_VLANPOOL_PATH      = "/validate-vlanpool"
+)
+
+// validateVirtualRouter rejects VirtualRouters with invalid addressing and
+// updates of immutable fields.
+func validateVirtualRouter(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req.Kind.Kind != "VirtualRouter" {
+		return denied(fmt.Errorf("unexpected kind %q", req.Kind.Kind))
+	}
+
+	virtualRouter := &samplev1alpha1.VirtualRouter{}
+	switch req.Operation {
+	case admissionv1.Create:
+		if err := json.Unmarshal(req.Object.Raw, virtualRouter); err != nil {
+			return denied(err)
+		}
+		if errs := validation.ValidateVirtualRouter(virtualRouter); len(errs) != 0 {
+			return denied(apierrors.NewInvalid(samplev1alpha1.Kind("VirtualRouter"), virtualRouter.Name, errs))
+		}
+	case admissionv1.Update:
+		oldVirtualRouter := &samplev1alpha1.VirtualRouter{}
+		if err := json.Unmarshal(req.Object.Raw, virtualRouter); err != nil {
+			return denied(err)
+		}
+		if err := json.Unmarshal(req.OldObject.Raw, oldVirtualRouter); err != nil {
+			return denied(err)
+		}
+		if errs := validation.ValidateVirtualRouterUpdate(virtualRouter, oldVirtualRouter); len(errs) != 0 {
+			return denied(apierrors.NewInvalid(samplev1alpha1.Kind("VirtualRouter"), virtualRouter.Name, errs))
+		}
+	}
+	return allowed()
+}
+
+// validateIPPool rejects IPPools with an invalid subnet, gateway or
+// exclusions, and changes of the subnet.
+func validateIPPool(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req.Kind.Kind != "IPPool" {
+		return denied(fmt.Errorf("unexpected kind %q", req.Kind.Kind))
+	}
+
+	pool := &samplev1alpha1.IPPool{}
+	switch req.Operation {
+	case admissionv1.Create:
+		if err := json.Unmarshal(req.Object.Raw, pool); err != nil {
+			return denied(err)
+		}
+		if errs := validation.ValidateIPPool(pool); len(errs) != 0 {
+			return denied(apierrors.NewInvalid(samplev1alpha1.Kind("IPPool"), pool.Name, errs))
+		}
+	case admissionv1.Update:
+		oldPool := &samplev1alpha1.IPPool{}
+		if err := json.Unmarshal(req.Object.Raw, pool); err != nil {
+			return denied(err)
+		}
+		if err := json.Unmarshal(req.OldObject.Raw, oldPool); err != nil {
+			return denied(err)
+		}
+		if errs := validation.ValidateIPPoolUpdate(pool, oldPool); len(errs) != 0 {
+			return denied(apierrors.NewInvalid(samplev1alpha1.Kind("IPPool"), pool.Name, errs))
+		}
+	}
+	return allowed()
+}
+
+// validateVlanPool rejects VlanPools with invalid ranges or reservations.
+func validateVlanPool(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req.Kind.Kind != "VlanPool" {
+		return denied(fmt.Errorf("unexpected kind %q", req.Kind.Kind))
+	}
+
+	pool := &samplev1alpha1.VlanPool{}
+	switch req.Operation {
+	case admissionv1.Create:
+		if err := json.Unmarshal(req.Object.Raw, pool); err != nil {
+			return denied(err)
+		}
+		if errs := validation.ValidateVlanPool(pool); len(errs) != 0 {
+			return denied(apierrors.NewInvalid(samplev1alpha1.Kind("VlanPool"), pool.Name, errs))
+		}
+	case admissionv1.Update:
+		oldPool := &samplev1alpha1.VlanPool{}
+		if err := json.Unmarshal(req.Object.Raw, pool); err != nil {
+			return denied(err)
+		}
+		if err := json.Unmarshal(req.OldObject.Raw, oldPool); err != nil {
+			return denied(err)
+		}
+		if errs := validation.ValidateVlanPoolUpdate(pool, oldPool); len(errs) != 0 {
+			return denied(apierrors.NewInvalid(samplev1alpha1.Kind("VlanPool"), pool.Name, errs))
+		}
+	}
+	return allowed()
+}
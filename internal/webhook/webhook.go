@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+)
+
+const (
+	TLS_CERT_FILE = "tls.crt"
+	TLS_KEY_FILE  = "tls.key"
+)
+
+// admitFunc handles a single AdmissionRequest.
+type admitFunc func(*admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse
+
+// NewHandler returns the handler serving every webhook of the manager.
+func NewHandler(defaults Defaults) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(DEFAULT_VIRTUALROUTER_PATH, serve(defaults.defaultVirtualRouter))
+	mux.Handle(VALIDATE_VIRTUALROUTER_PATH, serve(validateVirtualRouter))
+	mux.Handle(VALIDATE_IPPOOL_PATH, serve(validateIPPool))
+	mux.Handle(VALIDATE_VLANPOOL_PATH, serve(validateVlanPool))
+	mux.HandleFunc(CONVERT_VIRTUALROUTER_PATH, convertVirtualRouters)
+	return mux
+}
+
+// Serve serves handler over TLS on addr until stopCh is closed, using the
+// tls.crt and tls.key found in certDir. An empty addr disables the server.
+func Serve(addr string, certDir string, handler http.Handler, stopCh <-chan struct{}) error {
+	if addr == "" || addr == "0" {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(filepath.Join(certDir, TLS_CERT_FILE), filepath.Join(certDir, TLS_KEY_FILE))
+	if err != nil {
+		return fmt.Errorf("failed to load webhook certificate from %s: %v", certDir, err)
+	}
+
+	server := &http.Server{
+		Addr:      addr,
+		Handler:   handler,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+	}
+	go func() {
+		<-stopCh
+		server.Shutdown(context.Background())
+	}()
+	go func() {
+		klog.Infof("Serving webhooks on %s", addr)
+		if err := server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+			klog.Errorf("Error serving webhooks on %s: %s", addr, err.Error())
+		}
+	}()
+	return nil
+}
+
+// serve decodes the AdmissionReview, runs admit and writes back the response.
+func serve(admit admitFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if contentType := r.Header.Get("Content-Type"); contentType != "application/json" {
+			http.Error(w, fmt.Sprintf("unsupported content type %q", contentType), http.StatusUnsupportedMediaType)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		review := admissionv1.AdmissionReview{}
+		if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+			http.Error(w, fmt.Sprintf("failed to decode AdmissionReview: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		response := admit(review.Request)
+		response.UID = review.Request.UID
+		review.Response = response
+		review.Request = nil
+
+		if err := json.NewEncoder(w).Encode(&review); err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to write AdmissionReview: %v", err))
+		}
+	}
+}
+
+func allowed() *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{Allowed: true}
+}
+
+func denied(err error) *admissionv1.AdmissionResponse {
+	status := apierrors.NewBadRequest(err.Error()).Status()
+	if statusErr, ok := err.(apierrors.APIStatus); ok {
+		status = statusErr.Status()
+	}
+	return &admissionv1.AdmissionResponse{Allowed: false, Result: &status}
+}